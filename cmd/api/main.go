@@ -2,18 +2,39 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/cko-recruitment/payment-gateway-challenge-go/docs"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/api"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/audit"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/certs"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/config"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/currency"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/fraud"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/health"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/lists"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/logging"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/metrics"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/outbox"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/threeds"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/tracing"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/webhooks"
 )
 
 var (
@@ -29,10 +50,36 @@ var (
 //	@BasePath	/
 
 // @securityDefinitions.basic	BasicAuth
+
+// @securityDefinitions.apikey	MerchantAPIKey
+// @in							header
+// @name						Authorization
+// @description				Merchant API key, as "Bearer <key>", required when AUTH_MERCHANT_API_KEYS is set.
+
+// @securityDefinitions.apikey	OperatorAPIKey
+// @in							header
+// @name						Authorization
+// @description				Operator API key, as "Bearer <key>", from AUTH_OPERATOR_API_KEYS.
 func main() {
-	conf, err := config.LoadConfig()
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"),
+		"YAML, JSON or TOML config file, environment variables take precedence over it")
+	printConfig := flag.Bool("print-config", false, "print the effective config, secrets masked, and exit")
+	flag.Parse()
+
+	conf, err := config.LoadConfig(*configFile)
 	if err != nil {
-		log.Fatalf("error loading environment variables: %v", err)
+		log.Fatalf("error loading the config: %v", err)
+	}
+
+	if *printConfig {
+		if err := conf.Print(os.Stdout); err != nil {
+			log.Fatalf("error printing the config: %v", err)
+		}
+		return
+	}
+
+	if err := conf.Validate(); err != nil {
+		log.Fatalf("error validating the config: %v", err)
 	}
 
 	fmt.Printf("version %s, commit %s, built at %s\n", version, commit, date)
@@ -57,15 +104,370 @@ func main() {
 		}
 	}()
 
-	paymentsRepository := repository.NewPaymentsRepositoryInMemory()
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Exporter(conf.Tracing.Exporter),
+		tracing.WithService(conf.App.Name, version),
+		tracing.WithSampleRatio(conf.Tracing.SampleRatio),
+		tracing.WithOTLPEndpoint(conf.Tracing.OTLPEndpoint),
+	)
+	if err != nil {
+		log.Fatalf("error setting up tracing: %v", err)
+	}
+	defer func() {
+		// ctx is already cancelled here, give pending spans a moment to flush.
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			fmt.Printf("error flushing traces: %v\n", err)
+		}
+	}()
+
+	logLevel := new(slog.LevelVar)
+	level, err := logging.ParseLevel(conf.App.LogLevel)
+	if err != nil {
+		log.Fatalf("error loading the log level: %v", err)
+	}
+	logLevel.Set(level)
+
+	logOutput, closeLogOutput, err := logging.OpenOutput(conf.App.LogOutput)
+	if err != nil {
+		log.Fatalf("error opening the log output: %v", err)
+	}
+	defer closeLogOutput()
+
+	logHandler, err := logging.NewHandler(logOutput, logging.Format(conf.App.LogFormat), logLevel)
+	if err != nil {
+		log.Fatalf("error setting up the logger: %v", err)
+	}
+	// Request loggers carry the trace IDs of their request already, other
+	// records get them from the context they are logged with.
+	slog.SetDefault(slog.New(tracing.NewLogHandler(logHandler)))
+
+	clk := clock.New()
+	paymentsRepository := repository.NewPaymentsRepositoryInMemory(repository.WithClock(clk))
+
+	appMetrics := metrics.New()
+
+	bankHTTPClient, bankCertReloader, err := simulator.NewHTTPClient(simulator.HTTPClientConfig{
+		Timeout:        conf.BankSimulator.Timeout,
+		ClientCertFile: conf.BankSimulator.ClientCertFile,
+		ClientKeyFile:  conf.BankSimulator.ClientKeyFile,
+		CAFile:         conf.BankSimulator.CAFile,
+		PinnedKeys:     conf.BankSimulator.PinnedKeys,
+	})
+	if err != nil {
+		log.Fatalf("error setting up the bank connection: %v", err)
+	}
+	if bankCertReloader != nil {
+		go bankCertReloader.Watch(ctx, conf.TLS.ReloadInterval)
+	}
+
+	var bankOpts []simulator.ClientOption
+	if conf.BankSimulator.SigningKey != "" {
+		key, err := hex.DecodeString(conf.BankSimulator.SigningKey)
+		if err != nil {
+			log.Fatalf("error decoding the bank signing key: %v", err)
+		}
+		bankOpts = append(bankOpts, simulator.WithSigner(simulator.NewHMACSigner(conf.BankSimulator.SigningKeyID, key, simulator.WithSignerClock(clk))))
+	}
+
+	bankClient := simulator.NewClient(conf.BankSimulator.URL, bankHTTPClient, bankOpts...)
+	bankBreaker := simulator.NewCircuitBreaker(bankClient, simulator.BreakerPolicy{
+		FailureThreshold: conf.BankSimulator.BreakerFailures,
+		Cooldown:         conf.BankSimulator.BreakerCooldown,
+	}, simulator.WithBreakerClock(clk))
+	bankSimulator := appMetrics.InstrumentBank(bankBreaker)
+	enabledCurrencies, err := currency.NewEnabled(
+		conf.Payments.Currencies,
+		conf.Payments.MerchantCurrencyCodes(),
+	)
+	if err != nil {
+		log.Fatalf("error loading enabled currencies: %v", err)
+	}
+
+	expiryLocation, err := time.LoadLocation(conf.Payments.ExpiryTimezone)
+	if err != nil {
+		log.Fatalf("error loading card expiry timezone: %v", err)
+	}
+
+	webhooksRepository := repository.NewWebhooksRepositoryInMemory()
+	webhooksOpts := []webhooks.ServiceOption{webhooks.WithServiceClock(clk)}
+	webhooksClient := webhooks.NewHTTPClient(conf.Webhooks.Timeout)
+	if conf.Webhooks.AllowPrivateNetworks {
+		webhooksOpts = append(webhooksOpts, webhooks.WithPrivateNetworks())
+		webhooksClient = &http.Client{Timeout: conf.Webhooks.Timeout}
+	}
+	webhooksSvc := webhooks.NewService(webhooksRepository, webhooksOpts...)
+	dispatcher := webhooks.NewDispatcher(
+		webhooksRepository,
+		webhooks.WithDispatcherClock(clk),
+		webhooks.WithHTTPClient(webhooksClient),
+		webhooks.WithWorkers(conf.Webhooks.Workers),
+		webhooks.WithRetryPolicy(webhooks.RetryPolicy{
+			MaxAttempts:     conf.Webhooks.MaxAttempts,
+			InitialInterval: conf.Webhooks.RetryInitialInterval,
+			MaxInterval:     conf.Webhooks.RetryMaxInterval,
+		}),
+	)
+
+	auditOpts := []audit.Option{audit.WithClock(clk)}
+	if conf.Audit.ChainKey != "" {
+		key, err := hex.DecodeString(conf.Audit.ChainKey)
+		if err != nil {
+			log.Fatalf("error decoding the audit chain key: %v", err)
+		}
+		auditOpts = append(auditOpts, audit.WithChainKey(key))
+	} else {
+		fmt.Printf("AUDIT_CHAIN_KEY is not set, the audit trail hashes are not keyed\n")
+	}
+	auditTrail := audit.NewTrail(repository.NewAuditRepositoryInMemory(), auditOpts...)
 
-	bankSimulator := simulator.NewClient(conf.BankSimulator.URL, nil)
-	paymentsSvc := payments.NewService(paymentsRepository, bankSimulator)
+	var acs *threeds.Simulator
+	paymentsOpts := []payments.Option{
+		payments.WithClock(clk),
+		payments.WithAuditTrail(auditTrail),
+		payments.WithObserver(appMetrics),
+		payments.WithEnabledCurrencies(enabledCurrencies),
+		payments.WithExpiryPolicy(payments.ExpiryPolicy{
+			Location: expiryLocation,
+			Grace:    conf.Payments.ExpiryGrace,
+		}),
+		payments.WithAuthorizationTTL(conf.Payments.AuthorizationTTL),
+		payments.WithReviewSLA(conf.Payments.ReviewSLA),
+		payments.WithAVSPolicy(payments.AVSPolicy{
+			VoidOnMismatch: conf.Payments.AVSVoidOnMismatch,
+			Merchants:      conf.Payments.MerchantAVSVoidOnMismatch,
+		}),
+	}
 
-	paymentsHandler := api.NewPaymentsHandler(paymentsSvc)
-	api := api.New(paymentsHandler)
+	if conf.ThreeDS.Enabled {
+		acs = threeds.NewSimulator(conf.ThreeDS.ACSURL,
+			threeds.WithSessionTTL(conf.ThreeDS.AuthenticationTTL),
+			threeds.WithSimulatorClock(clk),
+		)
+		paymentsOpts = append(paymentsOpts, payments.WithAuthenticationTTL(conf.ThreeDS.AuthenticationTTL))
+		paymentsOpts = append(paymentsOpts, payments.WithThreeDS(
+			acs,
+			conf.ThreeDS.CallbackURL,
+			payments.SCAPolicy{
+				Required:  conf.ThreeDS.SCARequired,
+				Merchants: conf.ThreeDS.MerchantSCARequired,
+			},
+		))
+		paymentsOpts = append(paymentsOpts, payments.WithExemptions(
+			payments.NewExemptionEngine(payments.ExemptionPolicy{
+				LowValueLimits: conf.ThreeDS.LowValueLimits,
+				TRALimits:      conf.ThreeDS.TRALimits,
+				TRAMerchants:   conf.ThreeDS.TRAMerchants,
+			}),
+		))
+	}
+
+	if conf.Payments.CardFingerprintKey != "" {
+		key, err := hex.DecodeString(conf.Payments.CardFingerprintKey)
+		if err != nil {
+			log.Fatalf("error decoding the card fingerprint key: %v", err)
+		}
+		paymentsOpts = append(paymentsOpts, payments.WithCardFingerprintKey(key))
+	} else {
+		fmt.Printf("PAYMENTS_CARD_FINGERPRINT_KEY is not set, card fingerprints will change on restart\n")
+	}
+
+	listsSvc := lists.NewService(repository.NewListsRepositoryInMemory(), lists.WithClock(clk))
+	paymentsOpts = append(paymentsOpts, payments.WithLists(listsSvc))
+
+	var fraudEngine *fraud.Engine
+	if conf.Fraud.RulesFile != "" {
+		rules, err := fraud.LoadRules(conf.Fraud.RulesFile)
+		if err != nil {
+			log.Fatalf("error loading fraud rules: %v", err)
+		}
+		if err := rules.CheckVelocityRetention(conf.Fraud.VelocityRetention); err != nil {
+			log.Fatalf("error checking fraud rules against FRAUD_VELOCITY_RETENTION: %v", err)
+		}
+		counters := repository.NewVelocityCountersInMemory(conf.Fraud.VelocityRetention)
+		fraudEngine = fraud.NewEngine(rules, counters,
+			fraud.WithClock(clk),
+			fraud.WithVelocityRetention(conf.Fraud.VelocityRetention),
+		)
+		paymentsOpts = append(paymentsOpts, payments.WithFraudScreening(fraudEngine))
+	}
+
+	if conf.Payments.AsyncEnabled {
+		sealer, err := newCardSealer(conf.Payments.CardEncryptionKey)
+		if err != nil {
+			log.Fatalf("error setting up the card sealer: %v", err)
+		}
+		paymentsOpts = append(paymentsOpts, payments.WithAsyncAuthorization(
+			paymentsRepository,
+			sealer,
+			payments.AsyncPolicy{
+				QueueSize:  conf.Payments.AsyncQueueSize,
+				RetryDelay: conf.Payments.AsyncRetryDelay,
+			},
+		))
+	}
+
+	paymentsSvc := payments.NewService(paymentsRepository, bankSimulator, paymentsOpts...)
+
+	go paymentsSvc.RunAuthorizationExpiry(ctx, conf.Payments.ExpirySweepInterval)
+	go paymentsSvc.RunReviewExpiry(ctx, conf.Payments.ReviewSweepInterval)
+	if conf.ThreeDS.Enabled {
+		go paymentsSvc.RunAuthenticationExpiry(ctx, conf.Payments.ExpirySweepInterval)
+	}
+	go paymentsSvc.RunAuthorizationWorkers(ctx, conf.Payments.AsyncWorkers)
+	if fraudEngine != nil {
+		go fraudEngine.Watch(ctx, conf.Fraud.RulesFile, conf.Fraud.ReloadInterval)
+	}
+	go dispatcher.Run(ctx, conf.Webhooks.DispatchInterval)
+
+	publishers := outbox.Publishers{"webhooks": webhooksSvc}
+	for _, name := range conf.Outbox.Publishers {
+		switch name {
+		case "file":
+			filePublisher, err := outbox.NewFilePublisher(conf.Outbox.FilePath)
+			if err != nil {
+				log.Fatalf("error setting up the outbox file publisher: %v", err)
+			}
+			defer filePublisher.Close()
+			publishers[name] = filePublisher
+		case "http":
+			publishers[name] = outbox.NewHTTPPublisher(conf.Outbox.HTTPURL, &http.Client{Timeout: conf.Outbox.HTTPTimeout})
+		default:
+			log.Fatalf("unknown outbox publisher %q", name)
+		}
+	}
+
+	// The relay belongs in cmd/worker, but the outbox lives in the in-memory
+	// repository of this process: it stays here until payments are stored
+	// in a database both processes can reach.
+	relay := outbox.NewRelay(
+		paymentsRepository,
+		publishers,
+		outbox.WithClock(clk),
+		outbox.WithBatchSize(conf.Outbox.BatchSize),
+		outbox.WithRetention(conf.Outbox.Retention),
+	)
+	go relay.Run(ctx, conf.Outbox.RelayInterval)
+
+	paymentsHandler := api.NewPaymentsHandler(paymentsSvc, api.WithMaxBodyBytes(conf.App.APIMaxBodyBytes))
+	webhooksHandler := api.NewWebhooksHandler(webhooksSvc)
+	listsHandler := api.NewListsHandler(listsSvc)
+	readiness := health.NewReadiness(
+		health.WithCheck("repository", health.CheckerFunc(paymentsRepository.Ping)),
+		health.WithCheck("bank", health.CheckerFunc(bankClient.Health)),
+		health.WithCheck("bank_circuit", bankBreaker),
+	)
+	apiOpts := []api.Option{
+		api.WithMiddlewares(appMetrics.Middleware),
+		api.WithReadiness(readiness),
+		api.WithServerTimeouts(api.ServerTimeouts{
+			ReadHeader: conf.App.APIReadHeaderTimeout,
+			Read:       conf.App.APIReadTimeout,
+			Write:      conf.App.APIWriteTimeout,
+			Idle:       conf.App.APIIdleTimeout,
+			Handler:    conf.App.APIHandlerTimeout,
+		}),
+		api.WithMaxHeaderBytes(conf.App.APIMaxHeaderBytes),
+		api.WithShutdownPolicy(api.ShutdownPolicy{
+			ReadinessDelay: conf.App.ShutdownReadinessDelay,
+			DrainTimeout:   conf.App.ShutdownDrainTimeout,
+		}),
+		api.WithDrainers(paymentsSvc),
+		api.WithRequestLogger(slog.New(logHandler), logLevel),
+		api.WithAuditTrail(auditTrail),
+	}
+
+	merchantKeys, err := conf.Auth.MerchantKeys()
+	if err != nil {
+		log.Fatalf("error loading the merchant API keys: %v", err)
+	}
+	if len(merchantKeys) > 0 {
+		apiOpts = append(apiOpts, api.WithMerchantAPIKeys(merchantKeys))
+	}
+
+	operatorKeys, err := conf.Auth.OperatorKeys()
+	if err != nil {
+		log.Fatalf("error loading the operator API keys: %v", err)
+	}
+	apiOpts = append(apiOpts, api.WithOperatorAPIKeys(operatorKeys))
+
+	if conf.TLS.Enabled() {
+		tlsConfig, reloader, err := newServerTLS(conf.TLS)
+		if err != nil {
+			log.Fatalf("error setting up TLS: %v", err)
+		}
+		go reloader.Watch(ctx, conf.TLS.ReloadInterval)
+
+		apiOpts = append(apiOpts, api.WithTLS(tlsConfig), api.WithHSTS(conf.TLS.HSTSMaxAge))
+		if conf.TLS.ClientCAFile != "" {
+			apiOpts = append(apiOpts, api.WithCertificateMerchants(conf.TLS.ClientMerchants))
+		}
+	}
+
+	api := api.New(paymentsHandler, webhooksHandler, listsHandler, apiOpts...)
+	if acs != nil {
+		api.Mount("/acs", http.StripPrefix("/acs", acs.Handler()))
+	}
+
+	go func() {
+		if err := appMetrics.Run(ctx, ":"+conf.App.MetricsPort, metrics.ServerTimeouts{
+			ReadHeader: conf.App.APIReadHeaderTimeout,
+			Read:       conf.App.APIReadTimeout,
+			Write:      conf.App.APIWriteTimeout,
+			Idle:       conf.App.APIIdleTimeout,
+		}); err != nil {
+			log.Printf("error running the metrics server: %v", err)
+		}
+	}()
 
 	if err := api.Run(ctx, ":"+conf.App.APIPort); err != nil {
 		log.Fatalf("error setup the API: %v", err)
 	}
 }
+
+// newServerTLS loads the certificate of the API, and the authorities of the
+// client certificates when mutual TLS is configured.
+func newServerTLS(conf config.TLSConfig) (*tls.Config, *certs.Reloader, error) {
+	reloader, err := certs.NewReloader(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if conf.ClientCAFile != "" {
+		tlsConfig.ClientCAs, err = certs.LoadCertPool(conf.ClientCAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load client CA: %w", err)
+		}
+
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		if conf.ClientAuth == "optional" {
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+
+	return tlsConfig, reloader, nil
+}
+
+// newCardSealer decodes the configured key, falling back to a random one.
+func newCardSealer(hexKey string) (*payments.CardSealer, error) {
+	if hexKey == "" {
+		fmt.Printf("PAYMENTS_CARD_ENCRYPTION_KEY is not set, pending payments will not survive a restart\n")
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		return payments.NewCardSealer(key)
+	}
+
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode key: %w", err)
+	}
+
+	return payments.NewCardSealer(key)
+}
@@ -15,9 +15,245 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/payments": {
-            "post": {
-                "description": "Creates a new payment and authorizes it with the bank\nAmount must be expressed in minor units of the currency (e.g. 1099 = $10.99 USD).\nThe only currencies supported for now are USD, EUR, and BRL (ISO 4217).",
+        "/api/v1/admin/audit": {
+            "get": {
+                "security": [
+                    {
+                        "OperatorAPIKey": []
+                    }
+                ],
+                "description": "Lists the recorded state-changing calls matching the filters, oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Query the audit trail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Actor who made the change",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Merchant the change was made for",
+                        "name": "merchant_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Method and route, e.g. POST /api/v1/payments",
+                        "name": "operation",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payment changed",
+                        "name": "payment_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earliest entry, RFC 3339 timestamp, inclusive",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest entry, RFC 3339 timestamp, exclusive",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries returned",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/audit.Entry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/audit/export": {
+            "get": {
+                "security": [
+                    {
+                        "OperatorAPIKey": []
+                    }
+                ],
+                "description": "Downloads the entries matching the filters as NDJSON, one entry per line, oldest first.",
+                "produces": [
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Export the audit trail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Actor who made the change",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Merchant the change was made for",
+                        "name": "merchant_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Method and route, e.g. POST /api/v1/payments",
+                        "name": "operation",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payment changed",
+                        "name": "payment_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earliest entry, RFC 3339 timestamp, inclusive",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest entry, RFC 3339 timestamp, exclusive",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/audit.Entry"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/audit/verify": {
+            "get": {
+                "security": [
+                    {
+                        "OperatorAPIKey": []
+                    }
+                ],
+                "description": "Checks every entry matches its hash and follows the previous one, detecting entries altered, removed or reordered.\nWith AUDIT_CHAIN_KEY set, the hashes are keyed, so a trail rebuilt without the key does not verify either.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Verify the audit trail",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.auditVerification"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/log-level": {
+            "get": {
+                "security": [
+                    {
+                        "OperatorAPIKey": []
+                    }
+                ],
+                "description": "Returns the minimum level of the records logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get the log level",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.logLevel"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "OperatorAPIKey": []
+                    }
+                ],
+                "description": "Changes the minimum level of the records logged, effective immediately and until the next restart.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,17 +261,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "payments"
+                    "admin"
                 ],
-                "summary": "Create a payment",
+                "summary": "Change the log level",
                 "parameters": [
                     {
-                        "description": "Payment request",
+                        "description": "New log level",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/payments.PaymentRequest"
+                            "$ref": "#/definitions/api.logLevel"
                         }
                     }
                 ],
@@ -43,7 +279,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/payments.Payment"
+                            "$ref": "#/definitions/api.logLevel"
                         }
                     },
                     "400": {
@@ -52,8 +288,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.ErrorResponseBody"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponseBody"
                         }
@@ -61,19 +297,75 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/payments/{id}": {
+        "/api/v1/admin/reviews": {
             "get": {
-                "description": "Retrieves a payment by its unique identifier",
-                "consumes": [
+                "security": [
+                    {
+                        "OperatorAPIKey": []
+                    }
+                ],
+                "description": "Lists the payments of every merchant flagged by the fraud screening and waiting for a manual review, oldest first.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "List payments held for review",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of payments returned",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.OperatorPayment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/reviews/{id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "OperatorAPIKey": []
+                    }
+                ],
+                "description": "Captures the payment. The authenticated operator is recorded as the reviewer.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "payments"
+                    "reviews"
                 ],
-                "summary": "Get payment by ID",
+                "summary": "Approve a payment held for review",
                 "parameters": [
                     {
                         "type": "string",
@@ -87,48 +379,1910 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/payments.Payment"
+                            "$ref": "#/definitions/api.OperatorPayment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/reviews/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "OperatorAPIKey": []
+                    }
+                ],
+                "description": "Voids the payment. The authenticated operator is recorded as the reviewer.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "Reject a payment held for review",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OperatorPayment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/lists/entries": {
+            "get": {
+                "security": [
+                    {
+                        "OperatorAPIKey": []
+                    }
+                ],
+                "description": "Lists the entries matching the filters, oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lists"
+                ],
+                "summary": "List list entries",
+                "parameters": [
+                    {
+                        "enum": [
+                            "block",
+                            "allow"
+                        ],
+                        "type": "string",
+                        "description": "List",
+                        "name": "list",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "card_fingerprint",
+                            "bin",
+                            "country",
+                            "merchant_reference"
+                        ],
+                        "type": "string",
+                        "description": "Kind",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Value",
+                        "name": "value",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Merchant the entry is limited to",
+                        "name": "merchant_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only the entries not expired yet",
+                        "name": "active",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/lists.Entry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "OperatorAPIKey": []
+                    }
+                ],
+                "description": "Blocks, or always allows, the payments matching a card fingerprint, BIN prefix, billing country or merchant reference.\nAllowlist entries, of card fingerprints and BINs only, skip the fraud rules and the other blocklists, never a card or BIN blocklist entry. The authenticated operator is recorded as the author of the entry.\nOperators acting for a merchant, with the X-Merchant-ID header, can only add blocklist entries limited to that merchant.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lists"
+                ],
+                "summary": "Add a list entry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant the operator acts for",
+                        "name": "X-Merchant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Entry",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateListEntryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lists.Entry"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/lists/entries/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "OperatorAPIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lists"
+                ],
+                "summary": "Get a list entry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lists.Entry"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "OperatorAPIKey": []
+                    }
+                ],
+                "description": "Replaces the reason and expiry of an entry. The authenticated operator is recorded as the author of the change.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lists"
+                ],
+                "summary": "Update a list entry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Changes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateListEntryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lists.Entry"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "OperatorAPIKey": []
+                    }
+                ],
+                "tags": [
+                    "lists"
+                ],
+                "summary": "Delete a list entry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Entry deleted"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/payments": {
+            "get": {
+                "security": [
+                    {
+                        "MerchantAPIKey": []
+                    }
+                ],
+                "description": "Lists the payments of the merchant matching the given reference and metadata, oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payments"
+                ],
+                "summary": "Search payments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant identifier",
+                        "name": "X-Merchant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Merchant reference",
+                        "name": "reference",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Metadata filter in key:value format",
+                        "name": "metadata",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of payments returned",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/payments.Payment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "MerchantAPIKey": []
+                    }
+                ],
+                "description": "Creates a new payment and authorizes it with the bank\nAmount must be expressed in minor units of the currency (e.g. 1099 = $10.99 USD).\nAny ISO 4217 currency can be used as long as it is enabled for the merchant (USD, EUR, and BRL by default).\nSend \"Prefer: respond-async\" to have the payment persisted as pending and authorized in the background:\nthe response is then 202 with a Location header to poll, when asynchronous processing is enabled.\nA payment whose authorization was interrupted before the bank answered is answered with 504,\nwith a Location header to poll: it stays pending until reconciled with the bank and must not be retried.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payments"
+                ],
+                "summary": "Create a payment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant identifier",
+                        "name": "X-Merchant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "respond-async to authorize the payment asynchronously",
+                        "name": "Prefer",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Payment request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payments.PaymentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/payments.Payment"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/payments.Payment"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the payment"
+                            },
+                            "Preference-Applied": {
+                                "type": "string",
+                                "description": "respond-async"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/payments.Payment"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the payment"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/payments/3ds/callback": {
+            "post": {
+                "description": "Callback posted by the cardholder's browser once the 3-D Secure challenge is completed.\nSuccessful authentications are sent to the bank for authorization, failed ones decline the payment.\nOnly the resulting status is returned, the callback is not authenticated.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payments"
+                ],
+                "summary": "Complete 3-D Secure authentication",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "3-D Secure transaction ID",
+                        "name": "transaction_id",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ThreeDSCallbackResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/payments/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "MerchantAPIKey": []
+                    }
+                ],
+                "description": "Retrieves a payment by its unique identifier",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payments"
+                ],
+                "summary": "Get payment by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/payments.Payment"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/payments/{id}/events": {
+            "get": {
+                "security": [
+                    {
+                        "MerchantAPIKey": []
+                    }
+                ],
+                "description": "Server-Sent Events stream sending the current state of the payment, then every status change until the payment reaches a terminal status (declined, rejected, expired, voided, blocked or captured).\nEach event is named after the status, e.g. \"payment.authorized\", and carries the payment as data. Its ID is the status: a payment never goes through the same status twice,\nso reconnecting with the Last-Event-ID header skips the state already received. Comments are sent periodically as heartbeats.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "payments"
+                ],
+                "summary": "Stream payment status changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/payments.Payment"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/ping": {
+            "get": {
+                "description": "Simple health check endpoint used to verify service availability. Prefer /healthz and /readyz for probes.",
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "204": {
+                        "description": "Service is healthy"
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "MerchantAPIKey": []
+                    }
+                ],
+                "description": "Lists the delivery logs of the merchant, oldest first, including every attempt made.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant identifier",
+                        "name": "X-Merchant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Endpoint ID",
+                        "name": "endpoint_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Event ID",
+                        "name": "event_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "succeeded",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "Delivery status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of deliveries returned",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webhooks.Delivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/deliveries/{id}/redeliver": {
+            "post": {
+                "security": [
+                    {
+                        "MerchantAPIKey": []
+                    }
+                ],
+                "description": "Schedules a new delivery of the event to the same endpoint, attempted right away.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Redeliver a webhook event",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant identifier",
+                        "name": "X-Merchant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/webhooks.Delivery"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/endpoints": {
+            "get": {
+                "security": [
+                    {
+                        "MerchantAPIKey": []
+                    }
+                ],
+                "description": "Lists the webhook endpoints of the merchant, oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhook endpoints",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant identifier",
+                        "name": "X-Merchant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webhooks.Endpoint"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "MerchantAPIKey": []
+                    }
+                ],
+                "description": "Registers an URL receiving the payment events of the merchant.\nThe signing secret is only returned in this response, use it to verify the X-Webhook-Signature header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Register a webhook endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant identifier",
+                        "name": "X-Merchant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Endpoint",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateEndpointRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/webhooks.Endpoint"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/endpoints/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "MerchantAPIKey": []
+                    }
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a webhook endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant identifier",
+                        "name": "X-Merchant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Endpoint ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Endpoint deleted"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Reports the process is alive and serving HTTP. Dependencies are not checked, so a failing bank never gets the gateway restarted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.liveness"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Checks the repository and the acquiring bank, and reports whether the gateway should receive traffic with the outcome of every check.\nNot ready once graceful shutdown started.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.CreateEndpointRequest": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "description": "Subscribed events, every event when empty.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/payments.EventType"
+                    },
+                    "example": [
+                        "payment.authorized",
+                        "payment.declined"
+                    ]
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://merchant.example/webhooks"
+                }
+            }
+        },
+        "api.CreateListEntryRequest": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "When the entry stops applying, never when empty.",
+                    "type": "string",
+                    "example": "2026-02-15T10:00:00Z"
+                },
+                "kind": {
+                    "enum": [
+                        "card_fingerprint",
+                        "bin",
+                        "country",
+                        "merchant_reference"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/lists.Kind"
+                        }
+                    ],
+                    "example": "bin"
+                },
+                "list": {
+                    "enum": [
+                        "block",
+                        "allow"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/lists.List"
+                        }
+                    ],
+                    "example": "block"
+                },
+                "merchant_id": {
+                    "description": "Limits the entry to a merchant, every merchant when empty.",
+                    "type": "string",
+                    "example": "merchant_123"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Card testing attack reported by the issuer"
+                },
+                "value": {
+                    "type": "string",
+                    "example": "411111"
+                }
+            }
+        },
+        "api.ErrorResponseBody": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.OperatorPayment": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Amount expressed in minor units of the given currency. Example: $10.99 USD → 1099",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "authorization_code": {
+                    "description": "Code returned by the bank for an authorization.",
+                    "type": "string",
+                    "example": "A1B2C3"
+                },
+                "authorized_at": {
+                    "description": "When the bank authorized the payment.",
+                    "type": "string",
+                    "example": "2026-01-15T10:00:00Z"
+                },
+                "avs_result": {
+                    "description": "Address Verification result returned by the bank.",
+                    "enum": [
+                        "Y",
+                        "A",
+                        "Z",
+                        "N",
+                        "U"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.AVSResult"
+                        }
+                    ],
+                    "example": "Y"
+                },
+                "billing_address": {
+                    "description": "Billing address of the cardholder.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.BillingAddress"
+                        }
+                    ]
+                },
+                "card_fingerprint": {
+                    "description": "Keyed fingerprint identifying the card without revealing its number.",
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "card_number_last_four": {
+                    "description": "Last four digits of the card number used in the payment.",
+                    "type": "string",
+                    "example": "8877"
+                },
+                "cardholder_name": {
+                    "description": "Name of the cardholder as printed on the card.",
+                    "type": "string",
+                    "example": "Jane Doe"
+                },
+                "created_at": {
+                    "description": "When the payment was created.",
+                    "type": "string",
+                    "example": "2026-01-15T10:00:00Z"
+                },
+                "currency": {
+                    "description": "Currency code in ISO 4217 format (e.g. USD, JPY, KWD).",
+                    "type": "string",
+                    "example": "USD"
+                },
+                "cvv_result": {
+                    "description": "Card verification value check result returned by the bank.",
+                    "enum": [
+                        "M",
+                        "N",
+                        "U"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.CVVResult"
+                        }
+                    ],
+                    "example": "M"
+                },
+                "description": {
+                    "description": "Free-text description of the payment.",
+                    "type": "string",
+                    "example": "Set of 3 masks"
+                },
+                "display_amount": {
+                    "description": "Amount formatted in major units using the currency exponent. Example: 1099 USD → \"10.99\"",
+                    "type": "string",
+                    "example": "10.00"
+                },
+                "expires_at": {
+                    "description": "When an uncaptured authorization expires.",
+                    "type": "string",
+                    "example": "2026-01-22T10:00:00Z"
+                },
+                "expiry_month": {
+                    "description": "Expiration month (1–12).",
+                    "type": "integer",
+                    "example": 12
+                },
+                "expiry_year": {
+                    "description": "Expiration year (four digits).",
+                    "type": "integer",
+                    "example": 2050
+                },
+                "id": {
+                    "description": "Unique identifier of the payment.",
+                    "type": "string",
+                    "example": "019ba901-48a1-7138-824e-d0e65a8dc38a"
+                },
+                "merchant_id": {
+                    "description": "Identifier of the merchant that created the payment.",
+                    "type": "string",
+                    "example": "merchant_123"
+                },
+                "metadata": {
+                    "description": "Merchant key/value pairs stored with the payment.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "coupon": "SUMMER",
+                        "order_channel": "web"
+                    }
+                },
+                "next_action": {
+                    "description": "Action the cardholder must take before the payment can proceed.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.NextAction"
+                        }
+                    ]
+                },
+                "reconciliation_required": {
+                    "description": "The authorization was interrupted: the payment stays pending until its outcome is reconciled with the bank.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "reference": {
+                    "description": "Merchant reference used to link the payment to an order.",
+                    "type": "string",
+                    "example": "ORD-5023-4E89"
+                },
+                "review": {
+                    "description": "Manual review of a payment held by the fraud screening.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.Review"
+                        }
+                    ]
+                },
+                "risk_outcome": {
+                    "description": "Outcome of the fraud screening.",
+                    "type": "string",
+                    "enum": [
+                        "allow",
+                        "review",
+                        "block"
+                    ],
+                    "example": "allow"
+                },
+                "risk_score": {
+                    "description": "Fraud risk score, from 0 to 100.",
+                    "type": "integer",
+                    "example": 20
+                },
+                "sca_exemption": {
+                    "description": "Strong customer authentication exemption requested to the bank.",
+                    "enum": [
+                        "low_value",
+                        "transaction_risk_analysis",
+                        "recurring",
+                        "merchant_initiated"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.Exemption"
+                        }
+                    ],
+                    "example": "low_value"
+                },
+                "statement_descriptor": {
+                    "description": "Text shown on the cardholder statement.",
+                    "type": "string",
+                    "example": "ACME*MASKS"
+                },
+                "status": {
+                    "description": "Current status of the payment.",
+                    "type": "string",
+                    "enum": [
+                        "authorized",
+                        "declined",
+                        "rejected",
+                        "pending",
+                        "expired",
+                        "voided",
+                        "requires_action",
+                        "blocked",
+                        "held_for_review",
+                        "captured"
+                    ],
+                    "example": "authorized"
+                },
+                "three_ds": {
+                    "description": "Outcome of the 3-D Secure authentication.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.ThreeDSOutcome"
+                        }
+                    ]
+                },
+                "updated_at": {
+                    "description": "When the payment was last changed.",
+                    "type": "string",
+                    "example": "2026-01-15T10:00:00Z"
+                }
+            }
+        },
+        "api.ThreeDSCallbackResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "authorized",
+                        "declined",
+                        "rejected",
+                        "pending",
+                        "expired",
+                        "voided",
+                        "requires_action",
+                        "blocked",
+                        "held_for_review",
+                        "captured"
+                    ],
+                    "example": "authorized"
+                }
+            }
+        },
+        "api.UpdateListEntryRequest": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "When the entry stops applying, never when empty.",
+                    "type": "string",
+                    "example": "2026-03-15T10:00:00Z"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Confirmed by the issuer"
+                }
+            }
+        },
+        "api.auditVerification": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "description": "Entries verified before the first broken one.",
+                    "type": "integer",
+                    "example": 42
+                },
+                "error": {
+                    "description": "Why the trail is not valid.",
+                    "type": "string"
+                },
+                "valid": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.liveness": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/health.Status"
+                        }
+                    ],
+                    "example": "up"
+                }
+            }
+        },
+        "api.logLevel": {
+            "type": "object",
+            "properties": {
+                "level": {
+                    "description": "One of debug, info, warn or error.",
+                    "type": "string",
+                    "example": "info"
+                }
+            }
+        },
+        "audit.Entry": {
+            "type": "object",
+            "properties": {
+                "actor_id": {
+                    "description": "Operator who made the change, authenticated by their API key, or SystemActor.",
+                    "type": "string",
+                    "example": "ops@example.com"
+                },
+                "after_status": {
+                    "description": "Status of the payment after the change.",
+                    "type": "string",
+                    "example": "captured"
+                },
+                "before_status": {
+                    "description": "Status of the payment before the change, empty when created.",
+                    "type": "string",
+                    "example": "held_for_review"
+                },
+                "hash": {
+                    "description": "HMAC-SHA256 of the entry with the chain key, SHA-256 without one, hex encoded.",
+                    "type": "string"
+                },
+                "merchant_id": {
+                    "description": "Merchant the change was made for, from X-Merchant-ID.",
+                    "type": "string",
+                    "example": "merchant_123"
+                },
+                "occurred_at": {
+                    "description": "When the operation completed.",
+                    "type": "string",
+                    "example": "2025-01-01T12:00:00Z"
+                },
+                "operation": {
+                    "description": "Method and route of the call, or the background operation.",
+                    "type": "string",
+                    "example": "POST /api/v1/admin/reviews/{id}/approve"
+                },
+                "payment_id": {
+                    "description": "Payment changed by the operation.",
+                    "type": "string",
+                    "example": "0190d9b1-7d4e-7bb1-9c4e-6f1d2a3b4c5d"
+                },
+                "prev_hash": {
+                    "description": "Hash of the previous entry, empty for the first one.",
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "resource_id": {
+                    "description": "ID in the route, if any.",
+                    "type": "string",
+                    "example": "0190d9b1-7d4e-7bb1-9c4e-6f1d2a3b4c5d"
+                },
+                "result": {
+                    "description": "HTTP status code of the call, 0 for background operations.",
+                    "type": "integer",
+                    "example": 200
+                },
+                "sequence": {
+                    "description": "Position in the trail, starting at 1.",
+                    "type": "integer",
+                    "example": 42
+                },
+                "source_ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                }
+            }
+        },
+        "health.CheckResult": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
+                    "description": "Time taken by the check.",
+                    "type": "integer",
+                    "example": 3
+                },
+                "error": {
+                    "description": "Why the dependency is down.",
+                    "type": "string"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/health.Status"
+                        }
+                    ],
+                    "example": "up"
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "description": "Outcome per dependency.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.CheckResult"
+                    }
+                },
+                "shutting_down": {
+                    "description": "Set once graceful shutdown started.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "status": {
+                    "description": "\"up\" when every dependency is up and the gateway is not shutting down.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/health.Status"
                         }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/api.ErrorResponseBody"
+                    ],
+                    "example": "up"
+                }
+            }
+        },
+        "health.Status": {
+            "type": "string",
+            "enum": [
+                "up",
+                "down"
+            ],
+            "x-enum-varnames": [
+                "StatusUp",
+                "StatusDown"
+            ]
+        },
+        "lists.Entry": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-01-15T10:00:00Z"
+                },
+                "created_by": {
+                    "description": "Who added the entry.",
+                    "type": "string",
+                    "example": "analyst@risk"
+                },
+                "expires_at": {
+                    "description": "When the entry stops applying, never when empty.",
+                    "type": "string",
+                    "example": "2026-02-15T10:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "019ba901-48a1-7138-824e-d0e65a8dc38a"
+                },
+                "kind": {
+                    "enum": [
+                        "card_fingerprint",
+                        "bin",
+                        "country",
+                        "merchant_reference"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/lists.Kind"
                         }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/api.ErrorResponseBody"
+                    ],
+                    "example": "bin"
+                },
+                "list": {
+                    "enum": [
+                        "block",
+                        "allow"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/lists.List"
                         }
-                    }
+                    ],
+                    "example": "block"
+                },
+                "merchant_id": {
+                    "description": "MerchantID limits the entry to the payments of a merchant, every\nmerchant when empty.",
+                    "type": "string",
+                    "example": "merchant_123"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Card testing attack reported by the issuer"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2026-01-15T10:00:00Z"
+                },
+                "updated_by": {
+                    "description": "Who last changed the entry.",
+                    "type": "string",
+                    "example": "analyst@risk"
+                },
+                "value": {
+                    "type": "string",
+                    "example": "411111"
                 }
             }
         },
-        "/api/v1/ping": {
-            "get": {
-                "description": "Simple health check endpoint used to verify service availability",
-                "tags": [
-                    "health"
-                ],
-                "summary": "Health check",
-                "responses": {
-                    "204": {
-                        "description": "Service is healthy"
-                    },
-                    "500": {
-                        "description": "Internal server error"
-                    }
+        "lists.Kind": {
+            "type": "string",
+            "enum": [
+                "card_fingerprint",
+                "bin",
+                "country",
+                "merchant_reference"
+            ],
+            "x-enum-comments": {
+                "KindBIN": "Prefix of the card number, 6 to 8 digits.",
+                "KindCardFingerprint": "card_fingerprint of the payment.",
+                "KindCountry": "Billing address country, ISO 3166-1 alpha-2.",
+                "KindMerchantReference": "reference of the payment."
+            },
+            "x-enum-descriptions": [
+                "card_fingerprint of the payment.",
+                "Prefix of the card number, 6 to 8 digits.",
+                "Billing address country, ISO 3166-1 alpha-2.",
+                "reference of the payment."
+            ],
+            "x-enum-varnames": [
+                "KindCardFingerprint",
+                "KindBIN",
+                "KindCountry",
+                "KindMerchantReference"
+            ]
+        },
+        "lists.List": {
+            "type": "string",
+            "enum": [
+                "block",
+                "allow"
+            ],
+            "x-enum-varnames": [
+                "Blocklist",
+                "Allowlist"
+            ]
+        },
+        "payments.AVSResult": {
+            "type": "string",
+            "enum": [
+                "Y",
+                "A",
+                "Z",
+                "N",
+                "U"
+            ],
+            "x-enum-comments": {
+                "AVSAddressMatch": "Street address matches, postal code does not.",
+                "AVSFullMatch": "Street address and postal code match.",
+                "AVSNoMatch": "Neither street address nor postal code match.",
+                "AVSPostalMatch": "Postal code matches, street address does not.",
+                "AVSUnavailable": "The issuer could not verify the address."
+            },
+            "x-enum-descriptions": [
+                "Street address and postal code match.",
+                "Street address matches, postal code does not.",
+                "Postal code matches, street address does not.",
+                "Neither street address nor postal code match.",
+                "The issuer could not verify the address."
+            ],
+            "x-enum-varnames": [
+                "AVSFullMatch",
+                "AVSAddressMatch",
+                "AVSPostalMatch",
+                "AVSNoMatch",
+                "AVSUnavailable"
+            ]
+        },
+        "payments.BillingAddress": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "description": "City or locality.",
+                    "type": "string",
+                    "example": "London"
+                },
+                "country": {
+                    "description": "Country code in ISO 3166-1 alpha-2 format.",
+                    "type": "string",
+                    "example": "GB"
+                },
+                "line1": {
+                    "description": "First address line.",
+                    "type": "string",
+                    "example": "1 Main Street"
+                },
+                "line2": {
+                    "description": "Second address line.",
+                    "type": "string",
+                    "example": "Apartment 2"
+                },
+                "postal_code": {
+                    "description": "Postal or ZIP code.",
+                    "type": "string",
+                    "example": "W1 8QS"
+                },
+                "state": {
+                    "description": "State, county or province.",
+                    "type": "string",
+                    "example": "Greater London"
                 }
             }
-        }
-    },
-    "definitions": {
-        "api.ErrorResponseBody": {
+        },
+        "payments.CVVResult": {
+            "type": "string",
+            "enum": [
+                "M",
+                "N",
+                "U"
+            ],
+            "x-enum-comments": {
+                "CVVMatch": "The CVV matches.",
+                "CVVNoMatch": "The CVV does not match.",
+                "CVVUnavailable": "The issuer could not check the CVV."
+            },
+            "x-enum-descriptions": [
+                "The CVV matches.",
+                "The CVV does not match.",
+                "The issuer could not check the CVV."
+            ],
+            "x-enum-varnames": [
+                "CVVMatch",
+                "CVVNoMatch",
+                "CVVUnavailable"
+            ]
+        },
+        "payments.EventType": {
+            "type": "string",
+            "enum": [
+                "payment.requires_action",
+                "payment.authorized",
+                "payment.declined",
+                "payment.rejected",
+                "payment.voided",
+                "payment.expired",
+                "payment.blocked",
+                "payment.held_for_review",
+                "payment.captured"
+            ],
+            "x-enum-varnames": [
+                "EventPaymentRequiresAction",
+                "EventPaymentAuthorized",
+                "EventPaymentDeclined",
+                "EventPaymentRejected",
+                "EventPaymentVoided",
+                "EventPaymentExpired",
+                "EventPaymentBlocked",
+                "EventPaymentHeldForReview",
+                "EventPaymentCaptured"
+            ]
+        },
+        "payments.Exemption": {
+            "type": "string",
+            "enum": [
+                "",
+                "low_value",
+                "transaction_risk_analysis",
+                "recurring",
+                "merchant_initiated"
+            ],
+            "x-enum-varnames": [
+                "ExemptionNone",
+                "ExemptionLowValue",
+                "ExemptionTRA",
+                "ExemptionRecurring",
+                "ExemptionMerchantInitiated"
+            ]
+        },
+        "payments.NextAction": {
             "type": "object",
             "properties": {
-                "error": {
-                    "type": "string"
+                "redirect_url": {
+                    "description": "Where the cardholder must be redirected.",
+                    "type": "string",
+                    "example": "http://localhost:8090/acs/challenges/3f0c5c1e-1b8a-4f55"
+                },
+                "type": {
+                    "description": "Kind of action required.",
+                    "type": "string",
+                    "example": "redirect_to_url"
                 }
             }
         },
@@ -140,20 +2294,88 @@ const docTemplate = `{
                     "type": "integer",
                     "example": 1000
                 },
+                "authorization_code": {
+                    "description": "Code returned by the bank for an authorization.",
+                    "type": "string",
+                    "example": "A1B2C3"
+                },
+                "authorized_at": {
+                    "description": "When the bank authorized the payment.",
+                    "type": "string",
+                    "example": "2026-01-15T10:00:00Z"
+                },
+                "avs_result": {
+                    "description": "Address Verification result returned by the bank.",
+                    "enum": [
+                        "Y",
+                        "A",
+                        "Z",
+                        "N",
+                        "U"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.AVSResult"
+                        }
+                    ],
+                    "example": "Y"
+                },
+                "billing_address": {
+                    "description": "Billing address of the cardholder.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.BillingAddress"
+                        }
+                    ]
+                },
                 "card_number_last_four": {
                     "description": "Last four digits of the card number used in the payment.",
                     "type": "string",
                     "example": "8877"
                 },
+                "cardholder_name": {
+                    "description": "Name of the cardholder as printed on the card.",
+                    "type": "string",
+                    "example": "Jane Doe"
+                },
+                "created_at": {
+                    "description": "When the payment was created.",
+                    "type": "string",
+                    "example": "2026-01-15T10:00:00Z"
+                },
                 "currency": {
-                    "description": "Currency code in ISO 4217 format (e.g. USD, EUR, BRL).",
+                    "description": "Currency code in ISO 4217 format (e.g. USD, JPY, KWD).",
                     "type": "string",
+                    "example": "USD"
+                },
+                "cvv_result": {
+                    "description": "Card verification value check result returned by the bank.",
                     "enum": [
-                        "USD",
-                        "EUR",
-                        "BRL"
+                        "M",
+                        "N",
+                        "U"
                     ],
-                    "example": "USD"
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.CVVResult"
+                        }
+                    ],
+                    "example": "M"
+                },
+                "description": {
+                    "description": "Free-text description of the payment.",
+                    "type": "string",
+                    "example": "Set of 3 masks"
+                },
+                "display_amount": {
+                    "description": "Amount formatted in major units using the currency exponent. Example: 1099 USD → \"10.99\"",
+                    "type": "string",
+                    "example": "10.00"
+                },
+                "expires_at": {
+                    "description": "When an uncaptured authorization expires.",
+                    "type": "string",
+                    "example": "2026-01-22T10:00:00Z"
                 },
                 "expiry_month": {
                     "description": "Expiration month (1–12).",
@@ -170,6 +2392,83 @@ const docTemplate = `{
                     "type": "string",
                     "example": "019ba901-48a1-7138-824e-d0e65a8dc38a"
                 },
+                "merchant_id": {
+                    "description": "Identifier of the merchant that created the payment.",
+                    "type": "string",
+                    "example": "merchant_123"
+                },
+                "metadata": {
+                    "description": "Merchant key/value pairs stored with the payment.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "coupon": "SUMMER",
+                        "order_channel": "web"
+                    }
+                },
+                "next_action": {
+                    "description": "Action the cardholder must take before the payment can proceed.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.NextAction"
+                        }
+                    ]
+                },
+                "reconciliation_required": {
+                    "description": "The authorization was interrupted: the payment stays pending until its outcome is reconciled with the bank.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "reference": {
+                    "description": "Merchant reference used to link the payment to an order.",
+                    "type": "string",
+                    "example": "ORD-5023-4E89"
+                },
+                "review": {
+                    "description": "Manual review of a payment held by the fraud screening.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.Review"
+                        }
+                    ]
+                },
+                "risk_outcome": {
+                    "description": "Outcome of the fraud screening.",
+                    "type": "string",
+                    "enum": [
+                        "allow",
+                        "review",
+                        "block"
+                    ],
+                    "example": "allow"
+                },
+                "risk_score": {
+                    "description": "Fraud risk score, from 0 to 100.",
+                    "type": "integer",
+                    "example": 20
+                },
+                "sca_exemption": {
+                    "description": "Strong customer authentication exemption requested to the bank.",
+                    "enum": [
+                        "low_value",
+                        "transaction_risk_analysis",
+                        "recurring",
+                        "merchant_initiated"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.Exemption"
+                        }
+                    ],
+                    "example": "low_value"
+                },
+                "statement_descriptor": {
+                    "description": "Text shown on the cardholder statement.",
+                    "type": "string",
+                    "example": "ACME*MASKS"
+                },
                 "status": {
                     "description": "Current status of the payment.",
                     "type": "string",
@@ -177,9 +2476,28 @@ const docTemplate = `{
                         "authorized",
                         "declined",
                         "rejected",
-                        "pending"
+                        "pending",
+                        "expired",
+                        "voided",
+                        "requires_action",
+                        "blocked",
+                        "held_for_review",
+                        "captured"
                     ],
                     "example": "authorized"
+                },
+                "three_ds": {
+                    "description": "Outcome of the 3-D Secure authentication.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.ThreeDSOutcome"
+                        }
+                    ]
+                },
+                "updated_at": {
+                    "description": "When the payment was last changed.",
+                    "type": "string",
+                    "example": "2026-01-15T10:00:00Z"
                 }
             }
         },
@@ -191,19 +2509,27 @@ const docTemplate = `{
                     "type": "integer",
                     "example": 1000
                 },
+                "billing_address": {
+                    "description": "Billing address of the cardholder, sent to the bank for fraud scoring.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.BillingAddress"
+                        }
+                    ]
+                },
                 "card_number": {
                     "description": "Card number containing between 14 and 19 digits.",
                     "type": "string",
                     "example": "2222405343248877"
                 },
+                "cardholder_name": {
+                    "description": "Name of the cardholder as printed on the card (up to 100 characters).",
+                    "type": "string",
+                    "example": "Jane Doe"
+                },
                 "currency": {
-                    "description": "Currency code in ISO 4217 format (e.g. USD, EUR, BRL).",
+                    "description": "Currency code in ISO 4217 format (e.g. USD, JPY, KWD).",
                     "type": "string",
-                    "enum": [
-                        "USD",
-                        "EUR",
-                        "BRL"
-                    ],
                     "example": "USD"
                 },
                 "cvv": {
@@ -211,6 +2537,11 @@ const docTemplate = `{
                     "type": "string",
                     "example": "123"
                 },
+                "description": {
+                    "description": "Free-text description (up to 255 characters).",
+                    "type": "string",
+                    "example": "Set of 3 masks"
+                },
                 "expiry_month": {
                     "description": "Expiration month (1–12).",
                     "type": "integer",
@@ -220,6 +2551,245 @@ const docTemplate = `{
                     "description": "Expiration year (four digits).",
                     "type": "integer",
                     "example": 2050
+                },
+                "merchant_initiated": {
+                    "description": "Payment initiated by the merchant without the cardholder being present.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "metadata": {
+                    "description": "Up to 20 key/value pairs (keys up to 40 and values up to 500 characters).",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "coupon": "SUMMER",
+                        "order_channel": "web"
+                    }
+                },
+                "recurring": {
+                    "description": "Subsequent payment of a recurring series already authenticated.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "reference": {
+                    "description": "Merchant reference (up to 50 characters).",
+                    "type": "string",
+                    "example": "ORD-5023-4E89"
+                },
+                "statement_descriptor": {
+                    "description": "Text shown on the cardholder statement (up to 22 characters).",
+                    "type": "string",
+                    "example": "ACME*MASKS"
+                },
+                "three_ds": {
+                    "description": "3-D Secure preferences for the payment.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.ThreeDSRequest"
+                        }
+                    ]
+                }
+            }
+        },
+        "payments.Review": {
+            "type": "object",
+            "properties": {
+                "deadline": {
+                    "description": "When the payment is voided if still not reviewed.",
+                    "type": "string",
+                    "example": "2026-01-16T10:00:00Z"
+                },
+                "decision": {
+                    "description": "Outcome of the review, empty while pending.",
+                    "enum": [
+                        "approved",
+                        "rejected",
+                        "expired"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/payments.ReviewDecision"
+                        }
+                    ],
+                    "example": "approved"
+                },
+                "reviewed_at": {
+                    "description": "When the decision was taken.",
+                    "type": "string",
+                    "example": "2026-01-15T12:00:00Z"
+                },
+                "reviewed_by": {
+                    "description": "Who took the decision.",
+                    "type": "string",
+                    "example": "analyst@risk"
+                }
+            }
+        },
+        "payments.ReviewDecision": {
+            "type": "string",
+            "enum": [
+                "approved",
+                "rejected",
+                "expired"
+            ],
+            "x-enum-comments": {
+                "ReviewApproved": "The payment was captured.",
+                "ReviewExpired": "Nobody reviewed it in time, it was voided.",
+                "ReviewRejected": "The payment was voided."
+            },
+            "x-enum-descriptions": [
+                "The payment was captured.",
+                "The payment was voided.",
+                "Nobody reviewed it in time, it was voided."
+            ],
+            "x-enum-varnames": [
+                "ReviewApproved",
+                "ReviewRejected",
+                "ReviewExpired"
+            ]
+        },
+        "payments.ThreeDSOutcome": {
+            "type": "object",
+            "properties": {
+                "eci": {
+                    "description": "Electronic Commerce Indicator.",
+                    "type": "string",
+                    "example": "05"
+                },
+                "status": {
+                    "description": "3-D Secure transaction status, empty while pending.",
+                    "type": "string",
+                    "enum": [
+                        "Y",
+                        "A",
+                        "N",
+                        "R"
+                    ],
+                    "example": "Y"
+                },
+                "transaction_id": {
+                    "description": "Identifier of the authentication at the ACS.",
+                    "type": "string",
+                    "example": "3f0c5c1e-1b8a-4f55"
+                }
+            }
+        },
+        "payments.ThreeDSRequest": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "description": "Force a 3-D Secure challenge before authorization, skipping exemptions.",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "webhooks.Attempt": {
+            "type": "object",
+            "properties": {
+                "attempted_at": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "description": "HTTP status returned by the endpoint, if any.",
+                    "type": "integer"
+                }
+            }
+        },
+        "webhooks.Delivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webhooks.Attempt"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "endpoint_id": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "$ref": "#/definitions/payments.EventType"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "merchant_id": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "description": "Set while the delivery is pending.",
+                    "type": "string"
+                },
+                "status": {
+                    "enum": [
+                        "pending",
+                        "succeeded",
+                        "failed"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/webhooks.DeliveryStatus"
+                        }
+                    ]
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "webhooks.DeliveryStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "succeeded",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "DeliveryPending",
+                "DeliverySucceeded",
+                "DeliveryFailed"
+            ]
+        },
+        "webhooks.Endpoint": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "description": "Subscribed events, every event when empty.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/payments.EventType"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "merchant_id": {
+                    "type": "string"
+                },
+                "secret": {
+                    "description": "Signing secret, only returned when the endpoint is created.",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
                 }
             }
         }
@@ -227,6 +2797,18 @@ const docTemplate = `{
     "securityDefinitions": {
         "BasicAuth": {
             "type": "basic"
+        },
+        "MerchantAPIKey": {
+            "description": "Merchant API key, as \"Bearer \u003ckey\u003e\", required when AUTH_MERCHANT_API_KEYS is set.",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
+        "OperatorAPIKey": {
+            "description": "Operator API key, as \"Bearer \u003ckey\u003e\", from AUTH_OPERATOR_API_KEYS.",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,114 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// testOperators are the operator API keys of the handler tests.
+var testOperators = map[string]string{
+	operatorKey("analyst"):   "analyst",
+	operatorKey("analyst_a"): "analyst_a",
+	operatorKey("analyst_b"): "analyst_b",
+	operatorKey("ops"):       "ops",
+}
+
+func operatorKey(operatorID string) string {
+	return "operator-key-of-" + operatorID
+}
+
+// asOperator authenticates the request as one of testOperators, or leaves
+// it anonymous when operatorID is empty.
+func asOperator(req *http.Request, operatorID string) {
+	if operatorID != "" {
+		req.Header.Set("Authorization", "Bearer "+operatorKey(operatorID))
+	}
+}
+
+func TestOperatorAPIKeys(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		authorization string
+		actorHeader   string
+		wantStatus    int
+		wantActorID   string
+	}{
+		{
+			name:       "without API key",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "claimed actor without API key",
+			actorHeader: "analyst",
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			name:          "unknown API key",
+			authorization: "Bearer " + operatorKey("mallory"),
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "known API key",
+			authorization: "Bearer " + operatorKey("analyst"),
+			wantStatus:    http.StatusOK,
+			wantActorID:   "analyst",
+		},
+		{
+			name:          "known API key with another claimed actor",
+			authorization: "Bearer " + operatorKey("analyst"),
+			actorHeader:   "ops",
+			wantStatus:    http.StatusOK,
+			wantActorID:   "analyst",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var actorID string
+			handler := api.OperatorAPIKeys(testOperators)(api.RequireOperator(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					actorID = api.ActorIDFromContext(r.Context())
+				}),
+			))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/reviews", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.actorHeader != "" {
+				req.Header.Set("X-Actor-ID", tt.actorHeader)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantActorID, actorID)
+		})
+	}
+}
+
+func TestRequireOperatorWithoutKeys(t *testing.T) {
+	t.Parallel()
+
+	handler := api.OperatorAPIKeys(nil)(api.RequireOperator(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/reviews", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+}
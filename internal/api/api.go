@@ -2,7 +2,7 @@ package api
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/tls"
 	"fmt"
 	"log/slog"
 	"net"
@@ -11,6 +11,9 @@ import (
 	"time"
 
 	"github.com/cko-recruitment/payment-gateway-challenge-go/docs"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/audit"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/health"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -21,11 +24,185 @@ import (
 type Api struct {
 	router          *chi.Mux
 	paymentsHandler *PaymentsHandler
+	webhooksHandler *WebhooksHandler
+	listsHandler    *ListsHandler
+
+	middlewares []func(http.Handler) http.Handler
+	readiness   *health.Readiness
+	logger      *slog.Logger
+	logLevel    *slog.LevelVar
+	auditTrail  *audit.Trail
+
+	timeouts       ServerTimeouts
+	maxHeaderBytes int
+
+	tlsConfig            *tls.Config
+	hstsMaxAge           time.Duration
+	certificateMerchants map[string]string
+	merchantAPIKeys      map[string]string
+	operatorAPIKeys      map[string]string
+	// certificateAuth is set when client certificates authenticate
+	// merchants, whether or not any certificate is assigned to one.
+	certificateAuth bool
+
+	shutdown ShutdownPolicy
+	drainers []Drainer
+	// draining is cancelled when the drain starts.
+	draining     context.Context
+	stopDraining context.CancelFunc
+}
+
+// ServerTimeouts bound how long the server waits on clients and handlers.
+type ServerTimeouts struct {
+	// ReadHeader bounds reading the request headers.
+	ReadHeader time.Duration
+	// Read bounds reading the whole request, body included.
+	Read time.Duration
+	// Write bounds the time from the end of the request headers to the end
+	// of the response. Event streams lift it, they stay open much longer.
+	Write time.Duration
+	// Idle bounds how long a keep-alive connection waits for the next
+	// request.
+	Idle time.Duration
+	// Handler bounds the handling of a request, after which the client gets
+	// a 503. It must be shorter than Write for the 503 to be sent.
+	Handler time.Duration
+}
+
+// DefaultServerTimeouts are used unless WithServerTimeouts is given.
+var DefaultServerTimeouts = ServerTimeouts{
+	ReadHeader: 5 * time.Second,
+	Read:       15 * time.Second,
+	Write:      35 * time.Second,
+	Idle:       120 * time.Second,
+	Handler:    30 * time.Second,
+}
+
+// Option configures optional settings of the Api.
+type Option func(*Api)
+
+// WithMiddlewares adds middlewares to every route, after the request has
+// been identified and logged but before panics are recovered.
+func WithMiddlewares(middlewares ...func(http.Handler) http.Handler) Option {
+	return func(a *Api) {
+		a.middlewares = append(a.middlewares, middlewares...)
+	}
+}
+
+// WithRequestLogger sets the logger of the requests. Its level is changed
+// at runtime through level, which must be the one the logger's handler reads.
+func WithRequestLogger(logger *slog.Logger, level *slog.LevelVar) Option {
+	return func(a *Api) {
+		a.logger = logger
+		a.logLevel = level
+	}
+}
+
+// WithAuditTrail records the state-changing calls in the trail and serves
+// it under /api/v1/admin/audit.
+func WithAuditTrail(trail *audit.Trail) Option {
+	return func(a *Api) {
+		a.auditTrail = trail
+	}
+}
+
+// WithServerTimeouts sets the timeouts of the server and its handlers.
+func WithServerTimeouts(timeouts ServerTimeouts) Option {
+	return func(a *Api) {
+		a.timeouts = timeouts
+	}
+}
+
+// WithMaxHeaderBytes limits the size of the request headers, request line
+// included. Larger requests get a 431.
+func WithMaxHeaderBytes(n int) Option {
+	return func(a *Api) {
+		a.maxHeaderBytes = n
+	}
+}
+
+// WithTLS serves the API over TLS only. The config must provide the
+// server certificate, e.g. through GetCertificate.
+func WithTLS(config *tls.Config) Option {
+	return func(a *Api) {
+		a.tlsConfig = config
+	}
+}
+
+// WithHSTS sends the Strict-Transport-Security header on TLS connections.
+func WithHSTS(maxAge time.Duration) Option {
+	return func(a *Api) {
+		a.hstsMaxAge = maxAge
+	}
+}
+
+// WithCertificateMerchants identifies merchants from their verified client
+// certificate, mapping subject common names to merchant IDs.
+func WithCertificateMerchants(merchants map[string]string) Option {
+	return func(a *Api) {
+		a.certificateMerchants = merchants
+		a.certificateAuth = true
+	}
+}
+
+// WithShutdownPolicy sets how the server drains on shutdown.
+func WithShutdownPolicy(policy ShutdownPolicy) Option {
+	return func(a *Api) {
+		a.shutdown = policy
+	}
+}
+
+// WithDrainers adds services drained together with the requests in flight
+// on shutdown.
+func WithDrainers(drainers ...Drainer) Option {
+	return func(a *Api) {
+		a.drainers = append(a.drainers, drainers...)
+	}
 }
 
-func New(paymentsHandler *PaymentsHandler) *Api {
+// WithMerchantAPIKeys identifies merchants from the API key they send as a
+// bearer token, mapping keys to merchant IDs.
+func WithMerchantAPIKeys(keys map[string]string) Option {
+	return func(a *Api) {
+		a.merchantAPIKeys = keys
+	}
+}
+
+// WithOperatorAPIKeys authenticates the operators of the administrative
+// routes from the API key they send as a bearer token, mapping keys to
+// operator IDs. Without keys, these routes refuse every request.
+func WithOperatorAPIKeys(keys map[string]string) Option {
+	return func(a *Api) {
+		a.operatorAPIKeys = keys
+	}
+}
+
+// WithReadiness sets the dependency checks of the readiness probe.
+func WithReadiness(readiness *health.Readiness) Option {
+	return func(a *Api) {
+		a.readiness = readiness
+	}
+}
+
+func New(paymentsHandler *PaymentsHandler, webhooksHandler *WebhooksHandler, listsHandler *ListsHandler, opts ...Option) *Api {
 	a := &Api{
 		paymentsHandler: paymentsHandler,
+		webhooksHandler: webhooksHandler,
+		listsHandler:    listsHandler,
+		readiness:       health.NewReadiness(),
+		timeouts:        DefaultServerTimeouts,
+		maxHeaderBytes:  http.DefaultMaxHeaderBytes,
+		shutdown:        DefaultShutdownPolicy,
+	}
+	a.draining, a.stopDraining = context.WithCancel(context.Background())
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if a.logger == nil {
+		a.logLevel = new(slog.LevelVar)
+		a.logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: a.logLevel}))
 	}
 
 	a.setupRouter()
@@ -33,24 +210,48 @@ func New(paymentsHandler *PaymentsHandler) *Api {
 	return a
 }
 
+// Run serves the API until ctx is cancelled, then shuts down gracefully: the
+// readiness probe fails first, then requests in flight and the drainers get
+// the drain timeout to complete.
 func (a *Api) Run(ctx context.Context, addr string) error {
+	// Requests outlive ctx so they can complete during the drain.
+	requestsCtx, cancelRequests := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelRequests()
+
 	httpServer := &http.Server{
-		Addr:        addr,
-		Handler:     a.router,
-		BaseContext: func(_ net.Listener) context.Context { return ctx },
+		Addr:              addr,
+		Handler:           a.router,
+		BaseContext:       func(_ net.Listener) context.Context { return requestsCtx },
+		ReadHeaderTimeout: a.timeouts.ReadHeader,
+		ReadTimeout:       a.timeouts.Read,
+		WriteTimeout:      a.timeouts.Write,
+		IdleTimeout:       a.timeouts.Idle,
+		MaxHeaderBytes:    a.maxHeaderBytes,
+		TLSConfig:         a.tlsConfig,
 	}
 
-	g, ctx := errgroup.WithContext(ctx)
+	g, gctx := errgroup.WithContext(ctx)
 
 	g.Go(func() error {
-		<-ctx.Done()
+		<-gctx.Done()
 		fmt.Printf("shutting down HTTP server\n")
-		return httpServer.Shutdown(ctx)
+		a.readiness.StartShutdown()
+		// Nobody is routed here when the server failed to listen.
+		if ctx.Err() != nil {
+			time.Sleep(a.shutdown.ReadinessDelay)
+		}
+		return a.drain(httpServer, cancelRequests)
 	})
 
 	g.Go(func() error {
-		fmt.Printf("starting HTTP server on %s\n", addr)
-		err := httpServer.ListenAndServe()
+		var err error
+		if a.tlsConfig != nil {
+			fmt.Printf("starting HTTPS server on %s\n", addr)
+			err = httpServer.ListenAndServeTLS("", "")
+		} else {
+			fmt.Printf("starting HTTP server on %s\n", addr)
+			err = httpServer.ListenAndServe()
+		}
 		if err != nil && err != http.ErrServerClosed {
 			return err
 		}
@@ -64,47 +265,124 @@ func (a *Api) Run(ctx context.Context, addr string) error {
 func (a *Api) setupRouter() {
 	a.router = chi.NewRouter()
 
-	logger := slog.New(
-		slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-			Level: slog.LevelInfo,
-		}),
-	)
-
 	a.router.Use(middleware.RequestID)
-	a.router.Use(RequestLogger(logger))
+	if a.hstsMaxAge > 0 {
+		a.router.Use(StrictTransportSecurity(a.hstsMaxAge))
+	}
+	a.router.Use(tracing.Middleware)
+	a.router.Use(MerchantIdentifier)
+	if a.certificateAuth {
+		a.router.Use(CertificateMerchants(a.certificateMerchants))
+	}
+	if len(a.merchantAPIKeys) > 0 {
+		a.router.Use(MerchantAPIKeys(a.merchantAPIKeys))
+	}
+	a.router.Use(OperatorAPIKeys(a.operatorAPIKeys))
+	a.router.Use(RequestLogger(a.logger))
+	a.router.Use(a.middlewares...)
+	if a.auditTrail != nil {
+		a.router.Use(AuditTrail(a.auditTrail))
+	}
 	a.router.Use(middleware.Recoverer)
-	// TODO: improve this to include dynamic config by environment variable
-	a.router.Use(middleware.Timeout(30 * time.Second))
 
-	a.router.Get("/swagger/*", a.SwaggerHandler())
+	timeout := middleware.Timeout(a.timeouts.Handler)
+	// Merchants must prove who they are as soon as they can, with an API
+	// key or a client certificate.
+	merchant := RequireMerchant(len(a.merchantAPIKeys) > 0 || a.certificateAuth)
+
+	a.router.With(timeout).Get("/swagger/*", a.SwaggerHandler())
+	a.router.With(timeout).Get("/healthz", a.HealthzHandler())
+	a.router.With(timeout).Get("/readyz", a.ReadyzHandler())
 
 	a.router.Route("/api/v1", func(r chi.Router) {
-		r.Get("/ping", a.PingHandler())
+		// Event streams stay open until the payment is final, so they are
+		// not bound by the request timeout.
+		r.With(merchant, a.closeOnShutdown).Get("/payments/{id}/events", a.paymentsHandler.EventsHandler())
+
+		r.Group(func(r chi.Router) {
+			r.Use(timeout)
+
+			r.Get("/ping", a.PingHandler())
+
+			// Reached by the cardholder's browser, identified by the
+			// transaction ID only. It authorizes the payment, so it is never
+			// a GET a link prefetcher could follow.
+			r.Post("/payments/3ds/callback", a.paymentsHandler.ThreeDSCallbackHandler())
+
+			r.Group(func(r chi.Router) {
+				r.Use(merchant)
+
+				r.Get("/payments", a.paymentsHandler.SearchHandler())
+				r.Get("/payments/{id}", a.paymentsHandler.GetHandler())
+				r.Post("/payments", a.paymentsHandler.PostHandler())
+
+				r.Get("/webhooks/endpoints", a.webhooksHandler.ListEndpointsHandler())
+				r.Post("/webhooks/endpoints", a.webhooksHandler.CreateEndpointHandler())
+				r.Delete("/webhooks/endpoints/{id}", a.webhooksHandler.DeleteEndpointHandler())
+				r.Get("/webhooks/deliveries", a.webhooksHandler.ListDeliveriesHandler())
+				r.Post("/webhooks/deliveries/{id}/redeliver", a.webhooksHandler.RedeliverHandler())
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(RequireOperator)
 
-		r.Get("/payments/{id}", a.paymentsHandler.GetHandler())
-		r.Post("/payments", a.paymentsHandler.PostHandler())
+				r.Get("/lists/entries", a.listsHandler.ListEntriesHandler())
+				r.Post("/lists/entries", a.listsHandler.CreateEntryHandler())
+				r.Get("/lists/entries/{id}", a.listsHandler.GetEntryHandler())
+				r.Put("/lists/entries/{id}", a.listsHandler.UpdateEntryHandler())
+				r.Delete("/lists/entries/{id}", a.listsHandler.DeleteEntryHandler())
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(RequireOperator)
+
+				r.Get("/admin/log-level", a.GetLogLevelHandler())
+				r.Put("/admin/log-level", a.SetLogLevelHandler())
+			})
+
+			if a.auditTrail != nil {
+				r.Group(func(r chi.Router) {
+					r.Use(RequireOperator)
+
+					r.Get("/admin/audit", a.ListAuditEntriesHandler())
+					r.Get("/admin/audit/export", a.ExportAuditEntriesHandler())
+					r.Get("/admin/audit/verify", a.VerifyAuditTrailHandler())
+				})
+			}
+
+			r.Group(func(r chi.Router) {
+				r.Use(RequireOperator)
+
+				r.Get("/admin/reviews", a.paymentsHandler.ListReviewsHandler())
+				r.Post("/admin/reviews/{id}/approve", a.paymentsHandler.ApproveReviewHandler())
+				r.Post("/admin/reviews/{id}/reject", a.paymentsHandler.RejectReviewHandler())
+			})
+		})
 	})
 }
 
-type pong struct {
-	Message string `json:"message"`
+// ServeHTTP serves the routes of the API, without the server settings Run
+// applies.
+func (a *Api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.router.ServeHTTP(w, r)
+}
+
+// Mount attaches an additional handler, such as a simulator, under the given pattern.
+func (a *Api) Mount(pattern string, handler http.Handler) {
+	a.router.Mount(pattern, handler)
 }
 
 // PingHandler godoc
 //
 // @Summary     Health check
-// @Description Simple health check endpoint used to verify service availability
+// @Description Simple health check endpoint used to verify service availability. Prefer /healthz and /readyz for probes.
 // @Tags        health
 // @Success     204 "Service is healthy"
-// @Failure     500 "Internal server error"
 // @Router      /api/v1/ping [get]
 func (a *Api) PingHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
+		// 204 responses have no body.
 		w.WriteHeader(http.StatusNoContent)
-		if err := json.NewEncoder(w).Encode(pong{Message: "pong"}); err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
 	}
 }
 
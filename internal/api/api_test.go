@@ -0,0 +1,71 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/api"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApi_HandlerTimeout(t *testing.T) {
+	t.Parallel()
+
+	timeouts := api.DefaultServerTimeouts
+	timeouts.Handler = 50 * time.Millisecond
+
+	// The readiness check sees the context of the request, bound by the
+	// handler timeout rather than the much longer check timeout.
+	deadlines := make(chan time.Time, 1)
+	readiness := health.NewReadiness(
+		health.WithTimeout(time.Minute),
+		health.WithCheck("deadline", health.CheckerFunc(func(ctx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			deadlines <- deadline
+			return nil
+		})),
+	)
+
+	a := api.New(nil, nil, nil, api.WithServerTimeouts(timeouts), api.WithReadiness(readiness))
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	deadline := <-deadlines
+	assert.WithinDuration(t, start.Add(timeouts.Handler), deadline, timeouts.Handler)
+}
+
+func TestApi_CertificateMerchantsRefuseHeaderOnly(t *testing.T) {
+	t.Parallel()
+
+	// Client certificates authenticate merchants even when none is assigned
+	// yet, the header alone must not be trusted.
+	a := api.New(nil, nil, nil, api.WithCertificateMerchants(nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/payments", nil)
+	req.Header.Set(api.MerchantIDHeader, "merchant_a")
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestApi_ThreeDSCallbackRefusesGet(t *testing.T) {
+	t.Parallel()
+
+	a := api.New(nil, nil, nil)
+
+	// A link prefetcher following the callback must not authorize the
+	// payment.
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/payments/3ds/callback?transaction_id=tx", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/audit"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// AuditTrail records the state-changing calls in the trail once they
+// completed: every call with a mutating method, and any other call that
+// changed a payment. Failed calls are recorded too, with their status code.
+func AuditTrail(trail *audit.Trail) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, change := audit.WithChange(r.Context())
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			if !mutating(r.Method) && !change.Recorded() {
+				return
+			}
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			operation := r.URL.Path
+			if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+				operation = rctx.RoutePattern()
+			}
+
+			entry := audit.Entry{
+				ActorID:    ActorIDFromContext(ctx),
+				MerchantID: MerchantIDFromContext(ctx),
+				Operation:  r.Method + " " + operation,
+				ResourceID: chi.URLParam(r, "id"),
+				Result:     status,
+				RequestID:  middleware.GetReqID(ctx),
+				SourceIP:   sourceIP(r),
+			}
+
+			// The change is done, record it even if the client went away.
+			if _, err := trail.Record(context.WithoutCancel(ctx), entry, change); err != nil {
+				LoggingFromContext(ctx).Error("recording audit entry", "operation", entry.Operation, "error", err)
+			}
+		})
+	}
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+// sourceIP returns the address of the client connection. Forwarding headers
+// are ignored, since any client can set them.
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ListAuditEntries godoc
+// @Summary Query the audit trail
+// @Description Lists the recorded state-changing calls matching the filters, oldest first.
+// @Tags audit
+// @Produce json
+// @Param actor_id query string false "Actor who made the change"
+// @Param merchant_id query string false "Merchant the change was made for"
+// @Param operation query string false "Method and route, e.g. POST /api/v1/payments"
+// @Param payment_id query string false "Payment changed"
+// @Param from query string false "Earliest entry, RFC 3339 timestamp, inclusive"
+// @Param to query string false "Latest entry, RFC 3339 timestamp, exclusive"
+// @Param limit query int false "Maximum number of entries returned"
+// @Success 200 {array} audit.Entry
+// @Failure 400 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Security OperatorAPIKey
+// @Router /api/v1/admin/audit [get]
+func (a *Api) ListAuditEntriesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query, err := auditQuery(r)
+		if err != nil {
+			ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		entries, err := a.auditTrail.Query(r.Context(), query)
+		if err != nil {
+			ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if entries == nil {
+			entries = []*audit.Entry{}
+		}
+
+		OKResponse(w, entries)
+	}
+}
+
+// ExportAuditEntries godoc
+// @Summary Export the audit trail
+// @Description Downloads the entries matching the filters as NDJSON, one entry per line, oldest first.
+// @Tags audit
+// @Produce application/x-ndjson
+// @Param actor_id query string false "Actor who made the change"
+// @Param merchant_id query string false "Merchant the change was made for"
+// @Param operation query string false "Method and route, e.g. POST /api/v1/payments"
+// @Param payment_id query string false "Payment changed"
+// @Param from query string false "Earliest entry, RFC 3339 timestamp, inclusive"
+// @Param to query string false "Latest entry, RFC 3339 timestamp, exclusive"
+// @Success 200 {object} audit.Entry
+// @Failure 400 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Security OperatorAPIKey
+// @Router /api/v1/admin/audit/export [get]
+func (a *Api) ExportAuditEntriesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query, err := auditQuery(r)
+		if err != nil {
+			ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="audit.ndjson"`)
+
+		if _, err := a.auditTrail.Export(r.Context(), w, query); err != nil {
+			// The status is already sent, the truncated body is all the
+			// client can get.
+			LoggingFromContext(r.Context()).Error("exporting audit entries", "error", err)
+		}
+	}
+}
+
+type auditVerification struct {
+	Valid   bool   `json:"valid" example:"true"`
+	Entries int    `json:"entries" example:"42"` // Entries verified before the first broken one.
+	Error   string `json:"error,omitempty"`      // Why the trail is not valid.
+}
+
+// VerifyAuditTrail godoc
+// @Summary Verify the audit trail
+// @Description Checks every entry matches its hash and follows the previous one, detecting entries altered, removed or reordered.
+// @Description With AUDIT_CHAIN_KEY set, the hashes are keyed, so a trail rebuilt without the key does not verify either.
+// @Tags audit
+// @Produce json
+// @Success 200 {object} api.auditVerification
+// @Failure 401 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Security OperatorAPIKey
+// @Router /api/v1/admin/audit/verify [get]
+func (a *Api) VerifyAuditTrailHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		verified, err := a.auditTrail.Verify(r.Context())
+		if err != nil && !errors.Is(err, audit.ErrTampered) {
+			ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		result := auditVerification{Valid: err == nil, Entries: verified}
+		if err != nil {
+			result.Error = err.Error()
+		}
+
+		OKResponse(w, result)
+	}
+}
+
+func auditQuery(r *http.Request) (audit.Query, error) {
+	params := r.URL.Query()
+
+	query := audit.Query{
+		ActorID:    params.Get("actor_id"),
+		MerchantID: params.Get("merchant_id"),
+		Operation:  params.Get("operation"),
+		PaymentID:  params.Get("payment_id"),
+	}
+
+	bounds := []struct {
+		name string
+		at   *time.Time
+	}{{"from", &query.From}, {"to", &query.To}}
+	for _, bound := range bounds {
+		if param := params.Get(bound.name); param != "" {
+			t, err := time.Parse(time.RFC3339, param)
+			if err != nil {
+				return audit.Query{}, fmt.Errorf("%s must be an RFC 3339 timestamp", bound.name)
+			}
+			*bound.at = t
+		}
+	}
+
+	if param := params.Get("limit"); param != "" {
+		n, err := strconv.Atoi(param)
+		if err != nil || n < 0 {
+			return audit.Query{}, errors.New("limit must be a non-negative integer")
+		}
+		query.Limit = n
+	}
+
+	return query, nil
+}
@@ -0,0 +1,159 @@
+package api_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/api"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/audit"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAuditedTrail sends one request to each of a few routes standing for
+// the real ones behind the audit middleware, and returns the trail.
+func newAuditedTrail(t *testing.T) *audit.Trail {
+	t.Helper()
+
+	trail := audit.NewTrail(repository.NewAuditRepositoryInMemory())
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(api.MerchantIdentifier)
+	r.Use(api.OperatorAPIKeys(testOperators))
+	r.Use(api.AuditTrail(trail))
+
+	r.Post("/api/v1/payments", func(w http.ResponseWriter, r *http.Request) {
+		audit.RecordPaymentChange(r.Context(), "pay_1", "", "authorized")
+	})
+	r.Get("/api/v1/payments/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	r.Post("/api/v1/payments/3ds/callback", func(w http.ResponseWriter, r *http.Request) {
+		audit.RecordPaymentChange(r.Context(), "pay_2", "requires_action", "authorized")
+	})
+	r.Delete("/api/v1/lists/entries/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	send := func(method, path, merchantID, actorID string) {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = "203.0.113.7:51234"
+		req.Header.Set(api.MerchantIDHeader, merchantID)
+		asOperator(req, actorID)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	send(http.MethodPost, "/api/v1/payments", "merchant_a", "")
+	send(http.MethodGet, "/api/v1/payments/pay_1", "merchant_a", "")
+	send(http.MethodPost, "/api/v1/payments/3ds/callback?transaction_id=tx", "", "")
+	send(http.MethodDelete, "/api/v1/lists/entries/entry_1", "", "ops")
+
+	return trail
+}
+
+func TestAuditTrail(t *testing.T) {
+	t.Parallel()
+
+	trail := newAuditedTrail(t)
+
+	entries, err := trail.Query(context.Background(), audit.Query{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3, "reads without changes are not recorded")
+
+	created := entries[0]
+	assert.Equal(t, "POST /api/v1/payments", created.Operation)
+	assert.Equal(t, "merchant_a", created.MerchantID)
+	assert.Equal(t, "pay_1", created.PaymentID)
+	assert.Empty(t, created.BeforeStatus)
+	assert.Equal(t, "authorized", created.AfterStatus)
+	assert.Equal(t, http.StatusOK, created.Result)
+	assert.Equal(t, "203.0.113.7", created.SourceIP)
+	assert.NotEmpty(t, created.RequestID)
+
+	callback := entries[1]
+	assert.Equal(t, "POST /api/v1/payments/3ds/callback", callback.Operation)
+	assert.Equal(t, "requires_action", callback.BeforeStatus)
+
+	deleted := entries[2]
+	assert.Equal(t, "DELETE /api/v1/lists/entries/{id}", deleted.Operation)
+	assert.Equal(t, "ops", deleted.ActorID)
+	assert.Equal(t, "entry_1", deleted.ResourceID)
+	assert.Equal(t, http.StatusNotFound, deleted.Result)
+	assert.Empty(t, deleted.PaymentID)
+}
+
+func TestApi_AuditHandlers(t *testing.T) {
+	t.Parallel()
+
+	a := api.New(nil, nil, nil, api.WithAuditTrail(newAuditedTrail(t)), api.WithOperatorAPIKeys(testOperators))
+
+	t.Run("operator required", func(t *testing.T) {
+		t.Parallel()
+
+		for _, path := range []string{"/api/v1/admin/audit", "/api/v1/admin/audit/export", "/api/v1/admin/audit/verify"} {
+			rec := httptest.NewRecorder()
+			a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, http.StatusUnauthorized, rec.Code, path)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit", nil)
+		asOperator(req, "ops")
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		a.ListAuditEntriesHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit?payment_id=pay_1", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var entries []audit.Entry
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&entries))
+		require.Len(t, entries, 1)
+		assert.Equal(t, "POST /api/v1/payments", entries[0].Operation)
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		a.ListAuditEntriesHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit?from=yesterday", nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("export", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		a.ExportAuditEntriesHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit/export", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+
+		var sequences []int64
+		scanner := bufio.NewScanner(rec.Body)
+		for scanner.Scan() {
+			var entry audit.Entry
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+			sequences = append(sequences, entry.Sequence)
+		}
+		assert.Equal(t, []int64{1, 2, 3}, sequences)
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		a.VerifyAuditTrailHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit/verify", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"valid":true,"entries":3}`, rec.Body.String())
+	})
+}
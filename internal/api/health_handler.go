@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/health"
+)
+
+type liveness struct {
+	Status health.Status `json:"status" example:"up"`
+}
+
+// Healthz godoc
+// @Summary Liveness probe
+// @Description Reports the process is alive and serving HTTP. Dependencies are not checked, so a failing bank never gets the gateway restarted.
+// @Tags health
+// @Produce json
+// @Success 200 {object} api.liveness
+// @Router /healthz [get]
+func (a *Api) HealthzHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		OKResponse(w, liveness{Status: health.StatusUp})
+	}
+}
+
+// Readyz godoc
+// @Summary Readiness probe
+// @Description Checks the repository and the acquiring bank, and reports whether the gateway should receive traffic with the outcome of every check.
+// @Description Not ready once graceful shutdown started.
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /readyz [get]
+func (a *Api) ReadyzHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := a.readiness.Check(r.Context())
+
+		status := http.StatusOK
+		if report.Status != health.StatusUp {
+			status = http.StatusServiceUnavailable
+		}
+
+		JSONResponse(w, status, report)
+	}
+}
@@ -0,0 +1,101 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/api"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApi_HealthzHandler(t *testing.T) {
+	t.Parallel()
+
+	a := api.New(nil, nil, nil)
+
+	rec := httptest.NewRecorder()
+	a.HealthzHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"up"}`, rec.Body.String())
+}
+
+func TestApi_ReadyzHandler(t *testing.T) {
+	t.Parallel()
+
+	bankErr := errors.New("connection refused")
+
+	tests := []struct {
+		name         string
+		bankErr      error
+		shuttingDown bool
+		wantCode     int
+		wantStatus   health.Status
+	}{
+		{
+			name:       "ready",
+			wantCode:   http.StatusOK,
+			wantStatus: health.StatusUp,
+		},
+		{
+			name:       "bank down",
+			bankErr:    bankErr,
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: health.StatusDown,
+		},
+		{
+			name:         "shutting down",
+			shuttingDown: true,
+			wantCode:     http.StatusServiceUnavailable,
+			wantStatus:   health.StatusDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			readiness := health.NewReadiness(
+				health.WithCheck("repository", health.CheckerFunc(func(context.Context) error { return nil })),
+				health.WithCheck("bank", health.CheckerFunc(func(context.Context) error { return tt.bankErr })),
+			)
+			if tt.shuttingDown {
+				readiness.StartShutdown()
+			}
+			a := api.New(nil, nil, nil, api.WithReadiness(readiness))
+
+			rec := httptest.NewRecorder()
+			a.ReadyzHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			require.Equal(t, tt.wantCode, rec.Code)
+
+			var report health.Report
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+			assert.Equal(t, tt.wantStatus, report.Status)
+			assert.Equal(t, tt.shuttingDown, report.ShuttingDown)
+			assert.Equal(t, health.StatusUp, report.Checks["repository"].Status)
+			if tt.bankErr != nil {
+				assert.Equal(t, health.StatusDown, report.Checks["bank"].Status)
+				assert.Equal(t, tt.bankErr.Error(), report.Checks["bank"].Error)
+			}
+		})
+	}
+}
+
+func TestApi_PingHandler(t *testing.T) {
+	t.Parallel()
+
+	a := api.New(nil, nil, nil)
+
+	rec := httptest.NewRecorder()
+	a.PingHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
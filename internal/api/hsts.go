@@ -0,0 +1,23 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// StrictTransportSecurity tells browsers to only reach the gateway over
+// HTTPS for maxAge. The header is only sent on TLS connections, as browsers
+// ignore it otherwise.
+func StrictTransportSecurity(maxAge time.Duration) func(http.Handler) http.Handler {
+	value := fmt.Sprintf("max-age=%d; includeSubDomains", int64(maxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS != nil {
+				w.Header().Set("Strict-Transport-Security", value)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
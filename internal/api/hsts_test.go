@@ -0,0 +1,28 @@
+package api_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrictTransportSecurity(t *testing.T) {
+	t.Parallel()
+
+	a := api.New(nil, nil, nil, api.WithHSTS(24*time.Hour))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.TLS = &tls.ConnectionState{}
+	a.ServeHTTP(rec, req)
+	assert.Equal(t, "max-age=86400; includeSubDomains", rec.Header().Get("Strict-Transport-Security"))
+
+	rec = httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Empty(t, rec.Header().Get("Strict-Transport-Security"), "not sent over plain HTTP")
+}
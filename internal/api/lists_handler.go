@@ -0,0 +1,234 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/lists"
+	"github.com/go-chi/chi/v5"
+)
+
+type ListsHandler struct {
+	service *lists.Service
+}
+
+func NewListsHandler(svc *lists.Service) *ListsHandler {
+	return &ListsHandler{svc}
+}
+
+type CreateListEntryRequest struct {
+	List       lists.List `json:"list" example:"block" enums:"block,allow"`
+	Kind       lists.Kind `json:"kind" example:"bin" enums:"card_fingerprint,bin,country,merchant_reference"`
+	Value      string     `json:"value" example:"411111"`
+	MerchantID string     `json:"merchant_id,omitempty" example:"merchant_123"` // Limits the entry to a merchant, every merchant when empty.
+	Reason     string     `json:"reason" example:"Card testing attack reported by the issuer"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" example:"2026-02-15T10:00:00Z"` // When the entry stops applying, never when empty.
+}
+
+type UpdateListEntryRequest struct {
+	Reason    string     `json:"reason" example:"Confirmed by the issuer"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2026-03-15T10:00:00Z"` // When the entry stops applying, never when empty.
+}
+
+// CreateListEntry godoc
+// @Summary Add a list entry
+// @Description Blocks, or always allows, the payments matching a card fingerprint, BIN prefix, billing country or merchant reference.
+// @Description Allowlist entries, of card fingerprints and BINs only, skip the fraud rules and the other blocklists, never a card or BIN blocklist entry. The authenticated operator is recorded as the author of the entry.
+// @Description Operators acting for a merchant, with the X-Merchant-ID header, can only add blocklist entries limited to that merchant.
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Param X-Merchant-ID header string false "Merchant the operator acts for"
+// @Param request body api.CreateListEntryRequest true "Entry"
+// @Success 200 {object} lists.Entry
+// @Failure 400 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Failure 403 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Security OperatorAPIKey
+// @Router /api/v1/lists/entries [post]
+func (h *ListsHandler) CreateEntryHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateListEntryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "invalid request body format")
+			return
+		}
+
+		// Allowlist and global entries apply beyond the merchant, so only
+		// operators acting for no merchant may add them.
+		if merchantID := MerchantIDFromContext(r.Context()); merchantID != "" {
+			switch {
+			case req.List == lists.Allowlist:
+				ErrorResponse(w, http.StatusForbidden, "allowlist entries cannot be added for a merchant")
+				return
+			case req.MerchantID != merchantID:
+				ErrorResponse(w, http.StatusForbidden, "entries added for a merchant must be limited to it")
+				return
+			}
+		}
+
+		entry, err := h.service.CreateEntry(r.Context(), ActorIDFromContext(r.Context()), lists.NewEntry{
+			List:       req.List,
+			Kind:       req.Kind,
+			Value:      req.Value,
+			MerchantID: req.MerchantID,
+			Reason:     req.Reason,
+			ExpiresAt:  req.ExpiresAt,
+		})
+		if err != nil {
+			listsErrorResponse(w, err)
+			return
+		}
+
+		OKResponse(w, entry)
+	}
+}
+
+// ListListEntries godoc
+// @Summary List list entries
+// @Description Lists the entries matching the filters, oldest first.
+// @Tags lists
+// @Produce json
+// @Param list query string false "List" Enums(block, allow)
+// @Param kind query string false "Kind" Enums(card_fingerprint, bin, country, merchant_reference)
+// @Param value query string false "Value"
+// @Param merchant_id query string false "Merchant the entry is limited to"
+// @Param active query bool false "Only the entries not expired yet"
+// @Success 200 {array} lists.Entry
+// @Failure 400 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Security OperatorAPIKey
+// @Router /api/v1/lists/entries [get]
+func (h *ListsHandler) ListEntriesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+
+		query := lists.EntriesQuery{
+			List:       lists.List(params.Get("list")),
+			Kind:       lists.Kind(params.Get("kind")),
+			Value:      params.Get("value"),
+			MerchantID: params.Get("merchant_id"),
+		}
+
+		if active := params.Get("active"); active != "" {
+			b, err := strconv.ParseBool(active)
+			if err != nil {
+				ErrorResponse(w, http.StatusBadRequest, "active must be true or false")
+				return
+			}
+			query.Active = b
+		}
+
+		entries, err := h.service.ListEntries(r.Context(), query)
+		if err != nil {
+			ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if entries == nil {
+			entries = []*lists.Entry{}
+		}
+
+		OKResponse(w, entries)
+	}
+}
+
+// GetListEntry godoc
+// @Summary Get a list entry
+// @Tags lists
+// @Produce json
+// @Param id path string true "Entry ID"
+// @Success 200 {object} lists.Entry
+// @Failure 401 {object} api.ErrorResponseBody
+// @Failure 404 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Security OperatorAPIKey
+// @Router /api/v1/lists/entries/{id} [get]
+func (h *ListsHandler) GetEntryHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entry, err := h.service.GetEntry(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			listsErrorResponse(w, err)
+			return
+		}
+
+		OKResponse(w, entry)
+	}
+}
+
+// UpdateListEntry godoc
+// @Summary Update a list entry
+// @Description Replaces the reason and expiry of an entry. The authenticated operator is recorded as the author of the change.
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Entry ID"
+// @Param request body api.UpdateListEntryRequest true "Changes"
+// @Success 200 {object} lists.Entry
+// @Failure 400 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Failure 404 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Security OperatorAPIKey
+// @Router /api/v1/lists/entries/{id} [put]
+func (h *ListsHandler) UpdateEntryHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req UpdateListEntryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "invalid request body format")
+			return
+		}
+
+		entry, err := h.service.UpdateEntry(
+			r.Context(),
+			ActorIDFromContext(r.Context()),
+			chi.URLParam(r, "id"),
+			req.Reason,
+			req.ExpiresAt,
+		)
+		if err != nil {
+			listsErrorResponse(w, err)
+			return
+		}
+
+		OKResponse(w, entry)
+	}
+}
+
+// DeleteListEntry godoc
+// @Summary Delete a list entry
+// @Tags lists
+// @Param id path string true "Entry ID"
+// @Success 204 "Entry deleted"
+// @Failure 401 {object} api.ErrorResponseBody
+// @Failure 404 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Security OperatorAPIKey
+// @Router /api/v1/lists/entries/{id} [delete]
+func (h *ListsHandler) DeleteEntryHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := h.service.DeleteEntry(r.Context(), chi.URLParam(r, "id")); err != nil {
+			listsErrorResponse(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func listsErrorResponse(w http.ResponseWriter, err error) {
+	var invalidEntryErr *lists.InvalidEntryErr
+	switch {
+	case errors.As(err, &invalidEntryErr):
+		ErrorResponse(w, http.StatusBadRequest, invalidEntryErr.Error())
+	case errors.Is(err, lists.ErrEntryNotFound):
+		ErrorResponse(w, http.StatusNotFound, err.Error())
+	default:
+		ErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,167 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/api"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/lists"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func newListsRouter() http.Handler {
+	handler := api.NewListsHandler(lists.NewService(repository.NewListsRepositoryInMemory()))
+
+	r := chi.NewRouter()
+	r.Use(api.MerchantIdentifier)
+	r.Use(api.OperatorAPIKeys(testOperators))
+	r.Use(api.RequireOperator)
+	r.Get("/lists/entries", handler.ListEntriesHandler())
+	r.Post("/lists/entries", handler.CreateEntryHandler())
+	r.Get("/lists/entries/{id}", handler.GetEntryHandler())
+	r.Put("/lists/entries/{id}", handler.UpdateEntryHandler())
+	r.Delete("/lists/entries/{id}", handler.DeleteEntryHandler())
+	return r
+}
+
+func serveLists(r http.Handler, method, target, actor, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	asOperator(req, actor)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestListsHandler_CreateEntryHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		actor          string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "valid entry",
+			actor:          "analyst",
+			body:           `{"list":"block","kind":"bin","value":"411111","reason":"card testing"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid value",
+			actor:          "analyst",
+			body:           `{"list":"block","kind":"country","value":"Narnia","reason":"card testing"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing operator",
+			body:           `{"list":"block","kind":"bin","value":"411111","reason":"card testing"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "malformed body",
+			actor:          "analyst",
+			body:           `{`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := serveLists(newListsRouter(), http.MethodPost, "/lists/entries", tt.actor, tt.body)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var entry lists.Entry
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&entry))
+				require.Equal(t, "analyst", entry.CreatedBy)
+			}
+		})
+	}
+}
+
+func TestListsHandler_CreateEntryHandler_ForMerchant(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "blocklist entry of the merchant",
+			body:           `{"list":"block","kind":"bin","value":"411111","merchant_id":"merchant_a","reason":"card testing"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "global entry",
+			body:           `{"list":"block","kind":"bin","value":"411111","reason":"card testing"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "entry of another merchant",
+			body:           `{"list":"block","kind":"bin","value":"411111","merchant_id":"merchant_b","reason":"card testing"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "allowlist entry",
+			body:           `{"list":"allow","kind":"bin","value":"411111","merchant_id":"merchant_a","reason":"trusted"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/lists/entries", strings.NewReader(tt.body))
+			req.Header.Set(api.MerchantIDHeader, "merchant_a")
+			asOperator(req, "analyst")
+			rec := httptest.NewRecorder()
+
+			newListsRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestListsHandler_EntryLifecycle(t *testing.T) {
+	t.Parallel()
+
+	r := newListsRouter()
+
+	rec := serveLists(r, http.MethodPost, "/lists/entries", "analyst_a",
+		`{"list":"block","kind":"merchant_reference","value":"ORD-1","merchant_id":"merchant_a","reason":"chargeback"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var entry lists.Entry
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&entry))
+
+	rec = serveLists(r, http.MethodPut, "/lists/entries/"+entry.ID, "analyst_b",
+		`{"reason":"confirmed chargeback","expires_at":"2100-01-01T00:00:00Z"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveLists(r, http.MethodGet, "/lists/entries?list=block&merchant_id=merchant_a&active=true", "analyst_a", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var entries []lists.Entry
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&entries))
+	require.Len(t, entries, 1)
+	require.Equal(t, "confirmed chargeback", entries[0].Reason)
+	require.Equal(t, "analyst_a", entries[0].CreatedBy)
+	require.Equal(t, "analyst_b", entries[0].UpdatedBy)
+
+	require.Equal(t, http.StatusBadRequest, serveLists(r, http.MethodGet, "/lists/entries?active=maybe", "analyst_a", "").Code)
+
+	require.Equal(t, http.StatusNoContent, serveLists(r, http.MethodDelete, "/lists/entries/"+entry.ID, "analyst_b", "").Code)
+	require.Equal(t, http.StatusNotFound, serveLists(r, http.MethodGet, "/lists/entries/"+entry.ID, "analyst_a", "").Code)
+}
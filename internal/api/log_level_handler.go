@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/logging"
+)
+
+type logLevel struct {
+	Level string `json:"level" example:"info"` // One of debug, info, warn or error.
+}
+
+// GetLogLevel godoc
+// @Summary Get the log level
+// @Description Returns the minimum level of the records logged.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} api.logLevel
+// @Failure 401 {object} api.ErrorResponseBody
+// @Security OperatorAPIKey
+// @Router /api/v1/admin/log-level [get]
+func (a *Api) GetLogLevelHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		OKResponse(w, logLevel{Level: logging.LevelName(a.logLevel.Level())})
+	}
+}
+
+// SetLogLevel godoc
+// @Summary Change the log level
+// @Description Changes the minimum level of the records logged, effective immediately and until the next restart.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body api.logLevel true "New log level"
+// @Success 200 {object} api.logLevel
+// @Failure 400 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Security OperatorAPIKey
+// @Router /api/v1/admin/log-level [put]
+func (a *Api) SetLogLevelHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req logLevel
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "invalid request body format")
+			return
+		}
+
+		level, err := logging.ParseLevel(req.Level)
+		if err != nil {
+			ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		previous := a.logLevel.Level()
+		a.logLevel.Set(level)
+
+		// Logged at warn or above so the change is recorded whatever the new
+		// level.
+		LoggingFromContext(r.Context()).Log(r.Context(), max(level, slog.LevelWarn), "log level changed",
+			"from", logging.LevelName(previous),
+			"to", logging.LevelName(level),
+			"actor_id", ActorIDFromContext(r.Context()),
+		)
+
+		OKResponse(w, logLevel{Level: logging.LevelName(level)})
+	}
+}
@@ -4,10 +4,15 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/tracing"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
+// RequestLogger adds a logger describing the request to its context, and
+// logs a line once the request completed with its status, size and latency.
+// Server errors are logged at the error level.
 func RequestLogger(base *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -17,10 +22,33 @@ func RequestLogger(base *slog.Logger) func(http.Handler) http.Handler {
 				slog.String("method", r.Method),
 				slog.String("path", r.URL.Path),
 				slog.String("request_id", reqID),
-			)
+			).With(tracing.LogAttrs(r.Context())...)
+
+			// WrapResponseWriter keeps http.Flusher, needed by event streams.
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			start := time.Now()
+
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+
+				level := slog.LevelInfo
+				if status >= http.StatusInternalServerError {
+					level = slog.LevelError
+				}
+
+				logger.LogAttrs(context.Background(), level, "request completed",
+					slog.Int("status", status),
+					slog.Int("bytes", ww.BytesWritten()),
+					slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+					slog.String("merchant_id", MerchantIDFromContext(r.Context())),
+				)
+			}()
 
 			ctx := WithLogger(r.Context(), logger)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(ww, r.WithContext(ctx))
 		})
 	}
 }
@@ -0,0 +1,113 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogger(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantLevel string
+	}{
+		{name: "success", status: http.StatusOK, body: `{"id":"pay_123"}`, wantLevel: "INFO"},
+		{name: "client error", status: http.StatusBadRequest, body: `{"error":"invalid"}`, wantLevel: "INFO"},
+		{name: "server error", status: http.StatusInternalServerError, body: `{"error":"boom"}`, wantLevel: "ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+			handler := api.MerchantIdentifier(api.RequestLogger(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, ok := w.(http.Flusher)
+				assert.True(t, ok, "the wrapped writer must keep http.Flusher")
+
+				api.LoggingFromContext(r.Context()).Info("handling")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			})))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/payments", nil)
+			req.Header.Set(api.MerchantIDHeader, "merchant_a")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			dec := json.NewDecoder(&buf)
+
+			var handling map[string]any
+			require.NoError(t, dec.Decode(&handling))
+			assert.Equal(t, "handling", handling["msg"])
+			assert.Equal(t, "/api/v1/payments", handling["path"])
+
+			var completed map[string]any
+			require.NoError(t, dec.Decode(&completed))
+			assert.Equal(t, "request completed", completed["msg"])
+			assert.Equal(t, tt.wantLevel, completed["level"])
+			assert.Equal(t, "POST", completed["method"])
+			assert.EqualValues(t, tt.status, completed["status"])
+			assert.EqualValues(t, len(tt.body), completed["bytes"])
+			assert.Equal(t, "merchant_a", completed["merchant_id"])
+			assert.Contains(t, completed, "latency_ms")
+		})
+	}
+}
+
+func TestApi_LogLevelHandlers(t *testing.T) {
+	t.Parallel()
+
+	level := new(slog.LevelVar)
+	a := api.New(nil, nil, nil, api.WithRequestLogger(slog.New(slog.NewJSONHandler(&bytes.Buffer{}, &slog.HandlerOptions{Level: level})), level))
+
+	rec := httptest.NewRecorder()
+	a.GetLogLevelHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/admin/log-level", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"level":"info"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	a.SetLogLevelHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/v1/admin/log-level", bytes.NewBufferString(`{"level":"DEBUG"}`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"level":"debug"}`, rec.Body.String())
+	assert.Equal(t, slog.LevelDebug, level.Level())
+
+	rec = httptest.NewRecorder()
+	a.SetLogLevelHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/v1/admin/log-level", bytes.NewBufferString(`{"level":"verbose"}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, slog.LevelDebug, level.Level())
+}
+
+func TestApi_LogLevelRequiresOperator(t *testing.T) {
+	t.Parallel()
+
+	level := new(slog.LevelVar)
+	a := api.New(nil, nil, nil,
+		api.WithRequestLogger(slog.New(slog.NewJSONHandler(&bytes.Buffer{}, &slog.HandlerOptions{Level: level})), level),
+		api.WithOperatorAPIKeys(testOperators),
+	)
+
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/v1/admin/log-level", bytes.NewBufferString(`{"level":"debug"}`)))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, slog.LevelInfo, level.Level())
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/log-level", bytes.NewBufferString(`{"level":"debug"}`))
+	asOperator(req, "ops")
+	rec = httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, slog.LevelDebug, level.Level())
+}
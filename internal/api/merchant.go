@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// MerchantIDHeader is the request header carrying the merchant identifier.
+const MerchantIDHeader = "X-Merchant-ID"
+
+// MerchantIdentifier resolves the merchant issuing the request and stores
+// it in the request context.
+func MerchantIdentifier(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		merchantID := r.Header.Get(MerchantIDHeader)
+
+		ctx := WithMerchantID(r.Context(), merchantID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type merchantKeyType struct{}
+
+var merchantKey = merchantKeyType{}
+
+// merchantIdentity is the merchant of a request, authenticated when it was
+// proven by an API key or a client certificate rather than claimed by the
+// X-Merchant-ID header.
+type merchantIdentity struct {
+	id            string
+	authenticated bool
+}
+
+func WithMerchantID(ctx context.Context, merchantID string) context.Context {
+	return context.WithValue(ctx, merchantKey, merchantIdentity{id: merchantID})
+}
+
+func withAuthenticatedMerchant(ctx context.Context, merchantID string) context.Context {
+	return context.WithValue(ctx, merchantKey, merchantIdentity{id: merchantID, authenticated: true})
+}
+
+func MerchantIDFromContext(ctx context.Context) string {
+	if identity, ok := ctx.Value(merchantKey).(merchantIdentity); ok {
+		return identity.id
+	}
+	return ""
+}
+
+func merchantAuthenticated(ctx context.Context) bool {
+	identity, ok := ctx.Value(merchantKey).(merchantIdentity)
+	return ok && identity.authenticated
+}
+
+// CertificateMerchants identifies the merchants of requests sent with a
+// verified client certificate from the certificate, mapping its subject
+// common name to a merchant ID. It overrides the X-Merchant-ID header, which
+// is then only accepted when it names the same merchant. Certificates of
+// unknown subjects are refused, requests without one are left alone.
+func CertificateMerchants(merchants map[string]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			subject := r.TLS.VerifiedChains[0][0].Subject.CommonName
+			merchantID, ok := merchants[subject]
+			if !ok {
+				slog.WarnContext(r.Context(), "refused unknown client certificate", "subject", subject)
+				ErrorResponse(w, http.StatusForbidden, "client certificate is not assigned to a merchant")
+				return
+			}
+
+			if header := r.Header.Get(MerchantIDHeader); header != "" && header != merchantID {
+				slog.WarnContext(r.Context(), "refused merchant not matching the client certificate",
+					"subject", subject, "merchant_id", merchantID, "requested_merchant_id", header)
+				ErrorResponse(w, http.StatusForbidden, "merchant does not match the client certificate")
+				return
+			}
+
+			ctx := withAuthenticatedMerchant(r.Context(), merchantID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// apiKeys maps the SHA-256 digests of API keys to whom they identify, so
+// looking a key up takes the same time whatever its value.
+type apiKeys map[[sha256.Size]byte]string
+
+func newAPIKeys(keys map[string]string) apiKeys {
+	digests := make(apiKeys, len(keys))
+	for key, id := range keys {
+		digests[sha256.Sum256([]byte(key))] = id
+	}
+	return digests
+}
+
+// lookup returns whom the bearer token of the request identifies.
+func (k apiKeys) lookup(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "", false
+	}
+	id, ok := k[sha256.Sum256([]byte(token))]
+	return id, ok
+}
+
+// MerchantAPIKeys identifies merchants from the API key sent as a bearer
+// token, mapping keys to merchant IDs. Like CertificateMerchants it
+// overrides the X-Merchant-ID header, then only accepted when it names the
+// same merchant. Requests without a merchant key are left alone, to be
+// refused by RequireMerchant.
+func MerchantAPIKeys(keys map[string]string) func(http.Handler) http.Handler {
+	merchants := newAPIKeys(keys)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			merchantID, ok := merchants.lookup(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if header := r.Header.Get(MerchantIDHeader); header != "" && header != merchantID {
+				slog.WarnContext(r.Context(), "refused merchant not matching the API key",
+					"merchant_id", merchantID, "requested_merchant_id", header)
+				ErrorResponse(w, http.StatusForbidden, "merchant does not match the API key")
+				return
+			}
+
+			ctx := withAuthenticatedMerchant(r.Context(), merchantID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireMerchant refuses the requests whose merchant is not authenticated
+// by an API key or a client certificate, when authenticated is set.
+// Otherwise the X-Merchant-ID header is trusted, which only suits
+// development, but is still required: a request is never served on behalf
+// of no merchant.
+func RequireMerchant(authenticated bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case authenticated && !merchantAuthenticated(r.Context()):
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				ErrorResponse(w, http.StatusUnauthorized, "a merchant API key or client certificate is required")
+				return
+			case MerchantIDFromContext(r.Context()) == "":
+				ErrorResponse(w, http.StatusUnauthorized, "the X-Merchant-ID header is required")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -1,21 +1,64 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/threeds"
 	"github.com/go-chi/chi/v5"
 )
 
+// DefaultHeartbeatInterval is how often idle event streams send a comment,
+// keeping proxies from closing the connection.
+const DefaultHeartbeatInterval = 15 * time.Second
+
+// DefaultMaxBodyBytes limits the size of payment requests unless
+// WithMaxBodyBytes is given.
+const DefaultMaxBodyBytes = 64 << 10
+
 type PaymentsHandler struct {
-	service *payments.Service
+	service      *payments.Service
+	heartbeat    time.Duration
+	maxBodyBytes int64
+}
+
+// PaymentsHandlerOption configures optional settings of the PaymentsHandler.
+type PaymentsHandlerOption func(*PaymentsHandler)
+
+// WithHeartbeatInterval sets how often idle event streams send a heartbeat.
+func WithHeartbeatInterval(interval time.Duration) PaymentsHandlerOption {
+	return func(h *PaymentsHandler) {
+		h.heartbeat = interval
+	}
+}
+
+// WithMaxBodyBytes limits the size of payment requests. Larger ones get a
+// 413.
+func WithMaxBodyBytes(n int64) PaymentsHandlerOption {
+	return func(h *PaymentsHandler) {
+		h.maxBodyBytes = n
+	}
 }
 
-func NewPaymentsHandler(svc *payments.Service) *PaymentsHandler {
-	return &PaymentsHandler{svc}
+func NewPaymentsHandler(svc *payments.Service, opts ...PaymentsHandlerOption) *PaymentsHandler {
+	h := &PaymentsHandler{
+		service:      svc,
+		heartbeat:    DefaultHeartbeatInterval,
+		maxBodyBytes: DefaultMaxBodyBytes,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
 // GetPayment godoc
@@ -28,12 +71,14 @@ func NewPaymentsHandler(svc *payments.Service) *PaymentsHandler {
 // @Success 200 {object} payments.Payment
 // @Failure 404 {object} api.ErrorResponseBody
 // @Failure 500 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Security MerchantAPIKey
 // @Router /api/v1/payments/{id} [get]
 func (h *PaymentsHandler) GetHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
 
-		payment, err := h.service.GetPayment(r.Context(), id)
+		payment, err := h.merchantPayment(r.Context(), id)
 		if err != nil {
 			if errors.Is(err, payments.NotFoundPaymentErr) {
 				ErrorResponse(w, http.StatusNotFound, err.Error())
@@ -51,27 +96,54 @@ func (h *PaymentsHandler) GetHandler() http.HandlerFunc {
 // @Summary Create a payment
 // @Description Creates a new payment and authorizes it with the bank
 // @Description Amount must be expressed in minor units of the currency (e.g. 1099 = $10.99 USD).
-// @Description The only currencies supported for now are USD, EUR, and BRL (ISO 4217).
+// @Description Any ISO 4217 currency can be used as long as it is enabled for the merchant (USD, EUR, and BRL by default).
 // @Tags payments
 // @Accept json
 // @Produce json
+// @Description Send "Prefer: respond-async" to have the payment persisted as pending and authorized in the background:
+// @Description the response is then 202 with a Location header to poll, when asynchronous processing is enabled.
+// @Description A payment whose authorization was interrupted before the bank answered is answered with 504,
+// @Description with a Location header to poll: it stays pending until reconciled with the bank and must not be retried.
+// @Param X-Merchant-ID header string false "Merchant identifier"
+// @Param Prefer header string false "respond-async to authorize the payment asynchronously"
 // @Param request body payments.PaymentRequest true "Payment request"
 // @Success 200 {object} payments.Payment
+// @Success 202 {object} payments.Payment
+// @Header 202 {string} Location "URL of the payment"
+// @Header 202 {string} Preference-Applied "respond-async"
 // @Failure 400 {object} api.ErrorResponseBody
+// @Failure 413 {object} api.ErrorResponseBody
 // @Failure 500 {object} api.ErrorResponseBody
+// @Failure 503 {object} api.ErrorResponseBody
+// @Failure 504 {object} payments.Payment
+// @Header 504 {string} Location "URL of the payment"
+// @Failure 401 {object} api.ErrorResponseBody
+// @Security MerchantAPIKey
 // @Router /api/v1/payments [post]
 func (h *PaymentsHandler) PostHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log := LoggingFromContext(r.Context())
 		var paymentReq payments.PaymentRequest
 
-		if err := json.NewDecoder(r.Body).Decode(&paymentReq); err != nil {
+		body := http.MaxBytesReader(w, r.Body, h.maxBodyBytes)
+		if err := json.NewDecoder(body).Decode(&paymentReq); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				ErrorResponse(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+				return
+			}
 			ErrorResponse(w, http.StatusBadRequest, "invalid request body format")
 			return
 		}
+		paymentReq.MerchantID = MerchantIDFromContext(r.Context())
 
 		log.Info("Creating payment")
-		payment, err := h.service.CreatePayment(r.Context(), paymentReq)
+		create := h.service.CreatePayment
+		if prefersAsync(r) {
+			create = h.service.CreatePaymentAsync
+		}
+
+		payment, err := create(r.Context(), paymentReq)
 		if err != nil {
 			log.Error(fmt.Sprintf("Creating payment: %s", err.Error()))
 			var invalidPaymentRequestErr *payments.InvalidPaymentRequestErr
@@ -79,10 +151,34 @@ func (h *PaymentsHandler) PostHandler() http.HandlerFunc {
 				ErrorResponse(w, http.StatusBadRequest, invalidPaymentRequestErr.Message)
 				return
 			}
+			if errors.Is(err, payments.ErrQueueFull) {
+				w.Header().Set("Retry-After", "1")
+				ErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+				return
+			}
+			if errors.Is(err, payments.ErrShuttingDown) {
+				ErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+				return
+			}
 			ErrorResponse(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 
+		// The outcome of an interrupted authorization is unknown: retrying
+		// could charge the card twice, so it gets a status of its own.
+		if payment.ReconciliationRequired {
+			w.Header().Set("Location", "/api/v1/payments/"+payment.ID)
+			JSONResponse(w, http.StatusGatewayTimeout, payment)
+			return
+		}
+
+		if payment.Status == payments.StatusPending && prefersAsync(r) {
+			w.Header().Set("Preference-Applied", "respond-async")
+			w.Header().Set("Location", "/api/v1/payments/"+payment.ID)
+			JSONResponse(w, http.StatusAccepted, payment)
+			return
+		}
+
 		if payment.Status != payments.StatusAuthorized {
 			log.Warn("payment status is not authorized", "payment_id", payment.ID, "payment_status", payment.Status.String())
 		}
@@ -90,3 +186,255 @@ func (h *PaymentsHandler) PostHandler() http.HandlerFunc {
 		OKResponse(w, payment)
 	}
 }
+
+// EventsHandler godoc
+// @Summary Stream payment status changes
+// @Description Server-Sent Events stream sending the current state of the payment, then every status change until the payment reaches a terminal status (declined, rejected, expired, voided, blocked or captured).
+// @Description Each event is named after the status, e.g. "payment.authorized", and carries the payment as data. Its ID is the status: a payment never goes through the same status twice,
+// @Description so reconnecting with the Last-Event-ID header skips the state already received. Comments are sent periodically as heartbeats.
+// @Tags payments
+// @Produce text/event-stream
+// @Param id path string true "Payment ID"
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Success 200 {object} payments.Payment
+// @Failure 404 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Security MerchantAPIKey
+// @Router /api/v1/payments/{id}/events [get]
+func (h *PaymentsHandler) EventsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			ErrorResponse(w, http.StatusInternalServerError, "streaming is not supported")
+			return
+		}
+
+		// Subscribe before reading the payment so no change is missed.
+		changes, unsubscribe := h.service.SubscribePayment(id)
+		defer unsubscribe()
+
+		payment, err := h.merchantPayment(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, payments.NotFoundPaymentErr) {
+				ErrorResponse(w, http.StatusNotFound, err.Error())
+			} else {
+				ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+
+		// The stream outlives the write timeout of the server.
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		lastStatus := r.Header.Get("Last-Event-ID")
+
+		send := func(p *payments.Payment) (done bool, err error) {
+			status := p.Status.String()
+			if status != lastStatus {
+				if err := writePaymentEvent(w, p); err != nil {
+					return true, err
+				}
+				flusher.Flush()
+				lastStatus = status
+			}
+			return p.Status.IsTerminal(), nil
+		}
+
+		if done, err := send(payment); done || err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(h.heartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+
+			case p := <-changes:
+				if done, err := send(p); done || err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// merchantPayment returns the payment of the merchant of the request. The
+// payments of other merchants are reported as not found, not to reveal they
+// exist.
+func (h *PaymentsHandler) merchantPayment(ctx context.Context, id string) (*payments.Payment, error) {
+	payment, err := h.service.GetPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.MerchantID != MerchantIDFromContext(ctx) {
+		return nil, payments.NotFoundPaymentErr
+	}
+
+	return payment, nil
+}
+
+func writePaymentEvent(w http.ResponseWriter, payment *payments.Payment) error {
+	data, err := json.Marshal(payment)
+	if err != nil {
+		return err
+	}
+
+	status := payment.Status.String()
+	_, err = fmt.Fprintf(w, "id: %s\nevent: payment.%s\ndata: %s\n\n", status, status, data)
+	return err
+}
+
+// prefersAsync reports whether the client asked for an asynchronous
+// response, as defined by RFC 7240.
+func prefersAsync(r *http.Request) bool {
+	for _, header := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(header, ",") {
+			if strings.EqualFold(strings.TrimSpace(pref), "respond-async") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SearchPayments godoc
+// @Summary Search payments
+// @Description Lists the payments of the merchant matching the given reference and metadata, oldest first.
+// @Tags payments
+// @Produce json
+// @Param X-Merchant-ID header string false "Merchant identifier"
+// @Param reference query string false "Merchant reference"
+// @Param metadata query []string false "Metadata filter in key:value format" collectionFormat(multi)
+// @Param limit query int false "Maximum number of payments returned"
+// @Success 200 {array} payments.Payment
+// @Failure 400 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Security MerchantAPIKey
+// @Router /api/v1/payments [get]
+func (h *PaymentsHandler) SearchHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		merchantID := MerchantIDFromContext(r.Context())
+		if merchantID == "" {
+			ErrorResponse(w, http.StatusUnauthorized, "the merchant must be identified")
+			return
+		}
+
+		params := r.URL.Query()
+
+		query := payments.PaymentsQuery{
+			MerchantID: merchantID,
+			Reference:  params.Get("reference"),
+		}
+
+		for _, filter := range params["metadata"] {
+			key, value, ok := strings.Cut(filter, ":")
+			if !ok || key == "" {
+				ErrorResponse(w, http.StatusBadRequest, "metadata filters must use the key:value format")
+				return
+			}
+
+			if query.Metadata == nil {
+				query.Metadata = map[string]string{}
+			}
+			query.Metadata[key] = value
+		}
+
+		if limit := params.Get("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil || n < 0 {
+				ErrorResponse(w, http.StatusBadRequest, "limit must be a non-negative integer")
+				return
+			}
+			query.Limit = n
+		}
+
+		found, err := h.service.SearchPayments(r.Context(), query)
+		if err != nil {
+			ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if found == nil {
+			found = []*payments.Payment{}
+		}
+
+		OKResponse(w, found)
+	}
+}
+
+// ThreeDSCallbackResponse is all the cardholder's browser learns of the
+// payment, the merchant reads the rest with its own credentials.
+type ThreeDSCallbackResponse struct {
+	Status payments.PaymentStatus `json:"status" swaggertype:"string" example:"authorized" enums:"authorized,declined,rejected,pending,expired,voided,requires_action,blocked,held_for_review,captured"`
+}
+
+// CompleteAuthentication godoc
+// @Summary Complete 3-D Secure authentication
+// @Description Callback posted by the cardholder's browser once the 3-D Secure challenge is completed.
+// @Description Successful authentications are sent to the bank for authorization, failed ones decline the payment.
+// @Description Only the resulting status is returned, the callback is not authenticated.
+// @Tags payments
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param transaction_id formData string true "3-D Secure transaction ID"
+// @Success 200 {object} api.ThreeDSCallbackResponse
+// @Failure 400 {object} api.ErrorResponseBody
+// @Failure 404 {object} api.ErrorResponseBody
+// @Failure 409 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Failure 503 {object} api.ErrorResponseBody
+// @Router /api/v1/payments/3ds/callback [post]
+func (h *PaymentsHandler) ThreeDSCallbackHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := LoggingFromContext(r.Context())
+
+		transactionID := r.FormValue("transaction_id")
+		if transactionID == "" {
+			ErrorResponse(w, http.StatusBadRequest, "transaction_id is required")
+			return
+		}
+
+		payment, err := h.service.CompleteAuthentication(r.Context(), transactionID)
+		if err != nil {
+			log.Error(fmt.Sprintf("Completing authentication: %s", err.Error()))
+			switch {
+			case errors.Is(err, payments.ErrAuthenticationNotFound),
+				errors.Is(err, threeds.ErrSessionNotFound):
+				ErrorResponse(w, http.StatusNotFound, err.Error())
+			case errors.Is(err, threeds.ErrChallengeNotCompleted):
+				ErrorResponse(w, http.StatusConflict, err.Error())
+			case errors.Is(err, payments.ErrShuttingDown):
+				ErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+			default:
+				ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+
+		OKResponse(w, ThreeDSCallbackResponse{Status: payment.Status})
+	}
+}
@@ -1,17 +1,24 @@
 package api_test
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/api"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/threeds"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/require"
 )
@@ -19,6 +26,10 @@ import (
 type mockPaymentsRepository struct {
 	getFn func(ctx context.Context, id string) (*payments.Payment, error)
 	addFn func(ctx context.Context, payment *payments.Payment) error
+
+	updateFn      func(ctx context.Context, payment *payments.Payment) error
+	listExpiredFn func(ctx context.Context, at time.Time) ([]*payments.Payment, error)
+	searchFn      func(ctx context.Context, query payments.PaymentsQuery) ([]*payments.Payment, error)
 }
 
 func (m *mockPaymentsRepository) GetPayment(ctx context.Context, id string) (*payments.Payment, error) {
@@ -29,8 +40,22 @@ func (m *mockPaymentsRepository) AddPayment(ctx context.Context, payment *paymen
 	return m.addFn(ctx, payment)
 }
 
+func (m *mockPaymentsRepository) UpdatePayment(ctx context.Context, payment *payments.Payment) error {
+	return m.updateFn(ctx, payment)
+}
+
+func (m *mockPaymentsRepository) ListExpiredAuthorizations(ctx context.Context, at time.Time) ([]*payments.Payment, error) {
+	return m.listExpiredFn(ctx, at)
+}
+
+func (m *mockPaymentsRepository) SearchPayments(ctx context.Context, query payments.PaymentsQuery) ([]*payments.Payment, error) {
+	return m.searchFn(ctx, query)
+}
+
 type mockBankingSimulator struct {
 	authorizeFn func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error)
+	voidFn      func(ctx context.Context, authorizationCode string) error
+	captureFn   func(ctx context.Context, authorizationCode string, amount int64) error
 }
 
 func (m *mockBankingSimulator) Authorize(
@@ -40,6 +65,24 @@ func (m *mockBankingSimulator) Authorize(
 	return m.authorizeFn(ctx, req)
 }
 
+func (m *mockBankingSimulator) Void(ctx context.Context, authorizationCode string) error {
+	return m.voidFn(ctx, authorizationCode)
+}
+
+func (m *mockBankingSimulator) Capture(ctx context.Context, authorizationCode string, amount int64) error {
+	return m.captureFn(ctx, authorizationCode, amount)
+}
+
+// handlerNow pins the service clock so hard-coded expiry dates stay valid.
+var handlerNow = time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC)
+
+func newTestService(
+	repo payments.PaymentsRepository,
+	bank simulator.BankingSimulator,
+) *payments.Service {
+	return payments.NewService(repo, bank, payments.WithClock(clock.NewFake(handlerNow)))
+}
+
 func TestPaymentsHandler_PostHandler_Authorized(t *testing.T) {
 	t.Parallel()
 
@@ -59,7 +102,7 @@ func TestPaymentsHandler_PostHandler_Authorized(t *testing.T) {
 		},
 	}
 
-	svc := payments.NewService(repo, bank)
+	svc := newTestService(repo, bank)
 	handler := api.NewPaymentsHandler(svc)
 
 	body := payments.PaymentRequest{
@@ -80,6 +123,8 @@ func TestPaymentsHandler_PostHandler_Authorized(t *testing.T) {
 	handler.PostHandler().ServeHTTP(rec, req)
 
 	require.Equal(t, http.StatusOK, rec.Code)
+	// The fingerprint would let merchants link a card across merchants.
+	require.NotContains(t, rec.Body.String(), "card_fingerprint")
 }
 
 func TestPaymentsHandler_PostHandler_Declined(t *testing.T) {
@@ -98,7 +143,7 @@ func TestPaymentsHandler_PostHandler_Declined(t *testing.T) {
 		},
 	}
 
-	svc := payments.NewService(repo, bank)
+	svc := newTestService(repo, bank)
 	handler := api.NewPaymentsHandler(svc)
 
 	req := httptest.NewRequest(
@@ -123,7 +168,7 @@ func TestPaymentsHandler_PostHandler_Declined(t *testing.T) {
 func TestPaymentsHandler_PostHandler_Rejected(t *testing.T) {
 	t.Parallel()
 
-	svc := payments.NewService(nil, nil)
+	svc := newTestService(nil, nil)
 
 	handler := api.NewPaymentsHandler(svc)
 	req := httptest.NewRequest(
@@ -148,7 +193,7 @@ func TestPaymentsHandler_PostHandler_Rejected(t *testing.T) {
 func TestPaymentsHandler_PostHandler_InvalidJSON(t *testing.T) {
 	t.Parallel()
 
-	svc := payments.NewService(nil, nil)
+	svc := newTestService(nil, nil)
 
 	handler := api.NewPaymentsHandler(svc)
 	req := httptest.NewRequest(
@@ -163,6 +208,25 @@ func TestPaymentsHandler_PostHandler_InvalidJSON(t *testing.T) {
 	require.Equal(t, http.StatusBadRequest, rec.Code)
 }
 
+func TestPaymentsHandler_PostHandler_BodyTooLarge(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(nil, nil)
+
+	handler := api.NewPaymentsHandler(svc, api.WithMaxBodyBytes(16))
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/payments",
+		bytes.NewBufferString(`{"card_number":"4111111111111111"}`),
+	)
+	rec := httptest.NewRecorder()
+
+	handler.PostHandler().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	require.JSONEq(t, `{"error":"request body exceeds 16 bytes"}`, rec.Body.String())
+}
+
 func TestPaymentsHandler_PostHandler_BankError(t *testing.T) {
 	t.Parallel()
 
@@ -173,7 +237,7 @@ func TestPaymentsHandler_PostHandler_BankError(t *testing.T) {
 		},
 	}
 
-	svc := payments.NewService(repo, bank)
+	svc := newTestService(repo, bank)
 	handler := api.NewPaymentsHandler(svc)
 
 	req := httptest.NewRequest(
@@ -200,11 +264,11 @@ func TestPaymentsHandler_GetHandler_Found(t *testing.T) {
 
 	repo := &mockPaymentsRepository{
 		getFn: func(ctx context.Context, id string) (*payments.Payment, error) {
-			return &payments.Payment{ID: id}, nil
+			return &payments.Payment{ID: id, MerchantID: "merchant_123"}, nil
 		},
 	}
 
-	svc := payments.NewService(repo, nil)
+	svc := newTestService(repo, nil)
 	handler := api.NewPaymentsHandler(svc)
 
 	req := httptest.NewRequest(http.MethodGet, "/payments/123", nil)
@@ -212,13 +276,36 @@ func TestPaymentsHandler_GetHandler_Found(t *testing.T) {
 
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", "123")
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req = req.WithContext(context.WithValue(api.WithMerchantID(req.Context(), "merchant_123"), chi.RouteCtxKey, rctx))
 
 	handler.GetHandler().ServeHTTP(rec, req)
 
 	require.Equal(t, http.StatusOK, rec.Code)
 }
 
+func TestPaymentsHandler_GetHandler_OtherMerchant(t *testing.T) {
+	t.Parallel()
+
+	repo := &mockPaymentsRepository{
+		getFn: func(ctx context.Context, id string) (*payments.Payment, error) {
+			return &payments.Payment{ID: id, MerchantID: "merchant_123"}, nil
+		},
+	}
+
+	handler := api.NewPaymentsHandler(newTestService(repo, nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/payments/123", nil)
+	rec := httptest.NewRecorder()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "123")
+	req = req.WithContext(context.WithValue(api.WithMerchantID(req.Context(), "merchant_456"), chi.RouteCtxKey, rctx))
+
+	handler.GetHandler().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
 func TestPaymentsHandler_GetHandler_NotFound(t *testing.T) {
 	t.Parallel()
 
@@ -228,7 +315,7 @@ func TestPaymentsHandler_GetHandler_NotFound(t *testing.T) {
 		},
 	}
 
-	svc := payments.NewService(repo, nil)
+	svc := newTestService(repo, nil)
 	handler := api.NewPaymentsHandler(svc)
 
 	req := httptest.NewRequest(http.MethodGet, "/payments/123", nil)
@@ -242,3 +329,355 @@ func TestPaymentsHandler_GetHandler_NotFound(t *testing.T) {
 
 	require.Equal(t, http.StatusNotFound, rec.Code)
 }
+
+func TestPaymentsHandler_SearchHandler(t *testing.T) {
+	t.Parallel()
+
+	repo := &mockPaymentsRepository{
+		searchFn: func(ctx context.Context, query payments.PaymentsQuery) ([]*payments.Payment, error) {
+			require.Equal(t, payments.PaymentsQuery{
+				MerchantID: "merchant_123",
+				Reference:  "ORD-1",
+				Metadata:   map[string]string{"channel": "web", "coupon": "A:B"},
+				Limit:      10,
+			}, query)
+			return []*payments.Payment{{ID: "1", Reference: "ORD-1"}}, nil
+		},
+	}
+
+	svc := newTestService(repo, nil)
+	handler := api.NewPaymentsHandler(svc)
+
+	req := httptest.NewRequest(
+		http.MethodGet,
+		"/payments?reference=ORD-1&metadata=channel:web&metadata=coupon:A:B&limit=10",
+		nil,
+	)
+	req = req.WithContext(api.WithMerchantID(req.Context(), "merchant_123"))
+	rec := httptest.NewRecorder()
+
+	handler.SearchHandler().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var found []map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &found))
+	require.Len(t, found, 1)
+	require.Equal(t, "ORD-1", found[0]["reference"])
+}
+
+func TestPaymentsHandler_SearchHandler_InvalidMetadataFilter(t *testing.T) {
+	t.Parallel()
+
+	handler := api.NewPaymentsHandler(newTestService(nil, nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/payments?metadata=channel", nil)
+	req = req.WithContext(api.WithMerchantID(req.Context(), "merchant_123"))
+	rec := httptest.NewRecorder()
+
+	handler.SearchHandler().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestPaymentsHandler_SearchHandler_WithoutMerchant(t *testing.T) {
+	t.Parallel()
+
+	repo := &mockPaymentsRepository{
+		searchFn: func(ctx context.Context, query payments.PaymentsQuery) ([]*payments.Payment, error) {
+			t.Error("payments must not be searched without a merchant")
+			return nil, nil
+		},
+	}
+	handler := api.NewPaymentsHandler(newTestService(repo, nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/payments", nil)
+	rec := httptest.NewRecorder()
+
+	handler.SearchHandler().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestPaymentsHandler_ThreeDSCallbackHandler_UnknownTransaction(t *testing.T) {
+	t.Parallel()
+
+	handler := api.NewPaymentsHandler(newTestService(nil, nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/payments/3ds/callback?transaction_id=unknown", nil)
+	rec := httptest.NewRecorder()
+
+	handler.ThreeDSCallbackHandler().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestPaymentsHandler_ThreeDSCallbackHandler_ReturnsStatusOnly(t *testing.T) {
+	t.Parallel()
+
+	svc, acs, _ := newEventsTestServer(t)
+	payment := createChallengedPayment(t, svc)
+
+	callbackURL, err := acs.Complete(payment.ThreeDS.TransactionID, true)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, callbackURL, nil)
+	rec := httptest.NewRecorder()
+
+	api.NewPaymentsHandler(svc).ThreeDSCallbackHandler().ServeHTTP(rec, req)
+
+	// Anyone with the transaction ID can post it, so nothing else of the
+	// payment is disclosed.
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"status":"authorized"}`, rec.Body.String())
+}
+
+func TestPaymentsHandler_ThreeDSCallbackHandler_MissingTransaction(t *testing.T) {
+	t.Parallel()
+
+	handler := api.NewPaymentsHandler(newTestService(nil, nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/payments/3ds/callback", nil)
+	rec := httptest.NewRecorder()
+
+	handler.ThreeDSCallbackHandler().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestPaymentsHandler_PostHandler_Async(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.NewPaymentsRepositoryInMemory()
+	sealer, err := payments.NewCardSealer(bytes.Repeat([]byte{0x42}, 32))
+	require.NoError(t, err)
+
+	svc := payments.NewService(repo, &mockBankingSimulator{},
+		payments.WithClock(clock.NewFake(handlerNow)),
+		payments.WithAsyncAuthorization(repo, sealer, payments.AsyncPolicy{QueueSize: 1}),
+	)
+	handler := api.NewPaymentsHandler(svc)
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/payments", bytes.NewBufferString(`{
+			"card_number": "4111111111111111",
+			"expiry_month": 4,
+			"expiry_year": 2026,
+			"currency": "USD",
+			"amount": 1000,
+			"cvv": "123"
+		}`))
+		req.Header.Set("Prefer", "respond-async, wait=10")
+		rec := httptest.NewRecorder()
+		handler.PostHandler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post()
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	var payment map[string]any
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&payment))
+	require.Equal(t, "pending", payment["status"])
+	require.Equal(t, "/api/v1/payments/"+payment["id"].(string), rec.Header().Get("Location"))
+	require.Equal(t, "respond-async", rec.Header().Get("Preference-Applied"))
+
+	// No worker runs, so the single queue slot stays taken.
+	rec = post()
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, "1", rec.Header().Get("Retry-After"))
+}
+
+func TestPaymentsHandler_PostHandler_ReconciliationRequired(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The client goes away while the bank is authorizing the payment.
+	svc := newTestService(repository.NewPaymentsRepositoryInMemory(), &mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			cancel()
+			return nil, ctx.Err()
+		},
+	})
+	handler := api.NewPaymentsHandler(svc)
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/payments", bytes.NewBufferString(`{
+		"card_number": "4111111111111111",
+		"expiry_month": 4,
+		"expiry_year": 2026,
+		"currency": "USD",
+		"amount": 1000,
+		"cvv": "123"
+	}`))
+	rec := httptest.NewRecorder()
+	handler.PostHandler().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	require.Empty(t, rec.Header().Get("Preference-Applied"), "the client did not ask for an asynchronous response")
+
+	var payment map[string]any
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&payment))
+	require.Equal(t, "pending", payment["status"])
+	require.Equal(t, true, payment["reconciliation_required"])
+	require.Equal(t, "/api/v1/payments/"+payment["id"].(string), rec.Header().Get("Location"))
+}
+
+// sseEvent is a parsed Server-Sent Event, or a comment when only Comment is set.
+type sseEvent struct {
+	ID      string
+	Event   string
+	Data    string
+	Comment string
+}
+
+func readSSEEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	t.Helper()
+
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return event
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "":
+			event.Comment = value
+		case "id":
+			event.ID = value
+		case "event":
+			event.Event = value
+		case "data":
+			event.Data = value
+		}
+	}
+}
+
+func newEventsTestServer(t *testing.T, opts ...api.PaymentsHandlerOption) (*payments.Service, *threeds.Simulator, *httptest.Server) {
+	t.Helper()
+
+	acs := threeds.NewSimulator("http://acs.test")
+	svc := payments.NewService(
+		repository.NewPaymentsRepositoryInMemory(),
+		&mockBankingSimulator{
+			authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+				return &simulator.AuthorizationResponse{Authorized: true}, nil
+			},
+		},
+		payments.WithClock(clock.NewFake(handlerNow)),
+		payments.WithThreeDS(acs, "http://gateway.test/callback", payments.SCAPolicy{Required: true}),
+	)
+
+	r := chi.NewRouter()
+	r.Get("/payments/{id}/events", api.NewPaymentsHandler(svc, opts...).EventsHandler())
+
+	// Streams must outlive the write timeout of the server.
+	server := httptest.NewUnstartedServer(r)
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return svc, acs, server
+}
+
+func createChallengedPayment(t *testing.T, svc *payments.Service) *payments.Payment {
+	t.Helper()
+
+	payment, err := svc.CreatePayment(context.Background(), payments.PaymentRequest{
+		CardNumber:  "4111111111111111",
+		ExpiryMonth: 4,
+		ExpiryYear:  2026,
+		Currency:    "USD",
+		Amount:      1000,
+		CVV:         "123",
+	})
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusRequiresAction, payment.Status)
+
+	return payment
+}
+
+func openEventStream(t *testing.T, url, lastEventID string) *bufio.Reader {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	return bufio.NewReader(res.Body)
+}
+
+func TestPaymentsHandler_EventsHandler(t *testing.T) {
+	t.Parallel()
+
+	svc, acs, server := newEventsTestServer(t)
+	payment := createChallengedPayment(t, svc)
+
+	stream := openEventStream(t, server.URL+"/payments/"+payment.ID+"/events", "")
+
+	event := readSSEEvent(t, stream)
+	require.Equal(t, "requires_action", event.ID)
+	require.Equal(t, "payment.requires_action", event.Event)
+	require.Contains(t, event.Data, payment.ID)
+
+	// Past the write timeout of the server.
+	time.Sleep(200 * time.Millisecond)
+
+	_, err := acs.Complete(payment.ThreeDS.TransactionID, false)
+	require.NoError(t, err)
+	_, err = svc.CompleteAuthentication(context.Background(), payment.ThreeDS.TransactionID)
+	require.NoError(t, err)
+
+	event = readSSEEvent(t, stream)
+	require.Equal(t, "declined", event.ID)
+	require.Equal(t, "payment.declined", event.Event)
+
+	// The stream ends once the payment is final.
+	_, err = stream.ReadString('\n')
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestPaymentsHandler_EventsHandler_LastEventIDAndHeartbeat(t *testing.T) {
+	t.Parallel()
+
+	svc, _, server := newEventsTestServer(t, api.WithHeartbeatInterval(10*time.Millisecond))
+	payment := createChallengedPayment(t, svc)
+
+	// The client already received the current state, so only heartbeats
+	// are sent until the payment changes.
+	stream := openEventStream(t, server.URL+"/payments/"+payment.ID+"/events", "requires_action")
+
+	event := readSSEEvent(t, stream)
+	require.Equal(t, sseEvent{Comment: "heartbeat"}, event)
+}
+
+func TestPaymentsHandler_EventsHandler_NotFound(t *testing.T) {
+	t.Parallel()
+
+	_, _, server := newEventsTestServer(t)
+
+	res, err := http.Get(server.URL + "/payments/unknown/events")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}
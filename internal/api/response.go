@@ -18,11 +18,16 @@ var ErrJSONResponseSerialization = errors.New("json response serialization error
 //
 // If serialization or writing fails, an error is returned.
 func OKResponse(w http.ResponseWriter, data any) error {
+	return JSONResponse(w, http.StatusOK, data)
+}
+
+// JSONResponse writes the data as JSON with the given HTTP status code.
+func JSONResponse(w http.ResponseWriter, status int, data any) error {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(status)
 
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		return fmt.Errorf("encode ok response: %w", ErrJSONResponseSerialization)
+		return fmt.Errorf("encode %d response: %w", status, ErrJSONResponseSerialization)
 	}
 
 	return nil
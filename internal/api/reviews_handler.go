@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/go-chi/chi/v5"
+)
+
+// OperatorPayment is a payment as operators see it, with the card
+// fingerprint they block or allow cards by. Merchants never see it: it is
+// the same for every merchant, so it would let them link a card across
+// merchants.
+type OperatorPayment struct {
+	*payments.Payment
+	CardFingerprint string `json:"card_fingerprint,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"` // Keyed fingerprint identifying the card without revealing its number.
+}
+
+func operatorPayment(payment *payments.Payment) OperatorPayment {
+	return OperatorPayment{Payment: payment, CardFingerprint: payment.CardFingerprint}
+}
+
+// ListReviews godoc
+// @Summary List payments held for review
+// @Description Lists the payments of every merchant flagged by the fraud screening and waiting for a manual review, oldest first.
+// @Tags reviews
+// @Produce json
+// @Param limit query int false "Maximum number of payments returned"
+// @Success 200 {array} api.OperatorPayment
+// @Failure 400 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Security OperatorAPIKey
+// @Router /api/v1/admin/reviews [get]
+func (h *PaymentsHandler) ListReviewsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := 0
+		if param := r.URL.Query().Get("limit"); param != "" {
+			n, err := strconv.Atoi(param)
+			if err != nil || n < 0 {
+				ErrorResponse(w, http.StatusBadRequest, "limit must be a non-negative integer")
+				return
+			}
+			limit = n
+		}
+
+		held, err := h.service.ListHeldPayments(r.Context(), limit)
+		if err != nil {
+			ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		views := make([]OperatorPayment, len(held))
+		for i, payment := range held {
+			views[i] = operatorPayment(payment)
+		}
+
+		OKResponse(w, views)
+	}
+}
+
+// ApproveReview godoc
+// @Summary Approve a payment held for review
+// @Description Captures the payment. The authenticated operator is recorded as the reviewer.
+// @Tags reviews
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Success 200 {object} api.OperatorPayment
+// @Failure 400 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Failure 404 {object} api.ErrorResponseBody
+// @Failure 409 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Failure 503 {object} api.ErrorResponseBody
+// @Security OperatorAPIKey
+// @Router /api/v1/admin/reviews/{id}/approve [post]
+func (h *PaymentsHandler) ApproveReviewHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payment, err := h.service.ApproveReview(r.Context(), chi.URLParam(r, "id"), ActorIDFromContext(r.Context()))
+		if err != nil {
+			reviewErrorResponse(w, err)
+			return
+		}
+
+		OKResponse(w, operatorPayment(payment))
+	}
+}
+
+// RejectReview godoc
+// @Summary Reject a payment held for review
+// @Description Voids the payment. The authenticated operator is recorded as the reviewer.
+// @Tags reviews
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Success 200 {object} api.OperatorPayment
+// @Failure 400 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Failure 404 {object} api.ErrorResponseBody
+// @Failure 409 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Failure 503 {object} api.ErrorResponseBody
+// @Security OperatorAPIKey
+// @Router /api/v1/admin/reviews/{id}/reject [post]
+func (h *PaymentsHandler) RejectReviewHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payment, err := h.service.RejectReview(r.Context(), chi.URLParam(r, "id"), ActorIDFromContext(r.Context()))
+		if err != nil {
+			reviewErrorResponse(w, err)
+			return
+		}
+
+		OKResponse(w, operatorPayment(payment))
+	}
+}
+
+func reviewErrorResponse(w http.ResponseWriter, err error) {
+	var invalidPaymentRequestErr *payments.InvalidPaymentRequestErr
+	switch {
+	case errors.As(err, &invalidPaymentRequestErr):
+		ErrorResponse(w, http.StatusBadRequest, invalidPaymentRequestErr.Message)
+	case errors.Is(err, payments.NotFoundPaymentErr):
+		ErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, payments.ErrNotHeldForReview):
+		ErrorResponse(w, http.StatusConflict, err.Error())
+	case errors.Is(err, simulator.ErrAuthorizationUnavailable):
+		w.Header().Set("Retry-After", "1")
+		ErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		ErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
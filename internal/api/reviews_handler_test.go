@@ -0,0 +1,95 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/api"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/fraud"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentsHandler_Reviews(t *testing.T) {
+	t.Parallel()
+
+	rules, err := fraud.ParseRules([]byte("rules: [{name: review_all, action: review}]"))
+	require.NoError(t, err)
+	engine := fraud.NewEngine(rules, repository.NewVelocityCountersInMemory(time.Hour))
+
+	bank := &mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			return &simulator.AuthorizationResponse{Authorized: true, AuthorizationCode: "AUTH123"}, nil
+		},
+		captureFn: func(ctx context.Context, authorizationCode string, amount int64) error {
+			return nil
+		},
+	}
+
+	svc := payments.NewService(
+		repository.NewPaymentsRepositoryInMemory(),
+		bank,
+		payments.WithClock(clock.NewFake(handlerNow)),
+		payments.WithFraudScreening(engine),
+	)
+
+	held, err := svc.CreatePayment(context.Background(), payments.PaymentRequest{
+		CardNumber:  "2222405343248877",
+		ExpiryMonth: 12,
+		ExpiryYear:  2050,
+		Currency:    "USD",
+		Amount:      1000,
+		CVV:         "123",
+	})
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusHeldForReview, held.Status)
+
+	handler := api.NewPaymentsHandler(svc)
+	r := chi.NewRouter()
+	r.Use(api.OperatorAPIKeys(testOperators))
+	r.Use(api.RequireOperator)
+	r.Get("/admin/reviews", handler.ListReviewsHandler())
+	r.Post("/admin/reviews/{id}/approve", handler.ApproveReviewHandler())
+	r.Post("/admin/reviews/{id}/reject", handler.RejectReviewHandler())
+
+	serve := func(method, target, actor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		asOperator(req, actor)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	require.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/admin/reviews", "").Code)
+
+	rec := serve(http.MethodGet, "/admin/reviews", "analyst")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var listed []map[string]any
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&listed))
+	require.Len(t, listed, 1)
+	require.Equal(t, held.ID, listed[0]["id"])
+	require.Equal(t, held.CardFingerprint, listed[0]["card_fingerprint"], "operators block and allow cards by fingerprint")
+
+	// The reviewer cannot be claimed with a header.
+	req := httptest.NewRequest(http.MethodPost, "/admin/reviews/"+held.ID+"/approve", nil)
+	req.Header.Set("X-Actor-ID", "analyst")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	require.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/admin/reviews/unknown/approve", "analyst").Code)
+
+	rec = serve(http.MethodPost, "/admin/reviews/"+held.ID+"/approve", "analyst")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"status":"captured"`)
+
+	require.Equal(t, http.StatusConflict, serve(http.MethodPost, "/admin/reviews/"+held.ID+"/reject", "analyst").Code)
+}
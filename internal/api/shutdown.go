@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Drainer is a service with work in flight that must complete, or be
+// recorded as interrupted, before the process exits.
+type Drainer interface {
+	Drain(ctx context.Context) error
+}
+
+// ShutdownPolicy paces the graceful shutdown.
+type ShutdownPolicy struct {
+	// ReadinessDelay is how long the server keeps serving once /readyz
+	// fails, so load balancers stop routing new traffic to it first.
+	ReadinessDelay time.Duration
+	// DrainTimeout bounds how long requests in flight and the drainers get
+	// to complete. Requests left are then cancelled and their connections
+	// closed.
+	DrainTimeout time.Duration
+}
+
+// DefaultShutdownPolicy is used unless WithShutdownPolicy is given.
+var DefaultShutdownPolicy = ShutdownPolicy{
+	ReadinessDelay: 5 * time.Second,
+	DrainTimeout:   20 * time.Second,
+}
+
+// closeOnShutdown ends long-lived requests, such as event streams, when the
+// drain starts. The server would otherwise wait for them until the drain
+// timeout.
+func (a *Api) closeOnShutdown(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		stop := context.AfterFunc(a.draining, cancel)
+		defer stop()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// drain waits for the requests in flight and the drainers, then closes the
+// remaining connections. cancelRequests cancels the context of the requests
+// still running at the drain timeout.
+func (a *Api) drain(httpServer *http.Server, cancelRequests context.CancelFunc) error {
+	a.stopDraining()
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.shutdown.DrainTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, drainer := range a.drainers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// The work interrupted is recorded by the drainer, there is
+			// nothing more to do about it here.
+			if err := drainer.Drain(ctx); err != nil {
+				slog.Warn("draining", "error", err)
+			}
+		}()
+	}
+
+	err := httpServer.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		slog.Warn("drain timeout exceeded, closing the remaining connections",
+			"drain_timeout", a.shutdown.DrainTimeout)
+		cancelRequests()
+		// Let the drainers record the interrupted work before the
+		// connections of their requests are closed.
+		wg.Wait()
+		return httpServer.Close()
+	}
+
+	wg.Wait()
+	return err
+}
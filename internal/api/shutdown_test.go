@@ -0,0 +1,154 @@
+package api_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type drainerFunc func(ctx context.Context) error
+
+func (f drainerFunc) Drain(ctx context.Context) error { return f(ctx) }
+
+// freeAddr returns a local address nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	return addr
+}
+
+// blockPing holds /api/v1/ping requests until release is closed or their
+// context is cancelled, reporting each one on started.
+func blockPing(started chan<- struct{}, release <-chan struct{}) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/v1/ping" {
+				started <- struct{}{}
+				select {
+				case <-release:
+				case <-r.Context().Done():
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func runApi(t *testing.T, a *api.Api) (addr string, cancel context.CancelFunc, stopped <-chan error) {
+	t.Helper()
+
+	addr = freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() { errs <- a.Run(ctx, addr) }()
+	t.Cleanup(cancel)
+
+	require.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + addr + "/healthz")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return addr, cancel, errs
+}
+
+func TestApi_Run_DrainsOnShutdown(t *testing.T) {
+	t.Parallel()
+
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	drained := make(chan struct{})
+	a := api.New(nil, nil, nil,
+		api.WithMiddlewares(blockPing(started, release)),
+		api.WithShutdownPolicy(api.ShutdownPolicy{ReadinessDelay: 200 * time.Millisecond, DrainTimeout: 5 * time.Second}),
+		api.WithDrainers(drainerFunc(func(ctx context.Context) error {
+			close(drained)
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})),
+	)
+	addr, cancel, stopped := runApi(t, a)
+
+	pinged := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/api/v1/ping")
+		if !assert.NoError(t, err) {
+			pinged <- 0
+			return
+		}
+		resp.Body.Close()
+		pinged <- resp.StatusCode
+	}()
+	<-started
+
+	cancel()
+
+	// Load balancers see the readiness probe fail while the server still
+	// accepts connections.
+	require.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + addr + "/readyz")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusServiceUnavailable
+	}, time.Second, 10*time.Millisecond)
+
+	<-drained
+	select {
+	case <-stopped:
+		t.Fatal("stopped with a request in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	assert.Equal(t, http.StatusNoContent, <-pinged)
+	require.NoError(t, <-stopped)
+}
+
+func TestApi_Run_DrainTimeout(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{}, 1)
+	a := api.New(nil, nil, nil,
+		api.WithMiddlewares(blockPing(started, nil)),
+		api.WithShutdownPolicy(api.ShutdownPolicy{DrainTimeout: 50 * time.Millisecond}),
+	)
+	addr, cancel, stopped := runApi(t, a)
+
+	pinged := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/api/v1/ping")
+		if err == nil {
+			resp.Body.Close()
+		}
+		pinged <- err
+	}()
+	<-started
+
+	start := time.Now()
+	cancel()
+
+	// The request is cancelled and its connection closed once the drain
+	// timeout has passed.
+	require.NoError(t, <-stopped)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	<-pinged
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/webhooks"
+	"github.com/go-chi/chi/v5"
+)
+
+type WebhooksHandler struct {
+	service *webhooks.Service
+}
+
+func NewWebhooksHandler(svc *webhooks.Service) *WebhooksHandler {
+	return &WebhooksHandler{svc}
+}
+
+type CreateEndpointRequest struct {
+	URL    string               `json:"url" example:"https://merchant.example/webhooks"`
+	Events []payments.EventType `json:"events" example:"payment.authorized,payment.declined"` // Subscribed events, every event when empty.
+}
+
+// CreateEndpoint godoc
+// @Summary Register a webhook endpoint
+// @Description Registers an URL receiving the payment events of the merchant.
+// @Description The signing secret is only returned in this response, use it to verify the X-Webhook-Signature header.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-Merchant-ID header string false "Merchant identifier"
+// @Param request body api.CreateEndpointRequest true "Endpoint"
+// @Success 200 {object} webhooks.Endpoint
+// @Failure 400 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Security MerchantAPIKey
+// @Router /api/v1/webhooks/endpoints [post]
+func (h *WebhooksHandler) CreateEndpointHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateEndpointRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "invalid request body format")
+			return
+		}
+
+		endpoint, err := h.service.CreateEndpoint(r.Context(), MerchantIDFromContext(r.Context()), req.URL, req.Events)
+		if err != nil {
+			var invalidEndpointErr *webhooks.InvalidEndpointErr
+			if errors.As(err, &invalidEndpointErr) {
+				ErrorResponse(w, http.StatusBadRequest, invalidEndpointErr.Message)
+				return
+			}
+			ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		OKResponse(w, endpoint)
+	}
+}
+
+// ListEndpoints godoc
+// @Summary List webhook endpoints
+// @Description Lists the webhook endpoints of the merchant, oldest first.
+// @Tags webhooks
+// @Produce json
+// @Param X-Merchant-ID header string false "Merchant identifier"
+// @Success 200 {array} webhooks.Endpoint
+// @Failure 500 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Security MerchantAPIKey
+// @Router /api/v1/webhooks/endpoints [get]
+func (h *WebhooksHandler) ListEndpointsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		endpoints, err := h.service.ListEndpoints(r.Context(), MerchantIDFromContext(r.Context()))
+		if err != nil {
+			ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if endpoints == nil {
+			endpoints = []*webhooks.Endpoint{}
+		}
+
+		OKResponse(w, endpoints)
+	}
+}
+
+// DeleteEndpoint godoc
+// @Summary Delete a webhook endpoint
+// @Tags webhooks
+// @Param X-Merchant-ID header string false "Merchant identifier"
+// @Param id path string true "Endpoint ID"
+// @Success 204 "Endpoint deleted"
+// @Failure 404 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Security MerchantAPIKey
+// @Router /api/v1/webhooks/endpoints/{id} [delete]
+func (h *WebhooksHandler) DeleteEndpointHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := h.service.DeleteEndpoint(r.Context(), MerchantIDFromContext(r.Context()), chi.URLParam(r, "id"))
+		if err != nil {
+			if errors.Is(err, webhooks.ErrEndpointNotFound) {
+				ErrorResponse(w, http.StatusNotFound, err.Error())
+			} else {
+				ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ListDeliveries godoc
+// @Summary List webhook deliveries
+// @Description Lists the delivery logs of the merchant, oldest first, including every attempt made.
+// @Tags webhooks
+// @Produce json
+// @Param X-Merchant-ID header string false "Merchant identifier"
+// @Param endpoint_id query string false "Endpoint ID"
+// @Param event_id query string false "Event ID"
+// @Param status query string false "Delivery status" Enums(pending, succeeded, failed)
+// @Param limit query int false "Maximum number of deliveries returned"
+// @Success 200 {array} webhooks.Delivery
+// @Failure 400 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Security MerchantAPIKey
+// @Router /api/v1/webhooks/deliveries [get]
+func (h *WebhooksHandler) ListDeliveriesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+
+		query := webhooks.DeliveriesQuery{
+			MerchantID: MerchantIDFromContext(r.Context()),
+			EndpointID: params.Get("endpoint_id"),
+			EventID:    params.Get("event_id"),
+			Status:     webhooks.DeliveryStatus(params.Get("status")),
+		}
+
+		if limit := params.Get("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil || n < 0 {
+				ErrorResponse(w, http.StatusBadRequest, "limit must be a non-negative integer")
+				return
+			}
+			query.Limit = n
+		}
+
+		deliveries, err := h.service.ListDeliveries(r.Context(), query)
+		if err != nil {
+			ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if deliveries == nil {
+			deliveries = []*webhooks.Delivery{}
+		}
+
+		OKResponse(w, deliveries)
+	}
+}
+
+// Redeliver godoc
+// @Summary Redeliver a webhook event
+// @Description Schedules a new delivery of the event to the same endpoint, attempted right away.
+// @Tags webhooks
+// @Produce json
+// @Param X-Merchant-ID header string false "Merchant identifier"
+// @Param id path string true "Delivery ID"
+// @Success 200 {object} webhooks.Delivery
+// @Failure 404 {object} api.ErrorResponseBody
+// @Failure 500 {object} api.ErrorResponseBody
+// @Failure 401 {object} api.ErrorResponseBody
+// @Security MerchantAPIKey
+// @Router /api/v1/webhooks/deliveries/{id}/redeliver [post]
+func (h *WebhooksHandler) RedeliverHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		delivery, err := h.service.Redeliver(r.Context(), MerchantIDFromContext(r.Context()), chi.URLParam(r, "id"))
+		if err != nil {
+			switch {
+			case errors.Is(err, webhooks.ErrDeliveryNotFound),
+				errors.Is(err, webhooks.ErrEndpointNotFound):
+				ErrorResponse(w, http.StatusNotFound, err.Error())
+			default:
+				ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+
+		OKResponse(w, delivery)
+	}
+}
@@ -0,0 +1,95 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/api"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/webhooks"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooksHandler_CreateEndpointHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "valid endpoint",
+			body:           `{"url":"https://merchant.example/hooks","events":["payment.authorized"]}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid url",
+			body:           `{"url":"merchant.example/hooks"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown event",
+			body:           `{"url":"https://merchant.example/hooks","events":["payment.unknown"]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "malformed body",
+			body:           `{`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := api.NewWebhooksHandler(webhooks.NewService(repository.NewWebhooksRepositoryInMemory()))
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/endpoints", strings.NewReader(tt.body))
+			req = req.WithContext(api.WithMerchantID(req.Context(), "merchant_a"))
+			rec := httptest.NewRecorder()
+
+			handler.CreateEndpointHandler().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var endpoint webhooks.Endpoint
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&endpoint))
+				require.Equal(t, "merchant_a", endpoint.MerchantID)
+				require.NotEmpty(t, endpoint.Secret)
+			}
+		})
+	}
+}
+
+func TestWebhooksHandler_DeleteEndpointHandler_OtherMerchant(t *testing.T) {
+	t.Parallel()
+
+	svc := webhooks.NewService(repository.NewWebhooksRepositoryInMemory())
+	endpoint, err := svc.CreateEndpoint(context.Background(), "merchant_a", "https://merchant.example/hooks", nil)
+	require.NoError(t, err)
+
+	handler := api.NewWebhooksHandler(svc)
+
+	r := chi.NewRouter()
+	r.Delete("/webhooks/endpoints/{id}", handler.DeleteEndpointHandler())
+
+	del := func(merchantID string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/webhooks/endpoints/"+endpoint.ID, nil)
+		req = req.WithContext(api.WithMerchantID(req.Context(), merchantID))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusNotFound, del("merchant_b"))
+	require.Equal(t, http.StatusNoContent, del("merchant_a"))
+	require.Equal(t, http.StatusNotFound, del("merchant_a"))
+}
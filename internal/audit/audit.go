@@ -0,0 +1,99 @@
+// Package audit keeps an append-only, hash-chained record of who changed
+// what through the API, for PCI and dispute handling.
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrTampered is returned when an entry does not match its hash or does
+	// not follow the previous entry.
+	ErrTampered = errors.New("audit trail tampered")
+	// ErrOutOfSequence is returned by stores when an entry does not directly
+	// follow the last one.
+	ErrOutOfSequence = errors.New("audit entry out of sequence")
+)
+
+// SystemActor is the actor of the changes the gateway makes on its own, like
+// its background sweeps.
+const SystemActor = "system"
+
+// Entry records one state-changing operation. Entries are chained: each
+// hash covers the entry and the hash of the previous one, so altering,
+// removing or reordering entries breaks the chain from that point on. Keyed
+// with a secret, the chain cannot be rebuilt by whoever can write the store.
+type Entry struct {
+	Sequence     int64     `json:"sequence" example:"42"`                                                // Position in the trail, starting at 1.
+	OccurredAt   time.Time `json:"occurred_at" example:"2025-01-01T12:00:00Z"`                           // When the operation completed.
+	ActorID      string    `json:"actor_id,omitempty" example:"ops@example.com"`                         // Operator who made the change, authenticated by their API key, or SystemActor.
+	MerchantID   string    `json:"merchant_id,omitempty" example:"merchant_123"`                         // Merchant the change was made for, from X-Merchant-ID.
+	Operation    string    `json:"operation" example:"POST /api/v1/admin/reviews/{id}/approve"`          // Method and route of the call, or the background operation.
+	ResourceID   string    `json:"resource_id,omitempty" example:"0190d9b1-7d4e-7bb1-9c4e-6f1d2a3b4c5d"` // ID in the route, if any.
+	PaymentID    string    `json:"payment_id,omitempty" example:"0190d9b1-7d4e-7bb1-9c4e-6f1d2a3b4c5d"`  // Payment changed by the operation.
+	BeforeStatus string    `json:"before_status,omitempty" example:"held_for_review"`                    // Status of the payment before the change, empty when created.
+	AfterStatus  string    `json:"after_status,omitempty" example:"captured"`                            // Status of the payment after the change.
+	Result       int       `json:"result" example:"200"`                                                 // HTTP status code of the call, 0 for background operations.
+	RequestID    string    `json:"request_id,omitempty"`
+	SourceIP     string    `json:"source_ip,omitempty" example:"203.0.113.7"`
+	PrevHash     string    `json:"prev_hash"` // Hash of the previous entry, empty for the first one.
+	Hash         string    `json:"hash"`      // HMAC-SHA256 of the entry with the chain key, SHA-256 without one, hex encoded.
+}
+
+// computeHash hashes the entry with its hash left out, keyed with key
+// unless it is empty.
+func (e Entry) computeHash(key []byte) (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+
+	if len(key) == 0 {
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:]), nil
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Query filters entries. Empty fields match every entry.
+type Query struct {
+	ActorID    string
+	MerchantID string
+	Operation  string
+	PaymentID  string
+	From       time.Time // Inclusive.
+	To         time.Time // Exclusive.
+	Limit      int
+}
+
+// Matches reports whether the entry matches the query, ignoring Limit.
+func (q Query) Matches(e *Entry) bool {
+	return (q.ActorID == "" || e.ActorID == q.ActorID) &&
+		(q.MerchantID == "" || e.MerchantID == q.MerchantID) &&
+		(q.Operation == "" || e.Operation == q.Operation) &&
+		(q.PaymentID == "" || e.PaymentID == q.PaymentID) &&
+		(q.From.IsZero() || !e.OccurredAt.Before(q.From)) &&
+		(q.To.IsZero() || e.OccurredAt.Before(q.To))
+}
+
+// Store persists the trail. It only appends: entries are never updated or
+// deleted.
+type Store interface {
+	// Append stores the entry, failing with ErrOutOfSequence unless it
+	// directly follows the last entry.
+	Append(ctx context.Context, entry *Entry) error
+	// Last returns the last entry, or nil when the trail is empty.
+	Last(ctx context.Context) (*Entry, error)
+	// List returns the entries matching the query in sequence order.
+	List(ctx context.Context, query Query) ([]*Entry, error)
+}
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"context"
+	"sync"
+)
+
+// Change collects what an audited call changed, as it goes through the
+// layers serving it.
+type Change struct {
+	mu           sync.Mutex
+	paymentID    string
+	beforeStatus string
+	afterStatus  string
+}
+
+type changeKeyType struct{}
+
+var changeKey = changeKeyType{}
+
+// WithChange starts collecting the changes made with the returned context.
+func WithChange(ctx context.Context) (context.Context, *Change) {
+	change := &Change{}
+	return context.WithValue(ctx, changeKey, change), change
+}
+
+// RecordPaymentChange notes the payment status change made by the audited
+// call, if any. The before status is empty when the payment was created.
+func RecordPaymentChange(ctx context.Context, paymentID, beforeStatus, afterStatus string) {
+	change, ok := ctx.Value(changeKey).(*Change)
+	if !ok {
+		return
+	}
+
+	change.mu.Lock()
+	defer change.mu.Unlock()
+
+	change.paymentID = paymentID
+	change.beforeStatus = beforeStatus
+	change.afterStatus = afterStatus
+}
+
+// Recorded reports whether a payment change was noted.
+func (c *Change) Recorded() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.paymentID != ""
+}
+
+// apply copies the change to the entry.
+func (c *Change) apply(entry *Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.PaymentID = c.paymentID
+	entry.BeforeStatus = c.beforeStatus
+	entry.AfterStatus = c.afterStatus
+}
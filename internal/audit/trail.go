@@ -0,0 +1,162 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+)
+
+// Trail appends entries to the store, chaining their hashes.
+type Trail struct {
+	store    Store
+	clock    clock.Clock
+	chainKey []byte
+
+	// mu serializes appends so every entry chains to the last one.
+	mu sync.Mutex
+}
+
+// Option configures optional dependencies of the Trail.
+type Option func(*Trail)
+
+// WithClock sets the clock used to stamp entries.
+func WithClock(clk clock.Clock) Option {
+	return func(t *Trail) {
+		t.clock = clk
+	}
+}
+
+// WithChainKey keys the hashes of the entries with a secret, so only its
+// holders can chain entries: without it, whoever can write the store can
+// alter an entry and recompute the hashes of those following it.
+func WithChainKey(key []byte) Option {
+	return func(t *Trail) {
+		t.chainKey = key
+	}
+}
+
+func NewTrail(store Store, opts ...Option) *Trail {
+	t := &Trail{
+		store: store,
+		clock: clock.New(),
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// Record appends the entry, with the payment change collected in change if
+// any, and returns it with its sequence and hashes set.
+func (t *Trail) Record(ctx context.Context, entry Entry, change *Change) (*Entry, error) {
+	if change != nil {
+		change.apply(&entry)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	last, err := t.store.Last(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read last audit entry: %w", err)
+	}
+
+	entry.Sequence = 1
+	entry.PrevHash = ""
+	if last != nil {
+		entry.Sequence = last.Sequence + 1
+		entry.PrevHash = last.Hash
+	}
+	entry.OccurredAt = t.clock.Now().UTC()
+
+	if entry.Hash, err = entry.computeHash(t.chainKey); err != nil {
+		return nil, fmt.Errorf("hash audit entry: %w", err)
+	}
+
+	if err := t.store.Append(ctx, &entry); err != nil {
+		return nil, fmt.Errorf("append audit entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// RecordSystemChange appends an entry for a payment status change the
+// gateway made on its own, without a call to attribute it to, like an
+// expired authorization.
+func (t *Trail) RecordSystemChange(ctx context.Context, operation, merchantID, paymentID, beforeStatus, afterStatus string) (*Entry, error) {
+	return t.Record(ctx, Entry{
+		ActorID:      SystemActor,
+		MerchantID:   merchantID,
+		Operation:    operation,
+		ResourceID:   paymentID,
+		PaymentID:    paymentID,
+		BeforeStatus: beforeStatus,
+		AfterStatus:  afterStatus,
+	}, nil)
+}
+
+// Query returns the entries matching the query in sequence order.
+func (t *Trail) Query(ctx context.Context, query Query) ([]*Entry, error) {
+	entries, err := t.store.List(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list audit entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Export writes the entries matching the query as NDJSON, one entry per
+// line, and returns how many were written.
+func (t *Trail) Export(ctx context.Context, w io.Writer, query Query) (int, error) {
+	entries, err := t.Query(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	enc := json.NewEncoder(w)
+	for i, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return i, fmt.Errorf("encode audit entry %d: %w", entry.Sequence, err)
+		}
+	}
+
+	return len(entries), nil
+}
+
+// Verify walks the whole trail and checks every entry matches its hash and
+// follows the previous one. It returns how many entries were verified, and
+// an error wrapping ErrTampered naming the first broken entry.
+func (t *Trail) Verify(ctx context.Context) (int, error) {
+	entries, err := t.Query(ctx, Query{})
+	if err != nil {
+		return 0, err
+	}
+
+	prevHash := ""
+	for i, entry := range entries {
+		if entry.Sequence != int64(i+1) {
+			return i, fmt.Errorf("%w: entry %d found at position %d", ErrTampered, entry.Sequence, i+1)
+		}
+		if entry.PrevHash != prevHash {
+			return i, fmt.Errorf("%w: entry %d does not follow the previous entry", ErrTampered, entry.Sequence)
+		}
+
+		hash, err := entry.computeHash(t.chainKey)
+		if err != nil {
+			return i, fmt.Errorf("hash audit entry %d: %w", entry.Sequence, err)
+		}
+		if hash != entry.Hash {
+			return i, fmt.Errorf("%w: entry %d does not match its hash", ErrTampered, entry.Sequence)
+		}
+
+		prevHash = entry.Hash
+	}
+
+	return len(entries), nil
+}
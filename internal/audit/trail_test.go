@@ -0,0 +1,254 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/audit"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var auditNow = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// tamperableStore shares its entries with the test, so they can be altered
+// the way someone with access to the database could.
+type tamperableStore struct {
+	entries []*audit.Entry
+}
+
+func (s *tamperableStore) Append(_ context.Context, entry *audit.Entry) error {
+	e := *entry
+	s.entries = append(s.entries, &e)
+	return nil
+}
+
+func (s *tamperableStore) Last(_ context.Context) (*audit.Entry, error) {
+	if len(s.entries) == 0 {
+		return nil, nil
+	}
+	e := *s.entries[len(s.entries)-1]
+	return &e, nil
+}
+
+func (s *tamperableStore) List(_ context.Context, query audit.Query) ([]*audit.Entry, error) {
+	var found []*audit.Entry
+	for _, entry := range s.entries {
+		if query.Matches(entry) {
+			e := *entry
+			found = append(found, &e)
+		}
+	}
+	return found, nil
+}
+
+// newTrail returns a trail holding a payment creation, an approval and a
+// list entry creation.
+func newTrail(t *testing.T) (*audit.Trail, *tamperableStore) {
+	t.Helper()
+
+	store := &tamperableStore{}
+	clk := clock.NewFake(auditNow)
+	trail := audit.NewTrail(store, audit.WithClock(clk))
+
+	ctx := context.Background()
+
+	createCtx, change := audit.WithChange(ctx)
+	audit.RecordPaymentChange(createCtx, "pay_1", "", "held_for_review")
+	_, err := trail.Record(ctx, audit.Entry{MerchantID: "merchant_a", Operation: "POST /api/v1/payments", Result: 200}, change)
+	require.NoError(t, err)
+
+	clk.Advance(time.Minute)
+	reviewCtx, change := audit.WithChange(ctx)
+	audit.RecordPaymentChange(reviewCtx, "pay_1", "held_for_review", "captured")
+	_, err = trail.Record(ctx, audit.Entry{ActorID: "ops", Operation: "POST /api/v1/admin/reviews/{id}/approve", ResourceID: "pay_1", Result: 200}, change)
+	require.NoError(t, err)
+
+	clk.Advance(time.Minute)
+	_, err = trail.Record(ctx, audit.Entry{ActorID: "ops", Operation: "POST /api/v1/lists/entries", Result: 201}, nil)
+	require.NoError(t, err)
+
+	return trail, store
+}
+
+func TestTrail_Record(t *testing.T) {
+	t.Parallel()
+
+	trail, _ := newTrail(t)
+
+	entries, err := trail.Query(context.Background(), audit.Query{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	for i, entry := range entries {
+		assert.Equal(t, int64(i+1), entry.Sequence)
+		assert.Len(t, entry.Hash, 64)
+		if i == 0 {
+			assert.Empty(t, entry.PrevHash)
+		} else {
+			assert.Equal(t, entries[i-1].Hash, entry.PrevHash)
+		}
+	}
+
+	assert.Equal(t, auditNow, entries[0].OccurredAt)
+	assert.Equal(t, "pay_1", entries[0].PaymentID)
+	assert.Empty(t, entries[0].BeforeStatus)
+	assert.Equal(t, "held_for_review", entries[0].AfterStatus)
+
+	assert.Equal(t, "held_for_review", entries[1].BeforeStatus)
+	assert.Equal(t, "captured", entries[1].AfterStatus)
+
+	assert.Empty(t, entries[2].PaymentID)
+}
+
+func TestTrail_Query(t *testing.T) {
+	t.Parallel()
+
+	trail, _ := newTrail(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		query audit.Query
+		want  []int64
+	}{
+		{name: "actor", query: audit.Query{ActorID: "ops"}, want: []int64{2, 3}},
+		{name: "merchant", query: audit.Query{MerchantID: "merchant_a"}, want: []int64{1}},
+		{name: "payment", query: audit.Query{PaymentID: "pay_1"}, want: []int64{1, 2}},
+		{name: "operation", query: audit.Query{Operation: "POST /api/v1/lists/entries"}, want: []int64{3}},
+		{name: "time range", query: audit.Query{From: auditNow.Add(time.Minute), To: auditNow.Add(2 * time.Minute)}, want: []int64{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			entries, err := trail.Query(ctx, tt.query)
+			require.NoError(t, err)
+
+			var got []int64
+			for _, entry := range entries {
+				got = append(got, entry.Sequence)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTrail_Verify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		tamper   func(s *tamperableStore)
+		verified int
+		valid    bool
+	}{
+		{
+			name:     "untouched",
+			tamper:   func(*tamperableStore) {},
+			verified: 3,
+			valid:    true,
+		},
+		{
+			name:     "altered entry",
+			tamper:   func(s *tamperableStore) { s.entries[1].AfterStatus = "voided" },
+			verified: 1,
+		},
+		{
+			name:     "removed entry",
+			tamper:   func(s *tamperableStore) { s.entries = append(s.entries[:1], s.entries[2:]...) },
+			verified: 1,
+		},
+		{
+			name:     "reordered entries",
+			tamper:   func(s *tamperableStore) { s.entries[1], s.entries[2] = s.entries[2], s.entries[1] },
+			verified: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			trail, store := newTrail(t)
+			tt.tamper(store)
+
+			verified, err := trail.Verify(context.Background())
+			assert.Equal(t, tt.verified, verified)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, audit.ErrTampered)
+			}
+		})
+	}
+}
+
+func TestTrail_Export(t *testing.T) {
+	t.Parallel()
+
+	trail, _ := newTrail(t)
+
+	var buf bytes.Buffer
+	n, err := trail.Export(context.Background(), &buf, audit.Query{ActorID: "ops"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	dec := json.NewDecoder(&buf)
+	for _, want := range []int64{2, 3} {
+		var entry audit.Entry
+		require.NoError(t, dec.Decode(&entry))
+		assert.Equal(t, want, entry.Sequence)
+	}
+	assert.False(t, dec.More())
+}
+
+func TestTrail_ChainKey(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	record := func(trail *audit.Trail) {
+		t.Helper()
+		_, err := trail.Record(ctx, audit.Entry{ActorID: "ops", Operation: "POST /api/v1/lists/entries", Result: 201}, nil)
+		require.NoError(t, err)
+		_, err = trail.Record(ctx, audit.Entry{ActorID: "ops", Operation: "DELETE /api/v1/lists/entries/{id}", Result: 204}, nil)
+		require.NoError(t, err)
+	}
+
+	store := &tamperableStore{}
+	trail := audit.NewTrail(store, audit.WithChainKey([]byte("chain key")))
+	record(trail)
+
+	verified, err := trail.Verify(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, verified)
+
+	// A trail rebuilt without the key, e.g. to hide an entry, does not
+	// verify.
+	forgedStore := &tamperableStore{}
+	record(audit.NewTrail(forgedStore))
+	verified, err = audit.NewTrail(forgedStore, audit.WithChainKey([]byte("chain key"))).Verify(ctx)
+	assert.ErrorIs(t, err, audit.ErrTampered)
+	assert.Equal(t, 0, verified)
+}
+
+func TestTrail_RecordSystemChange(t *testing.T) {
+	t.Parallel()
+
+	trail := audit.NewTrail(&tamperableStore{}, audit.WithClock(clock.NewFake(auditNow)))
+
+	entry, err := trail.RecordSystemChange(context.Background(), "expire authorization", "merchant_a", "pay_1", "authorized", "expired")
+	require.NoError(t, err)
+	assert.Equal(t, audit.SystemActor, entry.ActorID)
+	assert.Equal(t, "expire authorization", entry.Operation)
+	assert.Equal(t, "merchant_a", entry.MerchantID)
+	assert.Equal(t, "pay_1", entry.PaymentID)
+	assert.Equal(t, "authorized", entry.BeforeStatus)
+	assert.Equal(t, "expired", entry.AfterStatus)
+	assert.Zero(t, entry.Result)
+}
@@ -0,0 +1,230 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+)
+
+// ErrCircuitOpen is returned without calling the bank while the circuit
+// breaker is open. It is an ErrAuthorizationUnavailable, so callers retry
+// it like any other unavailability.
+var ErrCircuitOpen = fmt.Errorf("%w: circuit open", ErrAuthorizationUnavailable)
+
+// BreakerPolicy decides when the circuit breaker stops calling the bank.
+type BreakerPolicy struct {
+	// FailureThreshold is how many calls in a row must fail to open the
+	// circuit.
+	FailureThreshold int
+	// Cooldown is how long the circuit stays open before a trial call is
+	// let through.
+	Cooldown time.Duration
+}
+
+// DefaultBreakerPolicy is used when NewCircuitBreaker is given a zero
+// policy.
+var DefaultBreakerPolicy = BreakerPolicy{
+	FailureThreshold: 5,
+	Cooldown:         30 * time.Second,
+}
+
+// BreakerState is the state of the circuit breaker.
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// CircuitBreaker stops calling a bank that keeps failing, so payments fail
+// fast instead of each waiting for the bank timeout. Once the cooldown
+// passed, a single trial call is let through: the circuit closes if it
+// succeeds and opens again otherwise. Rejections and calls abandoned by
+// their caller are not failures of the bank, and calls started before the
+// circuit last opened are ignored.
+type CircuitBreaker struct {
+	bank   BankingSimulator
+	policy BreakerPolicy
+	clock  clock.Clock
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	open     bool
+	trial    bool // a trial call is in flight
+	// generation counts the times the circuit opened, to tell the calls
+	// started before it last did.
+	generation int
+}
+
+// breakerCall is a call let through by the circuit breaker.
+type breakerCall struct {
+	generation int
+	trial      bool
+}
+
+// BreakerOption configures optional dependencies of the CircuitBreaker.
+type BreakerOption func(*CircuitBreaker)
+
+// WithBreakerClock sets the clock timing the cooldown.
+func WithBreakerClock(clk clock.Clock) BreakerOption {
+	return func(b *CircuitBreaker) {
+		b.clock = clk
+	}
+}
+
+func NewCircuitBreaker(bank BankingSimulator, policy BreakerPolicy, opts ...BreakerOption) *CircuitBreaker {
+	if policy == (BreakerPolicy{}) {
+		policy = DefaultBreakerPolicy
+	}
+
+	b := &CircuitBreaker{
+		bank:   bank,
+		policy: policy,
+		clock:  clock.New(),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+func (b *CircuitBreaker) Authorize(ctx context.Context, req AuthorizationRequest) (*AuthorizationResponse, error) {
+	call, err := b.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.bank.Authorize(ctx, req)
+	b.record(ctx, call, err)
+
+	return resp, err
+}
+
+func (b *CircuitBreaker) Void(ctx context.Context, authorizationCode string) error {
+	call, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	err = b.bank.Void(ctx, authorizationCode)
+	b.record(ctx, call, err)
+
+	return err
+}
+
+func (b *CircuitBreaker) Capture(ctx context.Context, authorizationCode string, amount int64) error {
+	call, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	err = b.bank.Capture(ctx, authorizationCode, amount)
+	b.record(ctx, call, err)
+
+	return err
+}
+
+// State returns the current state of the circuit.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state()
+}
+
+// CheckHealth reports the bank as unusable while the circuit is open, for
+// the readiness probe.
+func (b *CircuitBreaker) CheckHealth(context.Context) error {
+	if b.State() == BreakerOpen {
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// state must be called with the lock held.
+func (b *CircuitBreaker) state() BreakerState {
+	switch {
+	case !b.open:
+		return BreakerClosed
+	case b.clock.Now().Sub(b.openedAt) < b.policy.Cooldown:
+		return BreakerOpen
+	default:
+		return BreakerHalfOpen
+	}
+}
+
+// allow returns ErrCircuitOpen when the bank must not be called, and the
+// call to record the outcome of otherwise.
+func (b *CircuitBreaker) allow() (breakerCall, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	call := breakerCall{generation: b.generation}
+
+	switch b.state() {
+	case BreakerClosed:
+		return call, nil
+	case BreakerHalfOpen:
+		if b.trial {
+			return call, ErrCircuitOpen
+		}
+		b.trial = true
+		call.trial = true
+		return call, nil
+	default:
+		return call, ErrCircuitOpen
+	}
+}
+
+// record updates the circuit with the outcome of a call.
+func (b *CircuitBreaker) record(ctx context.Context, call breakerCall, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if call.trial {
+		b.trial = false
+	}
+
+	// Calls abandoned by their caller say nothing about the bank, and calls
+	// started before the circuit opened must neither close it nor count
+	// towards opening it again: only the trial call decides.
+	if (err != nil && ctx.Err() != nil) || call.generation != b.generation {
+		return
+	}
+
+	if !bankFailure(err) {
+		b.failures = 0
+		b.open = false
+		return
+	}
+
+	b.failures++
+	if call.trial || b.failures >= b.policy.FailureThreshold {
+		b.open = true
+		b.openedAt = b.clock.Now()
+		b.generation++
+	}
+}
+
+// bankFailure reports whether the error shows the bank is not serving
+// requests. Rejections are answers of the bank.
+func bankFailure(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ErrAuthorizationRejected),
+		errors.Is(err, ErrVoidRejected),
+		errors.Is(err, ErrCaptureRejected):
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,162 @@
+package simulator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+)
+
+// flakyBank fails its calls with err, and counts them.
+type flakyBank struct {
+	err   error
+	calls int
+}
+
+func (b *flakyBank) Authorize(context.Context, simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+	b.calls++
+	if b.err != nil {
+		return nil, b.err
+	}
+	return &simulator.AuthorizationResponse{Authorized: true}, nil
+}
+
+func (b *flakyBank) Void(context.Context, string) error {
+	b.calls++
+	return b.err
+}
+
+func (b *flakyBank) Capture(context.Context, string, int64) error {
+	b.calls++
+	return b.err
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC))
+	bank := &flakyBank{err: simulator.ErrAuthorizationUnavailable}
+	breaker := simulator.NewCircuitBreaker(bank, simulator.BreakerPolicy{
+		FailureThreshold: 3,
+		Cooldown:         time.Minute,
+	}, simulator.WithBreakerClock(clk))
+
+	for range 3 {
+		_, err := breaker.Authorize(ctx, simulator.AuthorizationRequest{})
+		require.ErrorIs(t, err, simulator.ErrAuthorizationUnavailable)
+		require.NotErrorIs(t, err, simulator.ErrCircuitOpen)
+	}
+	require.Equal(t, simulator.BreakerOpen, breaker.State())
+	require.ErrorIs(t, breaker.CheckHealth(ctx), simulator.ErrCircuitOpen)
+
+	// The bank is no longer called while the circuit is open.
+	err := breaker.Void(ctx, "AUTH123")
+	require.ErrorIs(t, err, simulator.ErrCircuitOpen)
+	require.ErrorIs(t, err, simulator.ErrAuthorizationUnavailable, "callers retry an open circuit like an unavailable bank")
+	require.Equal(t, 3, bank.calls)
+
+	// A failed trial opens the circuit again.
+	clk.Advance(time.Minute)
+	require.Equal(t, simulator.BreakerHalfOpen, breaker.State())
+	require.NoError(t, breaker.CheckHealth(ctx))
+	_, err = breaker.Authorize(ctx, simulator.AuthorizationRequest{})
+	require.ErrorIs(t, err, simulator.ErrAuthorizationUnavailable)
+	require.Equal(t, 4, bank.calls)
+	require.Equal(t, simulator.BreakerOpen, breaker.State())
+
+	// A successful trial closes it.
+	clk.Advance(time.Minute)
+	bank.err = nil
+	_, err = breaker.Authorize(ctx, simulator.AuthorizationRequest{})
+	require.NoError(t, err)
+	require.Equal(t, simulator.BreakerClosed, breaker.State())
+	require.NoError(t, breaker.CheckHealth(ctx))
+}
+
+func TestCircuitBreaker_IgnoresRejectionsAndCancellations(t *testing.T) {
+	t.Parallel()
+
+	bank := &flakyBank{}
+	breaker := simulator.NewCircuitBreaker(bank, simulator.BreakerPolicy{
+		FailureThreshold: 1,
+		Cooldown:         time.Minute,
+	})
+
+	bank.err = simulator.ErrCaptureRejected
+	require.Error(t, breaker.Capture(context.Background(), "AUTH123", 100))
+	require.Equal(t, simulator.BreakerClosed, breaker.State(), "rejections are answers of the bank")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	bank.err = errors.New("request cancelled")
+	require.Error(t, breaker.Void(ctx, "AUTH123"))
+	require.Equal(t, simulator.BreakerClosed, breaker.State(), "the caller gave up, not the bank")
+}
+
+// heldBank holds its voids and captures until they are given the error to
+// return, and fails every authorization.
+type heldBank struct {
+	held     chan struct{} // receives once a call is held
+	voids    chan error
+	captures chan error
+}
+
+func (b *heldBank) Authorize(context.Context, simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+	return nil, simulator.ErrAuthorizationUnavailable
+}
+
+func (b *heldBank) Void(context.Context, string) error {
+	b.held <- struct{}{}
+	return <-b.voids
+}
+
+func (b *heldBank) Capture(context.Context, string, int64) error {
+	b.held <- struct{}{}
+	return <-b.captures
+}
+
+func TestCircuitBreaker_IgnoresCallsStartedBeforeOpening(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC))
+	bank := &heldBank{held: make(chan struct{}), voids: make(chan error), captures: make(chan error)}
+	breaker := simulator.NewCircuitBreaker(bank, simulator.BreakerPolicy{
+		FailureThreshold: 1,
+		Cooldown:         time.Minute,
+	}, simulator.WithBreakerClock(clk))
+
+	// Two calls are in flight when the circuit opens.
+	stale := make(chan error, 2)
+	for range 2 {
+		go func() { stale <- breaker.Void(ctx, "AUTH123") }()
+		<-bank.held
+	}
+	_, err := breaker.Authorize(ctx, simulator.AuthorizationRequest{})
+	require.ErrorIs(t, err, simulator.ErrAuthorizationUnavailable)
+	require.Equal(t, simulator.BreakerOpen, breaker.State())
+
+	bank.voids <- nil
+	require.NoError(t, <-stale)
+	require.Equal(t, simulator.BreakerOpen, breaker.State(), "only the trial call closes the circuit")
+
+	clk.Advance(time.Minute)
+	trial := make(chan error, 1)
+	go func() { trial <- breaker.Capture(ctx, "AUTH123", 100) }()
+	<-bank.held
+
+	bank.voids <- nil
+	require.NoError(t, <-stale)
+	_, err = breaker.Authorize(ctx, simulator.AuthorizationRequest{})
+	require.ErrorIs(t, err, simulator.ErrCircuitOpen, "a single trial call is in flight")
+
+	bank.captures <- nil
+	require.NoError(t, <-trial)
+	require.Equal(t, simulator.BreakerClosed, breaker.State())
+}
@@ -7,48 +7,116 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type BankingSimulator interface {
 	Authorize(ctx context.Context, req AuthorizationRequest) (*AuthorizationResponse, error)
+	Void(ctx context.Context, authorizationCode string) error
+	Capture(ctx context.Context, authorizationCode string, amount int64) error
 }
 
 type AuthorizationRequest struct {
-	CardNumber string `json:"card_number"`
-	ExpiryDate string `json:"expiry_date"` // "MM/YYYY" format
-	Currency   string `json:"currency"`
-	Amount     int64  `json:"amount"` // amount in minor units (e.g. cents)
-	CVV        string `json:"cvv"`
+	CardNumber     string   `json:"card_number"`
+	ExpiryDate     string   `json:"expiry_date"` // "MM/YYYY" format
+	Currency       string   `json:"currency"`
+	Amount         int64    `json:"amount"` // amount in minor units (e.g. cents)
+	CVV            string   `json:"cvv"`
+	CardholderName string   `json:"cardholder_name,omitempty"`
+	BillingAddress *Address `json:"billing_address,omitempty"`
+	ThreeDS        *ThreeDS `json:"three_ds,omitempty"`
+	// Exemption from strong customer authentication requested for the payment.
+	Exemption         string `json:"exemption,omitempty"`
+	MerchantInitiated bool   `json:"merchant_initiated,omitempty"`
+}
+
+// ThreeDS carries the outcome of the cardholder authentication.
+type ThreeDS struct {
+	TransactionID       string `json:"transaction_id"`
+	Status              string `json:"status"`
+	ECI                 string `json:"eci"`
+	AuthenticationValue string `json:"authentication_value,omitempty"`
+}
+
+type Address struct {
+	Line1      string `json:"line1,omitempty"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city,omitempty"`
+	State      string `json:"state,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country,omitempty"` // ISO 3166-1 alpha-2
 }
 
 type AuthorizationResponse struct {
 	Authorized        bool   `json:"authorized"`
 	AuthorizationCode string `json:"authorization_code"`
+	AVSResult         string `json:"avs_result,omitempty"` // Address Verification result code
+	CVVResult         string `json:"cvv_result,omitempty"` // Card verification value check result code
+	ResponseCode      string `json:"response_code,omitempty"`
 }
 
+// ResponseCodeAuthenticationRequired is returned by the issuer when it
+// refuses an exemption and requires the cardholder to be authenticated.
+const ResponseCodeAuthenticationRequired = "1A"
+
+// SoftDeclined reports whether the payment was declined only because strong
+// customer authentication is required, so it can be retried with a challenge.
+func (r *AuthorizationResponse) SoftDeclined() bool {
+	return !r.Authorized && r.ResponseCode == ResponseCodeAuthenticationRequired
+}
+
+// DefaultTimeout bounds each call to the bank when NewClient is given no
+// HTTP client.
+const DefaultTimeout = 10 * time.Second
+
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	signer     Signer
 }
 
 type errorResponse struct {
 	ErrorMessage string `json:"error_message"`
 }
 
-func NewClient(baseURL string, httpClient *http.Client) *Client {
+// NewClient returns a client of the bank at baseURL. The HTTP client sets
+// the timeout and, for HTTPS, the TLS settings of the connection, such as
+// client certificates and trusted authorities.
+func NewClient(baseURL string, httpClient *http.Client, opts ...ClientOption) *Client {
 	if httpClient == nil {
 		httpClient = &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout: DefaultTimeout,
 		}
 	}
 
-	return &Client{
+	c := &Client{
 		baseURL:    baseURL,
 		httpClient: httpClient,
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 func (c *Client) Authorize(ctx context.Context, req AuthorizationRequest) (*AuthorizationResponse, error) {
+	ctx, span := startSpan(ctx, "simulator.Client.Authorize")
+	resp, err := c.authorize(ctx, req)
+	if resp != nil {
+		span.SetAttributes(
+			attribute.Bool("bank.authorized", resp.Authorized),
+			attribute.String("bank.response_code", resp.ResponseCode),
+		)
+	}
+	endSpan(span, err)
+
+	return resp, err
+}
+
+func (c *Client) authorize(ctx context.Context, req AuthorizationRequest) (*AuthorizationResponse, error) {
 	resp := &AuthorizationResponse{}
 	url := fmt.Sprintf("%s/payments", c.baseURL)
 
@@ -77,7 +145,7 @@ func (c *Client) Authorize(ctx context.Context, req AuthorizationRequest) (*Auth
 
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	httpResp, err := c.httpClient.Do(httpReq)
+	httpResp, err := c.do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf(
 			"%w: perform authorization request: %v",
@@ -127,3 +195,140 @@ func (c *Client) Authorize(ctx context.Context, req AuthorizationRequest) (*Auth
 		)
 	}
 }
+
+// Health checks the bank is reachable and serving requests.
+func (c *Client) Health(ctx context.Context) error {
+	url := fmt.Sprintf("%s/health", c.baseURL)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("create http request: %w", err)
+	}
+
+	// Not traced, probes would start a trace every few seconds, but signed
+	// like every call.
+	if err := c.sign(httpReq); err != nil {
+		return fmt.Errorf("sign request: %w", err)
+	}
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("perform health request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		return fmt.Errorf("%w: status code %d", ErrUnhealthy, httpResp.StatusCode)
+	}
+
+	return nil
+}
+
+// Void releases the funds held by a previous authorization.
+func (c *Client) Void(ctx context.Context, authorizationCode string) error {
+	ctx, span := startSpan(ctx, "simulator.Client.Void")
+	err := c.void(ctx, authorizationCode)
+	endSpan(span, err)
+
+	return err
+}
+
+func (c *Client) void(ctx context.Context, authorizationCode string) error {
+	url := fmt.Sprintf("%s/payments/%s/voids", c.baseURL, authorizationCode)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return fmt.Errorf(
+			"%w: create http request: %v",
+			ErrVoidInternal,
+			err,
+		)
+	}
+
+	httpResp, err := c.do(httpReq)
+	if err != nil {
+		return fmt.Errorf(
+			"%w: perform void request: %v",
+			ErrVoidInternal,
+			err,
+		)
+	}
+	defer httpResp.Body.Close()
+
+	switch {
+	case httpResp.StatusCode >= 200 && httpResp.StatusCode <= 299:
+		return nil
+
+	case httpResp.StatusCode == http.StatusServiceUnavailable:
+		return ErrAuthorizationUnavailable
+
+	default:
+		return fmt.Errorf(
+			"%w: status code %d",
+			ErrVoidRejected,
+			httpResp.StatusCode,
+		)
+	}
+}
+
+type captureRequest struct {
+	Amount int64 `json:"amount"`
+}
+
+// Capture settles the given amount of a previous authorization.
+func (c *Client) Capture(ctx context.Context, authorizationCode string, amount int64) error {
+	ctx, span := startSpan(ctx, "simulator.Client.Capture")
+	err := c.capture(ctx, authorizationCode, amount)
+	endSpan(span, err)
+
+	return err
+}
+
+func (c *Client) capture(ctx context.Context, authorizationCode string, amount int64) error {
+	url := fmt.Sprintf("%s/payments/%s/captures", c.baseURL, authorizationCode)
+
+	body, err := json.Marshal(captureRequest{Amount: amount})
+	if err != nil {
+		return fmt.Errorf(
+			"%w: marshal capture request: %v",
+			ErrCaptureInternal,
+			err,
+		)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf(
+			"%w: create http request: %v",
+			ErrCaptureInternal,
+			err,
+		)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := c.do(httpReq)
+	if err != nil {
+		return fmt.Errorf(
+			"%w: perform capture request: %v",
+			ErrCaptureInternal,
+			err,
+		)
+	}
+	defer httpResp.Body.Close()
+
+	switch {
+	case httpResp.StatusCode >= 200 && httpResp.StatusCode <= 299:
+		return nil
+
+	case httpResp.StatusCode == http.StatusServiceUnavailable:
+		return ErrAuthorizationUnavailable
+
+	default:
+		return fmt.Errorf(
+			"%w: status code %d",
+			ErrCaptureRejected,
+			httpResp.StatusCode,
+		)
+	}
+}
@@ -9,6 +9,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
 )
@@ -98,3 +101,160 @@ func TestClient_Authorize_UnexpectedStatus(t *testing.T) {
 
 	assert.ErrorIs(t, err, simulator.ErrAuthorizationUnexpected)
 }
+
+func TestClient_Authorize_BillingDetails(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req simulator.AuthorizationRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "Jane Doe", req.CardholderName)
+		require.NotNil(t, req.BillingAddress)
+		require.Equal(t, "GB", req.BillingAddress.Country)
+
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"authorized":         true,
+			"authorization_code": "auth_123",
+			"avs_result":         "Y",
+			"cvv_result":         "M",
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	client := simulator.NewClient(server.URL, server.Client())
+
+	resp, err := client.Authorize(context.Background(), simulator.AuthorizationRequest{
+		CardholderName: "Jane Doe",
+		BillingAddress: &simulator.Address{Line1: "1 Main Street", Country: "GB"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Y", resp.AVSResult)
+	assert.Equal(t, "M", resp.CVVResult)
+}
+
+func TestClient_Void(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/payments/auth_123/voids", r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	client := simulator.NewClient(server.URL, server.Client())
+
+	require.NoError(t, client.Void(context.Background(), "auth_123"))
+}
+
+func TestClient_Void_Rejected(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	t.Cleanup(server.Close)
+
+	client := simulator.NewClient(server.URL, server.Client())
+
+	err := client.Void(context.Background(), "auth_123")
+	assert.ErrorIs(t, err, simulator.ErrVoidRejected)
+}
+
+func TestClient_Capture(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/payments/auth_123/captures", r.URL.Path)
+
+		var body map[string]int64
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, int64(1050), body["amount"])
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	client := simulator.NewClient(server.URL, server.Client())
+
+	require.NoError(t, client.Capture(context.Background(), "auth_123", 1050))
+}
+
+func TestClient_Capture_Rejected(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	t.Cleanup(server.Close)
+
+	client := simulator.NewClient(server.URL, server.Client())
+
+	err := client.Capture(context.Background(), "auth_123", 1050)
+	assert.ErrorIs(t, err, simulator.ErrCaptureRejected)
+}
+
+func TestClient_Authorize_PropagatesTraceContext(t *testing.T) {
+	t.Parallel()
+
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	traceID := trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.Header.Get("traceparent"), traceID.String())
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(simulator.AuthorizationResponse{Authorized: true})
+	}))
+	t.Cleanup(server.Close)
+
+	client := simulator.NewClient(server.URL, server.Client())
+
+	_, err := client.Authorize(ctx, simulator.AuthorizationRequest{})
+	require.NoError(t, err)
+}
+
+func TestClient_Health(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr error
+	}{
+		{name: "healthy", status: http.StatusOK},
+		{name: "unhealthy", status: http.StatusServiceUnavailable, wantErr: simulator.ErrUnhealthy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodGet, r.Method)
+				require.Equal(t, "/health", r.URL.Path)
+
+				w.WriteHeader(tt.status)
+			}))
+			t.Cleanup(server.Close)
+
+			client := simulator.NewClient(server.URL, server.Client())
+
+			err := client.Health(context.Background())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -12,4 +12,15 @@ var (
 	ErrAuthorizationRejected    = errors.New("authorization rejected")
 	ErrAuthorizationUnavailable = errors.New("authorization service unavailable")
 	ErrAuthorizationUnexpected  = errors.New("unexpected authorization error")
+
+	// Void outcomes
+	ErrVoidInternal = errors.New("void internal error")
+	ErrVoidRejected = errors.New("void rejected")
+
+	// Capture outcomes
+	ErrCaptureInternal = errors.New("capture internal error")
+	ErrCaptureRejected = errors.New("capture rejected")
+
+	// Health outcomes
+	ErrUnhealthy = errors.New("bank unhealthy")
 )
@@ -0,0 +1,106 @@
+package simulator
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+)
+
+const (
+	// SignatureHeader carries the timestamp and HMAC-SHA256 signature of a
+	// request, e.g. "t=1768471200,v1=5257a869...".
+	SignatureHeader = "X-Signature"
+	// SignatureKeyIDHeader names the key the request was signed with, so
+	// the bank can rotate keys.
+	SignatureKeyIDHeader = "X-Signature-Key-ID"
+)
+
+// Signer signs the requests sent to the bank, e.g. by adding a signature
+// header. It is given the body, which it must not change.
+type Signer interface {
+	Sign(req *http.Request, body []byte) error
+}
+
+// ClientOption configures optional settings of the Client.
+type ClientOption func(*Client)
+
+// WithSigner signs every request sent to the bank.
+func WithSigner(signer Signer) ClientOption {
+	return func(c *Client) {
+		c.signer = signer
+	}
+}
+
+// HMACSigner signs requests with a shared key. The signed content is
+// "<unix timestamp>.<method> <path>.<body>", so a captured request cannot be
+// replayed with another timestamp or against another operation.
+type HMACSigner struct {
+	keyID string
+	key   []byte
+	clock clock.Clock
+}
+
+// HMACSignerOption configures optional dependencies of the HMACSigner.
+type HMACSignerOption func(*HMACSigner)
+
+// WithSignerClock sets the clock timestamping signatures.
+func WithSignerClock(clk clock.Clock) HMACSignerOption {
+	return func(s *HMACSigner) {
+		s.clock = clk
+	}
+}
+
+func NewHMACSigner(keyID string, key []byte, opts ...HMACSignerOption) *HMACSigner {
+	s := &HMACSigner{
+		keyID: keyID,
+		key:   key,
+		clock: clock.New(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *HMACSigner) Sign(req *http.Request, body []byte) error {
+	ts := strconv.FormatInt(s.clock.Now().Unix(), 10)
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(ts + "." + req.Method + " " + req.URL.RequestURI() + "."))
+	mac.Write(body)
+
+	req.Header.Set(SignatureHeader, "t="+ts+",v1="+hex.EncodeToString(mac.Sum(nil)))
+	if s.keyID != "" {
+		req.Header.Set(SignatureKeyIDHeader, s.keyID)
+	}
+	return nil
+}
+
+// sign hands the request and a copy of its body to the signer, if any.
+func (c *Client) sign(req *http.Request) error {
+	if c.signer == nil {
+		return nil
+	}
+
+	var body []byte
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		if body, err = io.ReadAll(rc); err != nil {
+			return err
+		}
+	}
+
+	return c.signer.Sign(req, body)
+}
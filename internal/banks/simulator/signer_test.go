@@ -0,0 +1,104 @@
+package simulator_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+)
+
+// verifyHMAC checks the signature the way the bank would.
+func verifyHMAC(t *testing.T, r *http.Request, key []byte) {
+	t.Helper()
+
+	body, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+
+	ts, sig, ok := strings.Cut(strings.TrimPrefix(r.Header.Get(simulator.SignatureHeader), "t="), ",v1=")
+	require.True(t, ok, "malformed signature header")
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(ts + "." + r.Method + " " + r.URL.RequestURI() + "."))
+	mac.Write(body)
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), sig)
+}
+
+func TestClient_HMACSigner(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("bank-shared-secret")
+
+	now := time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key_2025", r.Header.Get(simulator.SignatureKeyIDHeader))
+		assert.True(t, strings.HasPrefix(r.Header.Get(simulator.SignatureHeader), "t=1768471200,"), "signatures are timestamped with the clock")
+		verifyHMAC(t, r, key)
+
+		if r.URL.Path == "/payments" {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(simulator.AuthorizationResponse{Authorized: true})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := simulator.NewClient(server.URL, server.Client(),
+		simulator.WithSigner(simulator.NewHMACSigner("key_2025", key, simulator.WithSignerClock(clock.NewFake(now)))),
+	)
+
+	_, err := client.Authorize(context.Background(), simulator.AuthorizationRequest{
+		CardNumber: "4111111111111111",
+		Currency:   "USD",
+		Amount:     1000,
+	})
+	require.NoError(t, err)
+
+	// Requests without a body are signed too.
+	require.NoError(t, client.Health(context.Background()))
+}
+
+// signerFunc signs requests with a function.
+type signerFunc func(req *http.Request, body []byte) error
+
+func (f signerFunc) Sign(req *http.Request, body []byte) error {
+	return f(req, body)
+}
+
+func TestClient_WithSigner(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "signed", r.Header.Get("X-Custom-Signature"))
+
+		// The signer reads a copy, the body is still sent whole.
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"amount":1050}`, string(body))
+	}))
+	t.Cleanup(server.Close)
+
+	var signed []byte
+	client := simulator.NewClient(server.URL, server.Client(),
+		simulator.WithSigner(signerFunc(func(req *http.Request, body []byte) error {
+			signed = body
+			req.Header.Set("X-Custom-Signature", "signed")
+			return nil
+		})),
+	)
+
+	require.NoError(t, client.Capture(context.Background(), "auth_123", 1050))
+	assert.JSONEq(t, `{"amount":1050}`, string(signed))
+}
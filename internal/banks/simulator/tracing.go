@@ -0,0 +1,50 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator")
+
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// do signs the request and sends it with the W3C trace context of the
+// current span, so the bank can continue the trace.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if err := c.sign(req); err != nil {
+		return nil, fmt.Errorf("sign request: %w", err)
+	}
+
+	otel.GetTextMapPropagator().Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+
+	span := trace.SpanFromContext(req.Context())
+	span.SetAttributes(
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.URLFull(req.URL.String()),
+	)
+
+	resp, err := c.httpClient.Do(req)
+	if err == nil {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	}
+
+	return resp, err
+}
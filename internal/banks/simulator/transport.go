@@ -0,0 +1,69 @@
+package simulator
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/certs"
+)
+
+// HTTPClientConfig sets up the connection to the bank.
+type HTTPClientConfig struct {
+	// Timeout bounds each call, DefaultTimeout when zero.
+	Timeout time.Duration
+	// ClientCertFile and ClientKeyFile are the PEM encoded certificate and
+	// key presented to the bank for mutual TLS.
+	ClientCertFile string
+	ClientKeyFile  string
+	// CAFile holds the authorities trusted to sign the bank certificate,
+	// instead of the system ones.
+	CAFile string
+	// PinnedKeys are the public key pins the bank certificate chain must
+	// hold one of, see certs.PublicKeyPin.
+	PinnedKeys []string
+}
+
+// NewHTTPClient returns the HTTP client to pass to NewClient. The reloader
+// of the client certificate is returned for it to be watched, nil without
+// one.
+func NewHTTPClient(conf HTTPClientConfig) (*http.Client, *certs.Reloader, error) {
+	timeout := conf.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	var reloader *certs.Reloader
+	if conf.ClientCertFile != "" {
+		var err error
+		reloader, err = certs.NewReloader(conf.ClientCertFile, conf.ClientKeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.GetClientCertificate = reloader.GetClientCertificate
+	}
+
+	if conf.CAFile != "" {
+		rootCAs, err := certs.LoadCertPool(conf.CAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load CA: %w", err)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if len(conf.PinnedKeys) > 0 {
+		verify, err := certs.VerifyPinnedKeys(conf.PinnedKeys)
+		if err != nil {
+			return nil, nil, err
+		}
+		tlsConfig.VerifyConnection = verify
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Timeout: timeout, Transport: transport}, reloader, nil
+}
@@ -0,0 +1,146 @@
+package simulator_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/certs"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/certs/certstest"
+)
+
+func healthy(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// writeCA writes the certificate of the server for clients to trust it.
+func writeCA(t *testing.T, cert *x509.Certificate) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	require.NoError(t, os.WriteFile(file, data, 0o600))
+
+	return file
+}
+
+func checkHealth(t *testing.T, url string, conf simulator.HTTPClientConfig) error {
+	t.Helper()
+
+	httpClient, _, err := simulator.NewHTTPClient(conf)
+	require.NoError(t, err)
+
+	return simulator.NewClient(url, httpClient).Health(context.Background())
+}
+
+func TestNewHTTPClient_CA(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(healthy))
+	t.Cleanup(server.Close)
+
+	t.Run("trusted", func(t *testing.T) {
+		t.Parallel()
+
+		err := checkHealth(t, server.URL, simulator.HTTPClientConfig{CAFile: writeCA(t, server.Certificate())})
+		assert.NoError(t, err)
+	})
+
+	t.Run("system authorities", func(t *testing.T) {
+		t.Parallel()
+
+		err := checkHealth(t, server.URL, simulator.HTTPClientConfig{})
+		var unknownAuthority x509.UnknownAuthorityError
+		assert.ErrorAs(t, err, &unknownAuthority)
+	})
+}
+
+func TestNewHTTPClient_PinnedKeys(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(healthy))
+	t.Cleanup(server.Close)
+	caFile := writeCA(t, server.Certificate())
+
+	dir := t.TempDir()
+	other := certstest.WriteKeyPair(t, filepath.Join(dir, "other.pem"), filepath.Join(dir, "other.key"), "other")
+
+	tests := []struct {
+		name    string
+		pins    []string
+		wantErr error
+	}{
+		{
+			name: "pinned",
+			pins: []string{certs.PublicKeyPin(other), certs.PublicKeyPin(server.Certificate())},
+		},
+		{
+			name:    "not pinned",
+			pins:    []string{certs.PublicKeyPin(other)},
+			wantErr: certs.ErrPinMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkHealth(t, server.URL, simulator.HTTPClientConfig{CAFile: caFile, PinnedKeys: tt.pins})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("invalid pin", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := simulator.NewHTTPClient(simulator.HTTPClientConfig{PinnedKeys: []string{"not-a-pin"}})
+		assert.ErrorIs(t, err, certs.ErrInvalidPin)
+	})
+}
+
+func TestNewHTTPClient_ClientCertificate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	certstest.WriteKeyPair(t, certFile, keyFile, "gateway")
+	clientCAs, err := certs.LoadCertPool(certFile)
+	require.NoError(t, err)
+
+	var subject string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject = r.TLS.VerifiedChains[0][0].Subject.CommonName
+	}))
+	server.TLS = &tls.Config{
+		ClientCAs:  clientCAs,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	caFile := writeCA(t, server.Certificate())
+
+	err = checkHealth(t, server.URL, simulator.HTTPClientConfig{
+		CAFile:         caFile,
+		ClientCertFile: certFile,
+		ClientKeyFile:  keyFile,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "gateway", subject)
+
+	err = checkHealth(t, server.URL, simulator.HTTPClientConfig{CAFile: caFile})
+	assert.Error(t, err, "the bank refuses clients without a certificate")
+}
@@ -0,0 +1,147 @@
+// Package certs loads the certificates securing the connections of the
+// gateway, and keeps them current as they are renewed on disk.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoCertificates is returned when a PEM file holds no certificate.
+var ErrNoCertificates = errors.New("no certificates found")
+
+// Reloader serves a certificate and key pair read from files, replacing it
+// when the files change, so renewed certificates are used without a restart.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+
+	mu      sync.Mutex
+	version fileVersion // Of the files the pair was loaded from.
+}
+
+// NewReloader loads the certificate and key pair.
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the certificate and key pair again. The previous pair is
+// kept when they cannot be loaded.
+func (r *Reloader) Reload() error {
+	_, err := r.reload(true)
+	return err
+}
+
+// reload loads the pair, unless forced only when its files changed since
+// last loaded. It reports whether the pair was loaded.
+func (r *Reloader) reload(force bool) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Stat first: files changing while being read are reloaded on the next
+	// check.
+	version, err := r.stat()
+	if err != nil {
+		return false, err
+	}
+	if !force && version == r.version {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("load key pair: %w", err)
+	}
+
+	r.cert.Store(&cert)
+	r.version = version
+	return true, nil
+}
+
+// GetCertificate serves the pair to clients, see tls.Config.GetCertificate.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// GetClientCertificate presents the pair to servers, see
+// tls.Config.GetClientCertificate.
+func (r *Reloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// Watch reloads the pair every time one of its files changes, checking
+// every interval until the context is cancelled. Pairs that fail to load,
+// e.g. when only the certificate was replaced so far, are logged and tried
+// again on the next check.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.reload(false)
+			if err != nil {
+				slog.Error("reloading certificate, keeping the previous one", "cert_file", r.certFile, "error", err)
+				continue
+			}
+			if reloaded {
+				slog.Info("reloaded certificate", "cert_file", r.certFile, "not_after", r.cert.Load().Leaf.NotAfter)
+			}
+		}
+	}
+}
+
+// fileVersion tells two versions of the pair apart.
+type fileVersion struct {
+	certModTime, keyModTime time.Time
+	certSize, keySize       int64
+}
+
+func (r *Reloader) stat() (fileVersion, error) {
+	cert, err := os.Stat(r.certFile)
+	if err != nil {
+		return fileVersion{}, err
+	}
+	key, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fileVersion{}, err
+	}
+
+	return fileVersion{
+		certModTime: cert.ModTime(),
+		keyModTime:  key.ModTime(),
+		certSize:    cert.Size(),
+		keySize:     key.Size(),
+	}, nil
+}
+
+// LoadCertPool reads the PEM encoded certificates of a file into a pool,
+// e.g. the authorities trusted to sign client certificates.
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%w in %s", ErrNoCertificates, file)
+	}
+	return pool, nil
+}
@@ -0,0 +1,166 @@
+package certs_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/certs"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/certs/certstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func keyPairFiles(t *testing.T, name string) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	return filepath.Join(dir, name+".pem"), filepath.Join(dir, name+".key")
+}
+
+func commonName(t *testing.T, reloader *certs.Reloader) string {
+	t.Helper()
+
+	cert, err := reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	return cert.Leaf.Subject.CommonName
+}
+
+func TestReloader_Reload(t *testing.T) {
+	t.Parallel()
+
+	certFile, keyFile := keyPairFiles(t, "server")
+	certstest.WriteKeyPair(t, certFile, keyFile, "v1")
+
+	reloader, err := certs.NewReloader(certFile, keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", commonName(t, reloader))
+
+	certstest.WriteKeyPair(t, certFile, keyFile, "v2")
+	require.NoError(t, reloader.Reload())
+	assert.Equal(t, "v2", commonName(t, reloader))
+
+	// A certificate replaced without its key does not load.
+	otherCert, otherKey := keyPairFiles(t, "other")
+	certstest.WriteKeyPair(t, otherCert, otherKey, "v3")
+	require.NoError(t, os.Rename(otherCert, certFile))
+
+	require.Error(t, reloader.Reload())
+	assert.Equal(t, "v2", commonName(t, reloader), "the previous pair is kept")
+}
+
+func TestNewReloader_MissingFile(t *testing.T) {
+	t.Parallel()
+
+	certFile, keyFile := keyPairFiles(t, "server")
+
+	_, err := certs.NewReloader(certFile, keyFile)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestReloader_Watch(t *testing.T) {
+	t.Parallel()
+
+	certFile, keyFile := keyPairFiles(t, "server")
+	certstest.WriteKeyPair(t, certFile, keyFile, "v1")
+
+	reloader, err := certs.NewReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go reloader.Watch(ctx, 10*time.Millisecond)
+
+	certstest.WriteKeyPair(t, certFile, keyFile, "v2")
+	// File times can be too coarse to tell two quick writes apart.
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+
+	require.Eventually(t, func() bool {
+		return commonName(t, reloader) == "v2"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLoadCertPool(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no certificates", func(t *testing.T) {
+		t.Parallel()
+
+		file := filepath.Join(t.TempDir(), "ca.pem")
+		require.NoError(t, os.WriteFile(file, []byte("not a certificate"), 0o600))
+
+		_, err := certs.LoadCertPool(file)
+		require.ErrorIs(t, err, certs.ErrNoCertificates)
+	})
+
+	t.Run("mutual TLS", func(t *testing.T) {
+		t.Parallel()
+
+		serverCert, serverKey := keyPairFiles(t, "server")
+		certstest.WriteKeyPair(t, serverCert, serverKey, "gateway")
+		clientCert, clientKey := keyPairFiles(t, "client")
+		certstest.WriteKeyPair(t, clientCert, clientKey, "shop.example.com")
+
+		serverReloader, err := certs.NewReloader(serverCert, serverKey)
+		require.NoError(t, err)
+		clientCAs, err := certs.LoadCertPool(clientCert)
+		require.NoError(t, err)
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.TLS.VerifiedChains[0][0].Subject.CommonName))
+		}))
+		server.TLS = &tls.Config{
+			GetCertificate: serverReloader.GetCertificate,
+			ClientCAs:      clientCAs,
+			ClientAuth:     tls.RequireAndVerifyClientCert,
+		}
+		server.StartTLS()
+		t.Cleanup(server.Close)
+
+		clientReloader, err := certs.NewReloader(clientCert, clientKey)
+		require.NoError(t, err)
+		rootCAs, err := certs.LoadCertPool(serverCert)
+		require.NoError(t, err)
+
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			// Sent as SNI, for the server to use GetCertificate rather than
+			// the certificate of httptest.
+			ServerName:           "localhost",
+			RootCAs:              rootCAs,
+			GetClientCertificate: clientReloader.GetClientCertificate,
+		}}}
+
+		res, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		body := make([]byte, 64)
+		n, _ := res.Body.Read(body)
+		assert.Equal(t, "shop.example.com", string(body[:n]))
+	})
+}
+
+func TestVerifyPinnedKeys(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	leaf := certstest.WriteKeyPair(t, filepath.Join(dir, "leaf.pem"), filepath.Join(dir, "leaf.key"), "bank")
+	other := certstest.WriteKeyPair(t, filepath.Join(dir, "other.pem"), filepath.Join(dir, "other.key"), "other")
+
+	verify, err := certs.VerifyPinnedKeys([]string{certs.PublicKeyPin(leaf)})
+	require.NoError(t, err)
+
+	assert.NoError(t, verify(tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf}}}))
+	assert.ErrorIs(t, verify(tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{other}}}), certs.ErrPinMismatch)
+	assert.ErrorIs(t, verify(tls.ConnectionState{}), certs.ErrPinMismatch, "unverified peers are refused")
+
+	_, err = certs.VerifyPinnedKeys([]string{"dGVzdA=="})
+	assert.ErrorIs(t, err, certs.ErrInvalidPin, "not a SHA-256 digest")
+}
@@ -0,0 +1,55 @@
+package certs
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	// ErrInvalidPin is returned for pins that are not a base64 encoded
+	// SHA-256 digest.
+	ErrInvalidPin = errors.New("invalid public key pin")
+	// ErrPinMismatch is returned when no certificate of the peer matches a
+	// pin.
+	ErrPinMismatch = errors.New("no certificate matches the pinned public keys")
+)
+
+// PublicKeyPin returns the pin of the certificate public key: the base64
+// encoded SHA-256 of its SubjectPublicKeyInfo, as printed by
+//
+//	openssl x509 -in cert.pem -pubkey -noout | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64
+//
+// Pinning the key rather than the certificate survives renewals that keep
+// the key.
+func PublicKeyPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// VerifyPinnedKeys returns a tls.Config.VerifyConnection accepting peers
+// whose verified chain holds one of the pinned public keys, the leaf or an
+// authority. It runs after the usual verification, which it does not
+// replace.
+func VerifyPinnedKeys(pins []string) (func(tls.ConnectionState) error, error) {
+	for _, pin := range pins {
+		if digest, err := base64.StdEncoding.DecodeString(pin); err != nil || len(digest) != sha256.Size {
+			return nil, fmt.Errorf("%w %q", ErrInvalidPin, pin)
+		}
+	}
+
+	return func(cs tls.ConnectionState) error {
+		for _, chain := range cs.VerifiedChains {
+			for _, cert := range chain {
+				if slices.Contains(pins, PublicKeyPin(cert)) {
+					return nil
+				}
+			}
+		}
+		return ErrPinMismatch
+	}, nil
+}
@@ -0,0 +1,57 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the current time. Components that depend on time should
+// receive a Clock instead of calling time.Now directly so they can be tested
+// deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+// Real is a Clock backed by the system time.
+type Real struct{}
+
+func New() Clock {
+	return Real{}
+}
+
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Fake is a Clock that only moves when told to. It is safe for concurrent use.
+type Fake struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.now
+}
+
+// Set moves the clock to the given time.
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = now
+}
+
+// Advance moves the clock forward by the given duration.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+}
@@ -0,0 +1,24 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, time.December, 31, 23, 59, 0, 0, time.UTC)
+	c := clock.NewFake(start)
+
+	assert.Equal(t, start, c.Now())
+
+	c.Advance(time.Minute)
+	assert.Equal(t, time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), c.Now())
+
+	c.Set(start)
+	assert.Equal(t, start, c.Now())
+}
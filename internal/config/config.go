@@ -1,8 +1,28 @@
 package config
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Config is read from environment variables and an optional config file.
+// Each section holds the variables starting with its name, so that
+// bank_simulator.timeout in a file sets BANK_SIMULATOR_TIMEOUT. Settings
+// tagged secret accept file:// and env:// references and are masked when
+// printed.
 type Config struct {
-	App           AppConfig
-	BankSimulator BankSimulatorConfig
+	App           AppConfig           `config:"app"`
+	Payments      PaymentsConfig      `config:"payments"`
+	ThreeDS       ThreeDSConfig       `config:"threeds"`
+	Webhooks      WebhooksConfig      `config:"webhooks"`
+	Outbox        OutboxConfig        `config:"outbox"`
+	Fraud         FraudConfig         `config:"fraud"`
+	Tracing       TracingConfig       `config:"tracing"`
+	TLS           TLSConfig           `config:"tls"`
+	Auth          AuthConfig          `config:"auth"`
+	Audit         AuditConfig         `config:"audit"`
+	BankSimulator BankSimulatorConfig `config:"bank_simulator"`
 }
 
 type AppConfig struct {
@@ -11,9 +31,300 @@ type AppConfig struct {
 	Version     string `envconfig:"APP_VERSION"     default:"v1"`
 	LogLevel    string `envconfig:"APP_LOG_LEVEL"   default:"info"`
 	APIPort     string `envconfig:"APP_API_PORT"    default:"8090"`
-	// todo: APITimeout  string ...
+	// LogFormat is "json" or "text".
+	LogFormat string `envconfig:"APP_LOG_FORMAT" default:"json"`
+	// LogOutput is "stdout", "stderr" or the path of a file logs are
+	// appended to.
+	LogOutput string `envconfig:"APP_LOG_OUTPUT" default:"stdout"`
+	// MetricsPort serves /metrics apart from the API, so it can stay private.
+	MetricsPort string `envconfig:"APP_METRICS_PORT" default:"9090"`
+	// APIReadHeaderTimeout bounds reading the request headers.
+	APIReadHeaderTimeout time.Duration `envconfig:"APP_API_READ_HEADER_TIMEOUT" default:"5s"`
+	// APIReadTimeout bounds reading the whole request, body included.
+	APIReadTimeout time.Duration `envconfig:"APP_API_READ_TIMEOUT" default:"15s"`
+	// APIWriteTimeout bounds the time from the end of the request headers to
+	// the end of the response. It must exceed APIHandlerTimeout.
+	APIWriteTimeout time.Duration `envconfig:"APP_API_WRITE_TIMEOUT" default:"35s"`
+	// APIIdleTimeout bounds how long keep-alive connections wait for the
+	// next request.
+	APIIdleTimeout time.Duration `envconfig:"APP_API_IDLE_TIMEOUT" default:"120s"`
+	// APIHandlerTimeout bounds the handling of a request, after which the
+	// client gets a 503. Event streams are not bound by it.
+	APIHandlerTimeout time.Duration `envconfig:"APP_API_HANDLER_TIMEOUT" default:"30s"`
+	// APIMaxHeaderBytes limits the size of the request headers.
+	APIMaxHeaderBytes int `envconfig:"APP_API_MAX_HEADER_BYTES" default:"1048576"`
+	// APIMaxBodyBytes limits the size of payment requests.
+	APIMaxBodyBytes int64 `envconfig:"APP_API_MAX_BODY_BYTES" default:"65536"`
+	// ShutdownReadinessDelay is how long the server keeps serving once
+	// /readyz fails on shutdown.
+	ShutdownReadinessDelay time.Duration `envconfig:"APP_SHUTDOWN_READINESS_DELAY" default:"5s"`
+	// ShutdownDrainTimeout bounds how long requests and bank authorizations
+	// in flight get to complete on shutdown.
+	ShutdownDrainTimeout time.Duration `envconfig:"APP_SHUTDOWN_DRAIN_TIMEOUT" default:"20s"`
+}
+
+type PaymentsConfig struct {
+	// Currencies enabled for merchants without an explicit configuration.
+	Currencies []string `envconfig:"PAYMENTS_CURRENCIES" default:"USD,EUR,BRL"`
+	// MerchantCurrencies overrides the enabled currencies per merchant,
+	// e.g. "merchant_a:USD;JPY,merchant_b:KWD".
+	MerchantCurrencies map[string]string `envconfig:"PAYMENTS_MERCHANT_CURRENCIES"`
+	// ExpiryTimezone is the IANA timezone of the card issuer used to decide
+	// when the expiry month ends.
+	ExpiryTimezone string `envconfig:"PAYMENTS_EXPIRY_TIMEZONE" default:"UTC"`
+	// ExpiryGrace extends the card validity past the end of the expiry month.
+	ExpiryGrace time.Duration `envconfig:"PAYMENTS_EXPIRY_GRACE" default:"0s"`
+	// AuthorizationTTL is how long an uncaptured authorization remains valid.
+	AuthorizationTTL time.Duration `envconfig:"PAYMENTS_AUTHORIZATION_TTL" default:"168h"`
+	// ExpirySweepInterval is how often expired authorizations are looked up.
+	ExpirySweepInterval time.Duration `envconfig:"PAYMENTS_EXPIRY_SWEEP_INTERVAL" default:"1m"`
+	// AVSVoidOnMismatch voids authorized payments whose billing address does
+	// not match the issuer records.
+	AVSVoidOnMismatch bool `envconfig:"PAYMENTS_AVS_VOID_ON_MISMATCH" default:"false"`
+	// MerchantAVSVoidOnMismatch overrides AVSVoidOnMismatch per merchant,
+	// e.g. "merchant_a:true,merchant_b:false".
+	MerchantAVSVoidOnMismatch map[string]bool `envconfig:"PAYMENTS_MERCHANT_AVS_VOID_ON_MISMATCH"`
+	// AsyncEnabled lets clients sending "Prefer: respond-async" have their
+	// payments authorized in the background.
+	AsyncEnabled bool `envconfig:"PAYMENTS_ASYNC_ENABLED" default:"false"`
+	// AsyncWorkers is how many payments are authorized concurrently.
+	AsyncWorkers int `envconfig:"PAYMENTS_ASYNC_WORKERS" default:"8"`
+	// AsyncQueueSize bounds how many payments can wait for authorization
+	// before new ones are refused.
+	AsyncQueueSize int `envconfig:"PAYMENTS_ASYNC_QUEUE_SIZE" default:"1000"`
+	// AsyncRetryDelay is how long to wait before retrying when the bank is
+	// unavailable.
+	AsyncRetryDelay time.Duration `envconfig:"PAYMENTS_ASYNC_RETRY_DELAY" default:"5s"`
+	// CardEncryptionKey is the hex encoded AES key protecting the card data
+	// of payments waiting for authorization. A random key is used when
+	// empty, so pending payments cannot be resumed after a restart.
+	CardEncryptionKey string `envconfig:"PAYMENTS_CARD_ENCRYPTION_KEY" secret:"true"`
+	// CardFingerprintKey is the hex encoded HMAC key of the card
+	// fingerprints. A random key is used when empty, so fingerprints, and
+	// the fraud blocklists using them, change after a restart.
+	CardFingerprintKey string `envconfig:"PAYMENTS_CARD_FINGERPRINT_KEY" secret:"true"`
+	// ReviewSLA is how long payments held for review wait for a decision
+	// before they are voided.
+	ReviewSLA time.Duration `envconfig:"PAYMENTS_REVIEW_SLA" default:"24h"`
+	// ReviewSweepInterval is how often reviews past their SLA are looked up.
+	ReviewSweepInterval time.Duration `envconfig:"PAYMENTS_REVIEW_SWEEP_INTERVAL" default:"1m"`
+}
+
+// MerchantCurrencyCodes returns the per merchant currencies split into codes.
+func (c PaymentsConfig) MerchantCurrencyCodes() map[string][]string {
+	codes := make(map[string][]string, len(c.MerchantCurrencies))
+	for merchantID, currencies := range c.MerchantCurrencies {
+		codes[merchantID] = strings.Split(currencies, ";")
+	}
+	return codes
+}
+
+type ThreeDSConfig struct {
+	// Enabled turns on 3-D Secure using the in-process ACS simulator, which
+	// serves challenge pages anyone can complete. It is refused in
+	// production.
+	Enabled bool `envconfig:"THREEDS_ENABLED" default:"false"`
+	// AuthenticationTTL is how long the cardholder has to complete a
+	// challenge before the payment expires and its card data is dropped.
+	AuthenticationTTL time.Duration `envconfig:"THREEDS_AUTHENTICATION_TTL" default:"15m"`
+	// ACSURL is the public base URL of the simulated ACS challenge pages.
+	ACSURL string `envconfig:"THREEDS_ACS_URL" default:"http://localhost:8090/acs"`
+	// CallbackURL is where cardholders are sent back after the challenge.
+	CallbackURL string `envconfig:"THREEDS_CALLBACK_URL" default:"http://localhost:8090/api/v1/payments/3ds/callback"`
+	// SCARequired forces a challenge for every payment of merchants without
+	// an explicit setting.
+	SCARequired bool `envconfig:"THREEDS_SCA_REQUIRED" default:"false"`
+	// MerchantSCARequired overrides SCARequired per merchant,
+	// e.g. "merchant_eu:true,merchant_us:false".
+	MerchantSCARequired map[string]bool `envconfig:"THREEDS_MERCHANT_SCA_REQUIRED"`
+	// LowValueLimits are the per currency amounts, in minor units, up to
+	// which a low-value exemption is requested, e.g. "EUR:3000,GBP:2500".
+	LowValueLimits map[string]int64 `envconfig:"THREEDS_LOW_VALUE_LIMITS" default:"EUR:3000"`
+	// TRALimits are the per currency amounts, in minor units, up to which a
+	// transaction risk analysis exemption is requested.
+	TRALimits map[string]int64 `envconfig:"THREEDS_TRA_LIMITS" default:"EUR:10000"`
+	// TRAMerchants lists the merchants eligible for TRA exemptions.
+	TRAMerchants []string `envconfig:"THREEDS_TRA_MERCHANTS"`
+}
+
+type WebhooksConfig struct {
+	// DispatchInterval is how often due deliveries are looked up.
+	DispatchInterval time.Duration `envconfig:"WEBHOOKS_DISPATCH_INTERVAL" default:"1s"`
+	// Workers is how many deliveries are sent concurrently.
+	Workers int `envconfig:"WEBHOOKS_WORKERS" default:"4"`
+	// Timeout bounds each call to a merchant endpoint.
+	Timeout time.Duration `envconfig:"WEBHOOKS_TIMEOUT" default:"10s"`
+	// MaxAttempts is how many times a delivery is tried before failing.
+	MaxAttempts int `envconfig:"WEBHOOKS_MAX_ATTEMPTS" default:"10"`
+	// RetryInitialInterval is the wait before the first retry, doubled
+	// after every failed attempt.
+	RetryInitialInterval time.Duration `envconfig:"WEBHOOKS_RETRY_INITIAL_INTERVAL" default:"30s"`
+	// RetryMaxInterval caps the wait between two attempts.
+	RetryMaxInterval time.Duration `envconfig:"WEBHOOKS_RETRY_MAX_INTERVAL" default:"6h"`
+	// AllowPrivateNetworks lets merchants register endpoints on the
+	// loopback and private networks, for local development. It is refused
+	// in production.
+	AllowPrivateNetworks bool `envconfig:"WEBHOOKS_ALLOW_PRIVATE_NETWORKS" default:"false"`
+}
+
+type OutboxConfig struct {
+	// RelayInterval is how often unpublished events are looked up.
+	RelayInterval time.Duration `envconfig:"OUTBOX_RELAY_INTERVAL" default:"500ms"`
+	// BatchSize is how many events are read from the outbox at once.
+	BatchSize int `envconfig:"OUTBOX_BATCH_SIZE" default:"100"`
+	// Publishers lists the sinks events are published to, besides webhooks:
+	// "file" and "http".
+	Publishers []string `envconfig:"OUTBOX_PUBLISHERS"`
+	// FilePath is the NDJSON file events are appended to by the file publisher.
+	FilePath string `envconfig:"OUTBOX_FILE_PATH" default:"events.ndjson"`
+	// HTTPURL is where the http publisher POSTs events.
+	HTTPURL string `envconfig:"OUTBOX_HTTP_URL"`
+	// HTTPTimeout bounds each POST of the http publisher.
+	HTTPTimeout time.Duration `envconfig:"OUTBOX_HTTP_TIMEOUT" default:"10s"`
+	// Retention is how long events are kept once published by all the
+	// publishers.
+	Retention time.Duration `envconfig:"OUTBOX_RETENTION" default:"1h"`
+}
+
+type FraudConfig struct {
+	// RulesFile is the YAML or JSON file holding the fraud rules, see
+	// fraud_rules.example.yaml. Payments are not screened when empty.
+	RulesFile string `envconfig:"FRAUD_RULES_FILE"`
+	// ReloadInterval is how often the rules file is checked for changes.
+	ReloadInterval time.Duration `envconfig:"FRAUD_RELOAD_INTERVAL" default:"5s"`
+	// VelocityRetention is how long card usage is remembered by the velocity
+	// counters. It must cover the longest velocity window of the rules.
+	VelocityRetention time.Duration `envconfig:"FRAUD_VELOCITY_RETENTION" default:"24h"`
+}
+
+type TracingConfig struct {
+	// Exporter is where spans are sent: "otlp", "stdout" or "none".
+	Exporter string `envconfig:"TRACING_EXPORTER" default:"none"`
+	// OTLPEndpoint is the URL of the OpenTelemetry collector, e.g.
+	// "http://localhost:4318". The standard OTEL_EXPORTER_OTLP_* variables
+	// apply when empty.
+	OTLPEndpoint string `envconfig:"TRACING_OTLP_ENDPOINT"`
+	// SampleRatio is the share of the traces started by the gateway that
+	// are recorded. Traces started by callers follow their decision.
+	SampleRatio float64 `envconfig:"TRACING_SAMPLE_RATIO" default:"1"`
+}
+
+type TLSConfig struct {
+	// CertFile and KeyFile are the PEM encoded certificate and key of the
+	// API. The API is served over TLS only when they are set.
+	CertFile string `envconfig:"TLS_CERT_FILE"`
+	KeyFile  string `envconfig:"TLS_KEY_FILE"`
+	// ReloadInterval is how often the certificate files are checked for
+	// changes.
+	ReloadInterval time.Duration `envconfig:"TLS_RELOAD_INTERVAL" default:"1m"`
+	// ClientCAFile holds the authorities signing client certificates.
+	// Clients are asked for a certificate only when it is set.
+	ClientCAFile string `envconfig:"TLS_CLIENT_CA_FILE"`
+	// ClientAuth is "require" to refuse clients without a certificate, or
+	// "optional" to identify them from X-Merchant-ID as without TLS.
+	ClientAuth string `envconfig:"TLS_CLIENT_AUTH" default:"require"`
+	// ClientMerchants maps the subject common names of client certificates
+	// to merchant IDs, e.g. "shop.example.com:merchant_a".
+	ClientMerchants map[string]string `envconfig:"TLS_CLIENT_MERCHANTS"`
+	// HSTSMaxAge is how long browsers only reach the API over HTTPS once
+	// told so. Zero disables the header.
+	HSTSMaxAge time.Duration `envconfig:"TLS_HSTS_MAX_AGE" default:"8760h"`
+}
+
+// Enabled reports whether the API is served over TLS.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// MinAPIKeyLength is the length API keys must reach, so they cannot be
+// guessed.
+const MinAPIKeyLength = 16
+
+type AuthConfig struct {
+	// MerchantAPIKeys lists the API keys merchants authenticate with, sent
+	// as bearer tokens, e.g. "merchant_a:key1,merchant_a:key2". Without
+	// them, nor client certificates, merchants are identified by the
+	// X-Merchant-ID header, which is refused in production.
+	MerchantAPIKeys string `envconfig:"AUTH_MERCHANT_API_KEYS" secret:"true"`
+	// OperatorAPIKeys lists the API keys of the operators allowed on the
+	// administrative routes, e.g. "alice@risk:key1,bob@ops:key2". The
+	// operator is recorded as the author of their changes. Without keys
+	// these routes refuse every request.
+	OperatorAPIKeys string `envconfig:"AUTH_OPERATOR_API_KEYS" secret:"true"`
+}
+
+// MerchantKeys returns the merchant API keys, mapped to their merchant.
+func (c AuthConfig) MerchantKeys() (map[string]string, error) {
+	return parseAPIKeys("AUTH_MERCHANT_API_KEYS", c.MerchantAPIKeys)
+}
+
+// OperatorKeys returns the operator API keys, mapped to their operator.
+func (c AuthConfig) OperatorKeys() (map[string]string, error) {
+	return parseAPIKeys("AUTH_OPERATOR_API_KEYS", c.OperatorAPIKeys)
+}
+
+// parseAPIKeys reads "id:key" pairs separated by commas into a map of the
+// keys to their ID.
+func parseAPIKeys(name, value string) (map[string]string, error) {
+	keys := map[string]string{}
+	if strings.TrimSpace(value) == "" {
+		return keys, nil
+	}
+
+	for i, pair := range strings.Split(value, ",") {
+		id, key, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		switch {
+		case !ok || id == "" || key == "":
+			return nil, fmt.Errorf("%w: %s entry %d must use the id:key format", ErrInvalidConfig, name, i+1)
+		case len(key) < MinAPIKeyLength:
+			return nil, fmt.Errorf("%w: %s key of %s must be at least %d characters", ErrInvalidConfig, name, id, MinAPIKeyLength)
+		}
+		if _, ok := keys[key]; ok {
+			return nil, fmt.Errorf("%w: %s key of %s is used twice", ErrInvalidConfig, name, id)
+		}
+		keys[key] = id
+	}
+
+	return keys, nil
+}
+
+type AuditConfig struct {
+	// ChainKey is the hex encoded HMAC key of the audit trail hashes, so the
+	// trail cannot be rewritten by whoever can write its store. Required in
+	// production; the hashes are plain SHA-256 without it.
+	ChainKey string `envconfig:"AUDIT_CHAIN_KEY" secret:"true"`
 }
 
 type BankSimulatorConfig struct {
 	URL string `envconfig:"BANK_SIMULATOR_URL" default:"http://localhost:8080"`
+	// Timeout bounds each call to the bank.
+	Timeout time.Duration `envconfig:"BANK_SIMULATOR_TIMEOUT" default:"10s"`
+	// ClientCertFile and ClientKeyFile are the PEM encoded certificate and
+	// key presented to the bank for mutual TLS. They are reloaded when they
+	// change, checked every TLS_RELOAD_INTERVAL.
+	ClientCertFile string `envconfig:"BANK_SIMULATOR_CLIENT_CERT_FILE"`
+	ClientKeyFile  string `envconfig:"BANK_SIMULATOR_CLIENT_KEY_FILE"`
+	// CAFile holds the authorities trusted to sign the bank certificate,
+	// instead of the system ones.
+	CAFile string `envconfig:"BANK_SIMULATOR_CA_FILE"`
+	// PinnedKeys are the base64 SHA-256 pins of the public keys the bank
+	// certificate chain must hold one of, see certs.PublicKeyPin.
+	PinnedKeys []string `envconfig:"BANK_SIMULATOR_PINNED_KEYS"`
+	// SigningKey is the hex encoded HMAC key requests are signed with.
+	// Requests are not signed when empty.
+	SigningKey string `envconfig:"BANK_SIMULATOR_SIGNING_KEY" secret:"true"`
+	// SigningKeyID names the signing key to the bank.
+	SigningKeyID string `envconfig:"BANK_SIMULATOR_SIGNING_KEY_ID"`
+	// BreakerFailures is how many calls in a row must fail for the circuit
+	// breaker to stop calling the bank.
+	BreakerFailures int `envconfig:"BANK_SIMULATOR_BREAKER_FAILURES" default:"5"`
+	// BreakerCooldown is how long the bank is not called once the circuit
+	// opened.
+	BreakerCooldown time.Duration `envconfig:"BANK_SIMULATOR_BREAKER_COOLDOWN" default:"30s"`
+}
+
+// TLSConfigured reports whether any TLS setting of the bank connection is
+// set.
+func (c BankSimulatorConfig) TLSConfigured() bool {
+	return c.ClientCertFile != "" || c.CAFile != "" || len(c.PinnedKeys) > 0
 }
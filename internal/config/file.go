@@ -0,0 +1,212 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// setting is a leaf of Config, set by the environment variable env or by
+// key in the section of a config file.
+type setting struct {
+	section string
+	key     string
+	env     string
+	secret  bool
+	kind    reflect.Kind
+	index   []int // of the field in Config
+}
+
+// name is the setting as written in error messages about config files.
+func (s setting) name() string {
+	return s.section + "." + s.key
+}
+
+// schema lists the settings of Config in declaration order.
+var schema = sync.OnceValue(func() []setting {
+	var settings []setting
+
+	configType := reflect.TypeFor[Config]()
+	for i := range configType.NumField() {
+		section := configType.Field(i)
+		name := section.Tag.Get("config")
+		prefix := strings.ToUpper(name) + "_"
+
+		for j := range section.Type.NumField() {
+			field := section.Type.Field(j)
+			env := field.Tag.Get("envconfig")
+			if !strings.HasPrefix(env, prefix) {
+				panic(fmt.Sprintf("config: %s.%s: %s does not start with %s", section.Name, field.Name, env, prefix))
+			}
+
+			settings = append(settings, setting{
+				section: name,
+				key:     strings.ToLower(strings.TrimPrefix(env, prefix)),
+				env:     env,
+				secret:  field.Tag.Get("secret") == "true",
+				kind:    field.Type.Kind(),
+				index:   []int{i, j},
+			})
+		}
+	}
+
+	return settings
+})
+
+// ReadFile reads a YAML, JSON or TOML config file, chosen from its
+// extension, into the environment variables its settings stand for, in
+// the format envconfig parses. Lists are joined with commas, and maps
+// written as "key:value" pairs.
+func ReadFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	var doc map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml", ".json":
+		// JSON is read as YAML, of which it is a subset.
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	default:
+		return nil, fmt.Errorf("%w: config file %s: unsupported extension %q, use .yaml, .yml, .json or .toml",
+			ErrInvalidConfig, path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: config file %s: %v", ErrInvalidConfig, path, err)
+	}
+
+	env, errs := flatten(doc)
+	if len(errs) > 0 {
+		for i, err := range errs {
+			errs[i] = fmt.Errorf("%w: config file %s: %v", ErrInvalidConfig, path, err)
+		}
+		return nil, errors.Join(errs...)
+	}
+
+	return env, nil
+}
+
+// flatten maps the settings of doc to their environment variables,
+// reporting every unknown or malformed setting.
+func flatten(doc map[string]any) (map[string]string, []error) {
+	settings := map[string]setting{}
+	sections := map[string]bool{}
+	for _, s := range schema() {
+		settings[s.name()] = s
+		sections[s.section] = true
+	}
+
+	env := map[string]string{}
+	var errs []error
+
+	for _, section := range sortedKeys(doc) {
+		if !sections[section] {
+			errs = append(errs, fmt.Errorf("unknown section %q", section))
+			continue
+		}
+		values, ok := doc[section].(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: expected a section of settings, got %s", section, describe(doc[section])))
+			continue
+		}
+
+		for _, key := range sortedKeys(values) {
+			s, ok := settings[section+"."+key]
+			if !ok {
+				errs = append(errs, fmt.Errorf("unknown setting %s.%s", section, key))
+				continue
+			}
+
+			value, err := s.format(values[key])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %v", s.name(), err))
+				continue
+			}
+			env[s.env] = value
+		}
+	}
+
+	return env, errs
+}
+
+// format converts a value of a config file to the text envconfig parses.
+// Lists and maps may also be written in that text directly.
+func (s setting) format(value any) (string, error) {
+	switch s.kind {
+	case reflect.Slice:
+		if list, ok := value.([]any); ok {
+			items := make([]string, len(list))
+			for i, item := range list {
+				str, err := scalar(item)
+				if err == nil && strings.Contains(str, ",") {
+					err = fmt.Errorf("%q contains a comma", str)
+				}
+				if err != nil {
+					return "", fmt.Errorf("item %d: %v", i, err)
+				}
+				items[i] = str
+			}
+			return strings.Join(items, ","), nil
+		}
+
+	case reflect.Map:
+		if entries, ok := value.(map[string]any); ok {
+			pairs := make([]string, 0, len(entries))
+			for _, key := range sortedKeys(entries) {
+				str, err := scalar(entries[key])
+				if err == nil && strings.ContainsAny(key+str, ",:") {
+					err = fmt.Errorf("%q: %q contains a comma or a colon", key, str)
+				}
+				if err != nil {
+					return "", fmt.Errorf("entry %s: %v", key, err)
+				}
+				pairs = append(pairs, key+":"+str)
+			}
+			return strings.Join(pairs, ","), nil
+		}
+	}
+
+	return scalar(value)
+}
+
+func scalar(value any) (string, error) {
+	switch value.(type) {
+	case string, bool, int, int64, uint64, float64:
+		return fmt.Sprint(value), nil
+	default:
+		return "", fmt.Errorf("expected a single value, got %s", describe(value))
+	}
+}
+
+func describe(value any) string {
+	switch value.(type) {
+	case nil:
+		return "nothing"
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "a section"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -1,26 +1,90 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"io/fs"
+	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 )
 
-func LoadConfig() (*Config, error) {
+// LoadConfig reads the config from environment variables, then from the
+// config file at path, when given, and last from .env.local, each for the
+// variables still unset. Secret references are resolved, the result is not
+// validated so an invalid config can still be printed: callers run Validate.
+func LoadConfig(path string) (*Config, error) {
 	var config Config
 
+	// fromFile holds the variables set by the config file, to report
+	// invalid values under the name they have there.
+	fromFile := map[string]bool{}
+	if path != "" {
+		values, err := ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := setUnset(values, fromFile); err != nil {
+			return nil, err
+		}
+	}
+
 	// Load from env vars only if .env.local is found
-	err := godotenv.Load(".env.local")
+	values, err := godotenv.Read(".env.local")
 	if err != nil {
 		if _, ok := err.(*fs.PathError); !ok {
 			return nil, err
 		}
 	}
+	if err := setUnset(values, nil); err != nil {
+		return nil, err
+	}
 
 	if err := envconfig.Process("app", &config); err != nil {
+		var parseErr *envconfig.ParseError
+		if !errors.As(err, &parseErr) {
+			return nil, err
+		}
+
+		// The key is the prefixed one envconfig tried first, e.g.
+		// APP_BANKSIMULATOR_BANK_SIMULATOR_TIMEOUT.
+		source := parseErr.KeyName
+		for _, s := range schema() {
+			if strings.HasSuffix(parseErr.KeyName, "_"+s.env) {
+				source = s.env
+				if fromFile[s.env] {
+					source = s.name() + " in " + path
+				}
+				break
+			}
+		}
+		return nil, fmt.Errorf("%w: %s: invalid %s %q: %v",
+			ErrInvalidConfig, source, parseErr.TypeName, parseErr.Value, parseErr.Err)
+	}
+
+	if err := config.resolveSecrets(); err != nil {
 		return nil, err
 	}
 
 	return &config, nil
 }
+
+// setUnset sets the environment variables of values that are not set yet,
+// and records them in set when given.
+func setUnset(values map[string]string, set map[string]bool) error {
+	for env, value := range values {
+		if _, ok := os.LookupEnv(env); ok {
+			continue
+		}
+		if err := os.Setenv(env, value); err != nil {
+			return fmt.Errorf("set %s: %w", env, err)
+		}
+		if set != nil {
+			set[env] = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// unsetEnv unsets the variables for the test, so the config file sets
+// them, and restores them afterwards.
+func unsetEnv(t *testing.T, keys ...string) {
+	t.Helper()
+
+	for _, key := range keys {
+		t.Setenv(key, "")
+		require.NoError(t, os.Unsetenv(key))
+	}
+}
+
+// loadFile loads and validates the config with a config file holding
+// content. The variables the file sets are restored after the test.
+func loadFile(t *testing.T, name, content string) (*config.Config, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	if values, err := config.ReadFile(path); err == nil {
+		for key := range values {
+			if _, ok := os.LookupEnv(key); !ok {
+				unsetEnv(t, key)
+			}
+		}
+	}
+
+	c, err := config.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return c, c.Validate()
+}
+
+func TestLoadConfig_File(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+app:
+  api_port: "9000"
+  api_read_timeout: 20s
+payments:
+  currencies: [USD, GBP]
+  async_workers: 4
+threeds:
+  low_value_limits:
+    EUR: 3000
+    GBP: 2500
+bank_simulator:
+  timeout: 5s
+`,
+		"config.toml": `
+[app]
+api_port = "9000"
+api_read_timeout = "20s"
+
+[payments]
+currencies = ["USD", "GBP"]
+async_workers = 4
+
+[threeds.low_value_limits]
+EUR = 3000
+GBP = 2500
+
+[bank_simulator]
+timeout = "5s"
+`,
+		"config.json": `{
+  "app": {"api_port": "9000", "api_read_timeout": "20s"},
+  "payments": {"currencies": ["USD", "GBP"], "async_workers": 4},
+  "threeds": {"low_value_limits": {"EUR": 3000, "GBP": 2500}},
+  "bank_simulator": {"timeout": "5s"}
+}`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			// Environment variables take precedence over the file.
+			t.Setenv("BANK_SIMULATOR_TIMEOUT", "7s")
+
+			c, err := loadFile(t, name, content)
+			require.NoError(t, err)
+
+			assert.Equal(t, "9000", c.App.APIPort)
+			assert.Equal(t, 20*time.Second, c.App.APIReadTimeout)
+			assert.Equal(t, []string{"USD", "GBP"}, c.Payments.Currencies)
+			assert.Equal(t, 4, c.Payments.AsyncWorkers)
+			assert.Equal(t, map[string]int64{"EUR": 3000, "GBP": 2500}, c.ThreeDS.LowValueLimits)
+			assert.Equal(t, 7*time.Second, c.BankSimulator.Timeout)
+			// Defaults apply to what neither sets.
+			assert.Equal(t, 35*time.Second, c.App.APIWriteTimeout)
+		})
+	}
+}
+
+func TestLoadConfig_InvalidFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr []string
+	}{
+		{
+			name:    "unknown settings",
+			file:    "config.yaml",
+			content: "bank:\n  url: http://bank\napp:\n  api_prot: \"9000\"\n",
+			wantErr: []string{`unknown section "bank"`, "unknown setting app.api_prot"},
+		},
+		{
+			name:    "list instead of a value",
+			file:    "config.yaml",
+			content: "app:\n  api_port: [9000]\n",
+			wantErr: []string{"app.api_port: expected a single value, got a list"},
+		},
+		{
+			name:    "value instead of a section",
+			file:    "config.yaml",
+			content: "app: 9000\n",
+			wantErr: []string{"app: expected a section of settings, got int"},
+		},
+		{
+			name:    "invalid value",
+			file:    "config.toml",
+			content: "[app]\napi_read_timeout = \"fast\"\n",
+			wantErr: []string{`app.api_read_timeout in `, `invalid time.Duration "fast"`},
+		},
+		{
+			name:    "invalid setting",
+			file:    "config.yaml",
+			content: "app:\n  shutdown_drain_timeout: 1s\n",
+			wantErr: []string{"APP_SHUTDOWN_DRAIN_TIMEOUT 1s must be longer than BANK_SIMULATOR_TIMEOUT 10s"},
+		},
+		{
+			name:    "malformed",
+			file:    "config.yaml",
+			content: "app: [\n",
+			wantErr: []string{"config.yaml: yaml:"},
+		},
+		{
+			name:    "unsupported extension",
+			file:    "config.ini",
+			content: "[app]\n",
+			wantErr: []string{`unsupported extension ".ini"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadFile(t, tt.file, tt.content)
+			require.ErrorIs(t, err, config.ErrInvalidConfig)
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_DotEnvBelowFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile(".env.local",
+		[]byte("BANK_SIMULATOR_URL=http://localhost:8080\nAPP_LOG_LEVEL=debug\n"), 0o600))
+	unsetEnv(t, "BANK_SIMULATOR_URL", "APP_LOG_LEVEL")
+
+	// The file wins over .env.local, which still fills what the file leaves
+	// unset.
+	c, err := loadFile(t, "config.yaml", "bank_simulator:\n  url: http://bank.internal:8080\n")
+	require.NoError(t, err)
+	assert.Equal(t, "http://bank.internal:8080", c.BankSimulator.URL)
+	assert.Equal(t, "debug", c.App.LogLevel)
+}
+
+func TestLoadConfig_NotValidated(t *testing.T) {
+	t.Setenv("APP_SHUTDOWN_DRAIN_TIMEOUT", "1s")
+
+	// An invalid config still loads, to be printed while debugging it.
+	c, err := config.LoadConfig("")
+	require.NoError(t, err)
+	require.ErrorIs(t, c.Validate(), config.ErrInvalidConfig)
+}
+
+func TestLoadConfig_SecretReferences(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "signing_key")
+	require.NoError(t, os.WriteFile(secretFile, []byte("0a0b0c\n"), 0o600))
+
+	t.Setenv("BANK_SIMULATOR_SIGNING_KEY", "file://"+secretFile)
+	t.Setenv("FINGERPRINT_KEY", "0d0e0f")
+
+	c, err := loadFile(t, "config.yaml", "payments:\n  card_fingerprint_key: env://FINGERPRINT_KEY\n")
+	require.NoError(t, err)
+	assert.Equal(t, "0a0b0c", c.BankSimulator.SigningKey)
+	assert.Equal(t, "0d0e0f", c.Payments.CardFingerprintKey)
+}
+
+func TestLoadConfig_InvalidSecretReferences(t *testing.T) {
+	unsetEnv(t, "MISSING_KEY")
+	t.Setenv("BANK_SIMULATOR_SIGNING_KEY", "file:///nonexistent/signing_key")
+	t.Setenv("PAYMENTS_CARD_ENCRYPTION_KEY", "env://MISSING_KEY")
+
+	_, err := config.LoadConfig("")
+	require.ErrorIs(t, err, config.ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "BANK_SIMULATOR_SIGNING_KEY: open /nonexistent/signing_key")
+	assert.Contains(t, err.Error(), "PAYMENTS_CARD_ENCRYPTION_KEY: environment variable MISSING_KEY is not set")
+}
+
+func TestConfig_Print(t *testing.T) {
+	t.Setenv("BANK_SIMULATOR_SIGNING_KEY", "0a0b0c")
+	t.Setenv("PAYMENTS_MERCHANT_CURRENCIES", "merchant_a:USD;JPY")
+
+	c, err := config.LoadConfig("")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, c.Print(&out))
+
+	var printed map[string]map[string]any
+	require.NoError(t, yaml.Unmarshal(out.Bytes(), &printed))
+	assert.Equal(t, "********", printed["bank_simulator"]["signing_key"])
+	assert.Empty(t, printed["payments"]["card_encryption_key"])
+	assert.Equal(t, "30s", printed["app"]["api_handler_timeout"])
+	assert.NotContains(t, out.String(), "0a0b0c")
+
+	// The output is a config file giving back the same config.
+	t.Setenv("BANK_SIMULATOR_SIGNING_KEY", "")
+	c.BankSimulator.SigningKey = ""
+	out.Reset()
+	require.NoError(t, c.Print(&out))
+
+	reloaded, err := loadFile(t, "printed.yaml", out.String())
+	require.NoError(t, err)
+
+	var reprinted bytes.Buffer
+	require.NoError(t, reloaded.Print(&reprinted))
+	assert.Equal(t, out.String(), reprinted.String())
+}
+
+func TestLoadConfig_Example(t *testing.T) {
+	t.Setenv("CARD_ENCRYPTION_KEY", "00112233445566778899aabbccddeeff")
+	t.Setenv("PAYMENTS_CARD_FINGERPRINT_KEY", "0011")
+	t.Setenv("AUTH_MERCHANT_API_KEYS", "merchant_a:0123456789abcdef")
+	t.Setenv("AUTH_OPERATOR_API_KEYS", "alice@risk:fedcba9876543210")
+	t.Setenv("AUDIT_CHAIN_KEY", "00112233445566778899aabbccddeeff")
+
+	values, err := config.ReadFile("../../config.example.yaml")
+	require.NoError(t, err)
+	for key := range values {
+		if _, ok := os.LookupEnv(key); !ok {
+			unsetEnv(t, key)
+		}
+	}
+
+	c, err := config.LoadConfig("../../config.example.yaml")
+	require.NoError(t, err)
+	require.NoError(t, c.Validate())
+	assert.Equal(t, "00112233445566778899aabbccddeeff", c.Payments.CardEncryptionKey)
+	assert.Equal(t, map[string]string{"merchant_a": "USD;JPY"}, c.Payments.MerchantCurrencies)
+}
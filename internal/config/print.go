@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// masked replaces the secrets set when printing the config.
+const masked = "********"
+
+// Print writes the config as a YAML config file, with secrets masked.
+func (c *Config) Print(w io.Writer) error {
+	v := reflect.ValueOf(c).Elem()
+
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	var section *yaml.Node
+	current := ""
+	for _, s := range schema() {
+		if s.section != current {
+			current = s.section
+			section = &yaml.Node{Kind: yaml.MappingNode}
+			doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: s.section}, section)
+		}
+
+		value := v.FieldByIndex(s.index).Interface()
+		switch {
+		case s.secret && value != "":
+			value = masked
+		case s.kind == reflect.Int64:
+			if d, ok := value.(time.Duration); ok {
+				value = d.String()
+			}
+		}
+
+		node := &yaml.Node{}
+		if err := node.Encode(value); err != nil {
+			return fmt.Errorf("encode %s: %w", s.name(), err)
+		}
+		section.Content = append(section.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: s.key}, node)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("print config: %w", err)
+	}
+	return enc.Close()
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Secret references, accepted by the settings tagged secret instead of the
+// secret itself.
+const (
+	// fileRef reads the secret from a file, e.g. a mounted Kubernetes or
+	// Docker secret. Surrounding whitespace is trimmed.
+	fileRef = "file://"
+	// envRef reads the secret from another environment variable.
+	envRef = "env://"
+)
+
+// resolveSecrets replaces the secret references by the secrets they point
+// to.
+func (c *Config) resolveSecrets() error {
+	v := reflect.ValueOf(c).Elem()
+
+	var errs []error
+	for _, s := range schema() {
+		if !s.secret {
+			continue
+		}
+
+		field := v.FieldByIndex(s.index)
+		secret, err := resolveSecret(field.String())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, s.env, err))
+			continue
+		}
+		field.SetString(secret)
+	}
+
+	return errors.Join(errs...)
+}
+
+func resolveSecret(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, fileRef):
+		data, err := os.ReadFile(strings.TrimPrefix(ref, fileRef))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+
+	case strings.HasPrefix(ref, envRef):
+		name := strings.TrimPrefix(ref, envRef)
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+
+	default:
+		return ref, nil
+	}
+}
@@ -0,0 +1,312 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidConfig is returned when a setting is out of its valid range.
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Validate checks the settings that would otherwise only fail, or silently
+// misbehave, once the gateway serves traffic.
+func (c Config) Validate() error {
+	errs := []error{
+		c.App.validate(),
+		c.Payments.validate(),
+		c.ThreeDS.validate(),
+		c.Webhooks.validate(),
+		c.Outbox.validate(),
+		c.Fraud.validate(),
+		c.Tracing.validate(),
+		c.TLS.validate(),
+		c.Auth.validate(),
+		c.Audit.validate(),
+		c.BankSimulator.validate(),
+	}
+
+	if c.App.Environment == "production" && c.ThreeDS.Enabled {
+		errs = append(errs, fmt.Errorf("%w: THREEDS_ENABLED serves a simulated ACS and is refused in production", ErrInvalidConfig))
+	}
+
+	if c.App.Environment == "production" && c.Webhooks.AllowPrivateNetworks {
+		errs = append(errs, fmt.Errorf("%w: WEBHOOKS_ALLOW_PRIVATE_NETWORKS is refused in production", ErrInvalidConfig))
+	}
+
+	if c.App.Environment == "production" && c.Audit.ChainKey == "" {
+		errs = append(errs, fmt.Errorf("%w: production requires AUDIT_CHAIN_KEY to key the audit trail", ErrInvalidConfig))
+	}
+
+	// Anyone can claim to be any merchant with the header, and client
+	// certificates only identify merchants their subjects are assigned to.
+	if c.App.Environment == "production" && c.Auth.MerchantAPIKeys == "" && (c.TLS.ClientCAFile == "" || len(c.TLS.ClientMerchants) == 0) {
+		errs = append(errs, fmt.Errorf("%w: production requires AUTH_MERCHANT_API_KEYS or TLS_CLIENT_CA_FILE with TLS_CLIENT_MERCHANTS to authenticate merchants",
+			ErrInvalidConfig))
+	}
+
+	// Authorizations in flight would otherwise be interrupted on every
+	// shutdown while the bank is slow.
+	if c.App.ShutdownDrainTimeout <= c.BankSimulator.Timeout {
+		errs = append(errs, fmt.Errorf("%w: APP_SHUTDOWN_DRAIN_TIMEOUT %s must be longer than BANK_SIMULATOR_TIMEOUT %s",
+			ErrInvalidConfig, c.App.ShutdownDrainTimeout, c.BankSimulator.Timeout))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c AppConfig) validate() error {
+	errs := []error{
+		positive("APP_API_READ_HEADER_TIMEOUT", c.APIReadHeaderTimeout),
+		positive("APP_API_READ_TIMEOUT", c.APIReadTimeout),
+		positive("APP_API_WRITE_TIMEOUT", c.APIWriteTimeout),
+		positive("APP_API_IDLE_TIMEOUT", c.APIIdleTimeout),
+		positive("APP_API_HANDLER_TIMEOUT", c.APIHandlerTimeout),
+		positive("APP_API_MAX_HEADER_BYTES", c.APIMaxHeaderBytes),
+		positive("APP_API_MAX_BODY_BYTES", c.APIMaxBodyBytes),
+		positive("APP_SHUTDOWN_DRAIN_TIMEOUT", c.ShutdownDrainTimeout),
+		oneOf("APP_LOG_FORMAT", c.LogFormat, "json", "text"),
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		errs = append(errs, fmt.Errorf("%w: APP_LOG_LEVEL must be debug, info, warn or error, got %q", ErrInvalidConfig, c.LogLevel))
+	}
+
+	if c.ShutdownReadinessDelay < 0 {
+		errs = append(errs, fmt.Errorf("%w: APP_SHUTDOWN_READINESS_DELAY must not be negative, got %s",
+			ErrInvalidConfig, c.ShutdownReadinessDelay))
+	}
+
+	if c.APIReadHeaderTimeout > c.APIReadTimeout {
+		errs = append(errs, fmt.Errorf("%w: APP_API_READ_HEADER_TIMEOUT %s exceeds APP_API_READ_TIMEOUT %s",
+			ErrInvalidConfig, c.APIReadHeaderTimeout, c.APIReadTimeout))
+	}
+	// The handler timeout answers with a 503, which cannot be written once
+	// the write timeout has passed.
+	if c.APIHandlerTimeout >= c.APIWriteTimeout {
+		errs = append(errs, fmt.Errorf("%w: APP_API_HANDLER_TIMEOUT %s must be shorter than APP_API_WRITE_TIMEOUT %s",
+			ErrInvalidConfig, c.APIHandlerTimeout, c.APIWriteTimeout))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c PaymentsConfig) validate() error {
+	errs := []error{
+		positive("PAYMENTS_AUTHORIZATION_TTL", c.AuthorizationTTL),
+		positive("PAYMENTS_EXPIRY_SWEEP_INTERVAL", c.ExpirySweepInterval),
+		positive("PAYMENTS_ASYNC_WORKERS", c.AsyncWorkers),
+		positive("PAYMENTS_ASYNC_QUEUE_SIZE", c.AsyncQueueSize),
+		positive("PAYMENTS_ASYNC_RETRY_DELAY", c.AsyncRetryDelay),
+		positive("PAYMENTS_REVIEW_SLA", c.ReviewSLA),
+		positive("PAYMENTS_REVIEW_SWEEP_INTERVAL", c.ReviewSweepInterval),
+	}
+
+	if c.ExpiryGrace < 0 {
+		errs = append(errs, fmt.Errorf("%w: PAYMENTS_EXPIRY_GRACE must not be negative, got %s", ErrInvalidConfig, c.ExpiryGrace))
+	}
+	// Approving a review captures the authorization, which must not have
+	// expired by then.
+	if c.ReviewSLA >= c.AuthorizationTTL {
+		errs = append(errs, fmt.Errorf("%w: PAYMENTS_REVIEW_SLA %s must be shorter than PAYMENTS_AUTHORIZATION_TTL %s",
+			ErrInvalidConfig, c.ReviewSLA, c.AuthorizationTTL))
+	}
+	if _, err := time.LoadLocation(c.ExpiryTimezone); err != nil {
+		errs = append(errs, fmt.Errorf("%w: PAYMENTS_EXPIRY_TIMEZONE must be an IANA time zone, got %q", ErrInvalidConfig, c.ExpiryTimezone))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c ThreeDSConfig) validate() error {
+	errs := []error{positive("THREEDS_AUTHENTICATION_TTL", c.AuthenticationTTL)}
+
+	if c.Enabled {
+		errs = append(errs, absoluteURL("THREEDS_ACS_URL", c.ACSURL), absoluteURL("THREEDS_CALLBACK_URL", c.CallbackURL))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c WebhooksConfig) validate() error {
+	errs := []error{
+		positive("WEBHOOKS_DISPATCH_INTERVAL", c.DispatchInterval),
+		positive("WEBHOOKS_WORKERS", c.Workers),
+		positive("WEBHOOKS_TIMEOUT", c.Timeout),
+		positive("WEBHOOKS_MAX_ATTEMPTS", c.MaxAttempts),
+		positive("WEBHOOKS_RETRY_INITIAL_INTERVAL", c.RetryInitialInterval),
+		positive("WEBHOOKS_RETRY_MAX_INTERVAL", c.RetryMaxInterval),
+	}
+
+	if c.RetryInitialInterval > c.RetryMaxInterval {
+		errs = append(errs, fmt.Errorf("%w: WEBHOOKS_RETRY_INITIAL_INTERVAL %s exceeds WEBHOOKS_RETRY_MAX_INTERVAL %s",
+			ErrInvalidConfig, c.RetryInitialInterval, c.RetryMaxInterval))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c OutboxConfig) validate() error {
+	errs := []error{
+		positive("OUTBOX_RELAY_INTERVAL", c.RelayInterval),
+		positive("OUTBOX_BATCH_SIZE", c.BatchSize),
+		positive("OUTBOX_HTTP_TIMEOUT", c.HTTPTimeout),
+	}
+
+	if c.Retention < 0 {
+		errs = append(errs, fmt.Errorf("%w: OUTBOX_RETENTION must not be negative, got %s", ErrInvalidConfig, c.Retention))
+	}
+
+	seen := map[string]bool{}
+	for _, name := range c.Publishers {
+		if err := oneOf("OUTBOX_PUBLISHERS", name, "file", "http"); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if seen[name] {
+			errs = append(errs, fmt.Errorf("%w: OUTBOX_PUBLISHERS lists %s twice", ErrInvalidConfig, name))
+		}
+		seen[name] = true
+	}
+	if seen["file"] && c.FilePath == "" {
+		errs = append(errs, fmt.Errorf("%w: the file publisher requires OUTBOX_FILE_PATH", ErrInvalidConfig))
+	}
+	if seen["http"] {
+		errs = append(errs, absoluteURL("OUTBOX_HTTP_URL", c.HTTPURL))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c FraudConfig) validate() error {
+	return errors.Join(
+		positive("FRAUD_RELOAD_INTERVAL", c.ReloadInterval),
+		positive("FRAUD_VELOCITY_RETENTION", c.VelocityRetention),
+	)
+}
+
+func (c TracingConfig) validate() error {
+	errs := []error{oneOf("TRACING_EXPORTER", c.Exporter, "none", "stdout", "otlp")}
+
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("%w: TRACING_SAMPLE_RATIO must be between 0 and 1, got %v", ErrInvalidConfig, c.SampleRatio))
+	}
+	if c.OTLPEndpoint != "" {
+		errs = append(errs, absoluteURL("TRACING_OTLP_ENDPOINT", c.OTLPEndpoint))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c TLSConfig) validate() error {
+	var errs []error
+
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		errs = append(errs, fmt.Errorf("%w: TLS_CERT_FILE and TLS_KEY_FILE must be set together", ErrInvalidConfig))
+	}
+	if c.ClientCAFile != "" && !c.Enabled() {
+		errs = append(errs, fmt.Errorf("%w: TLS_CLIENT_CA_FILE requires TLS_CERT_FILE", ErrInvalidConfig))
+	}
+	if len(c.ClientMerchants) > 0 && c.ClientCAFile == "" {
+		errs = append(errs, fmt.Errorf("%w: TLS_CLIENT_MERCHANTS requires TLS_CLIENT_CA_FILE", ErrInvalidConfig))
+	}
+	// Without them every certificate the authorities signed is refused.
+	if c.ClientCAFile != "" && len(c.ClientMerchants) == 0 {
+		errs = append(errs, fmt.Errorf("%w: TLS_CLIENT_CA_FILE requires TLS_CLIENT_MERCHANTS", ErrInvalidConfig))
+	}
+	if c.ClientAuth != "require" && c.ClientAuth != "optional" {
+		errs = append(errs, fmt.Errorf("%w: TLS_CLIENT_AUTH must be require or optional, got %q", ErrInvalidConfig, c.ClientAuth))
+	}
+	if c.HSTSMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("%w: TLS_HSTS_MAX_AGE must not be negative, got %s", ErrInvalidConfig, c.HSTSMaxAge))
+	}
+
+	return errors.Join(append(errs, positive("TLS_RELOAD_INTERVAL", c.ReloadInterval))...)
+}
+
+func (c AuthConfig) validate() error {
+	merchants, err := c.MerchantKeys()
+	if err != nil {
+		return err
+	}
+	operators, err := c.OperatorKeys()
+	if err != nil {
+		return err
+	}
+
+	// A merchant must never be let onto the administrative routes.
+	for key, operatorID := range operators {
+		if merchantID, ok := merchants[key]; ok {
+			return fmt.Errorf("%w: AUTH_OPERATOR_API_KEYS key of %s is also the key of merchant %s",
+				ErrInvalidConfig, operatorID, merchantID)
+		}
+	}
+
+	return nil
+}
+
+func (c AuditConfig) validate() error {
+	if _, err := hex.DecodeString(c.ChainKey); err != nil {
+		return fmt.Errorf("%w: AUDIT_CHAIN_KEY must be hex encoded", ErrInvalidConfig)
+	}
+	return nil
+}
+
+func (c BankSimulatorConfig) validate() error {
+	errs := []error{
+		positive("BANK_SIMULATOR_TIMEOUT", c.Timeout),
+		positive("BANK_SIMULATOR_BREAKER_FAILURES", c.BreakerFailures),
+		positive("BANK_SIMULATOR_BREAKER_COOLDOWN", c.BreakerCooldown),
+		absoluteURL("BANK_SIMULATOR_URL", c.URL),
+	}
+
+	if (c.ClientCertFile == "") != (c.ClientKeyFile == "") {
+		errs = append(errs, fmt.Errorf("%w: BANK_SIMULATOR_CLIENT_CERT_FILE and BANK_SIMULATOR_CLIENT_KEY_FILE must be set together", ErrInvalidConfig))
+	}
+	if c.TLSConfigured() && !strings.HasPrefix(c.URL, "https://") {
+		errs = append(errs, fmt.Errorf("%w: the TLS settings of the bank require an https BANK_SIMULATOR_URL, got %q", ErrInvalidConfig, c.URL))
+	}
+	for _, pin := range c.PinnedKeys {
+		if digest, err := base64.StdEncoding.DecodeString(pin); err != nil || len(digest) != sha256.Size {
+			errs = append(errs, fmt.Errorf("%w: BANK_SIMULATOR_PINNED_KEYS must hold base64 SHA-256 digests, got %q", ErrInvalidConfig, pin))
+		}
+	}
+	if _, err := hex.DecodeString(c.SigningKey); err != nil {
+		errs = append(errs, fmt.Errorf("%w: BANK_SIMULATOR_SIGNING_KEY must be hex encoded", ErrInvalidConfig))
+	}
+	if c.SigningKeyID != "" && c.SigningKey == "" {
+		errs = append(errs, fmt.Errorf("%w: BANK_SIMULATOR_SIGNING_KEY_ID requires BANK_SIMULATOR_SIGNING_KEY", ErrInvalidConfig))
+	}
+
+	return errors.Join(errs...)
+}
+
+func positive[T int | int64 | time.Duration](name string, value T) error {
+	if value <= 0 {
+		return fmt.Errorf("%w: %s must be positive, got %v", ErrInvalidConfig, name, value)
+	}
+	return nil
+}
+
+func oneOf(name, value string, allowed ...string) error {
+	if !slices.Contains(allowed, value) {
+		return fmt.Errorf("%w: %s must be one of %s, got %q", ErrInvalidConfig, name, strings.Join(allowed, ", "), value)
+	}
+	return nil
+}
+
+func absoluteURL(name, value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %s must be an absolute http or https URL, got %q", ErrInvalidConfig, name, value)
+	}
+	return nil
+}
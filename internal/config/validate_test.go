@@ -0,0 +1,312 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/config"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		change  func(c *config.Config)
+		wantErr string
+	}{
+		{
+			name:   "defaults",
+			change: func(c *config.Config) {},
+		},
+		{
+			name:    "zero timeout",
+			change:  func(c *config.Config) { c.App.APIIdleTimeout = 0 },
+			wantErr: "APP_API_IDLE_TIMEOUT must be positive",
+		},
+		{
+			name:    "negative body size",
+			change:  func(c *config.Config) { c.App.APIMaxBodyBytes = -1 },
+			wantErr: "APP_API_MAX_BODY_BYTES must be positive",
+		},
+		{
+			name:    "header timeout longer than read timeout",
+			change:  func(c *config.Config) { c.App.APIReadHeaderTimeout = time.Minute },
+			wantErr: "APP_API_READ_HEADER_TIMEOUT 1m0s exceeds APP_API_READ_TIMEOUT 15s",
+		},
+		{
+			name:    "handler timeout not shorter than write timeout",
+			change:  func(c *config.Config) { c.App.APIHandlerTimeout = c.App.APIWriteTimeout },
+			wantErr: "APP_API_HANDLER_TIMEOUT 35s must be shorter than APP_API_WRITE_TIMEOUT 35s",
+		},
+		{
+			name:    "unknown log format",
+			change:  func(c *config.Config) { c.App.LogFormat = "xml" },
+			wantErr: `APP_LOG_FORMAT must be one of json, text, got "xml"`,
+		},
+		{
+			name:    "unknown log level",
+			change:  func(c *config.Config) { c.App.LogLevel = "verbose" },
+			wantErr: `APP_LOG_LEVEL must be debug, info, warn or error, got "verbose"`,
+		},
+		{
+			name:    "zero expiry sweep interval",
+			change:  func(c *config.Config) { c.Payments.ExpirySweepInterval = 0 },
+			wantErr: "PAYMENTS_EXPIRY_SWEEP_INTERVAL must be positive",
+		},
+		{
+			name:    "negative review sweep interval",
+			change:  func(c *config.Config) { c.Payments.ReviewSweepInterval = -time.Second },
+			wantErr: "PAYMENTS_REVIEW_SWEEP_INTERVAL must be positive",
+		},
+		{
+			name:    "review SLA outliving the authorization",
+			change:  func(c *config.Config) { c.Payments.ReviewSLA = c.Payments.AuthorizationTTL },
+			wantErr: "PAYMENTS_REVIEW_SLA 168h0m0s must be shorter than PAYMENTS_AUTHORIZATION_TTL 168h0m0s",
+		},
+		{
+			name:    "zero async queue size",
+			change:  func(c *config.Config) { c.Payments.AsyncQueueSize = 0 },
+			wantErr: "PAYMENTS_ASYNC_QUEUE_SIZE must be positive",
+		},
+		{
+			name:    "unknown expiry timezone",
+			change:  func(c *config.Config) { c.Payments.ExpiryTimezone = "Mars/Olympus" },
+			wantErr: `PAYMENTS_EXPIRY_TIMEZONE must be an IANA time zone, got "Mars/Olympus"`,
+		},
+		{
+			name:    "zero authentication TTL",
+			change:  func(c *config.Config) { c.ThreeDS.AuthenticationTTL = 0 },
+			wantErr: "THREEDS_AUTHENTICATION_TTL must be positive",
+		},
+		{
+			name: "relative 3-D Secure callback URL",
+			change: func(c *config.Config) {
+				c.ThreeDS.Enabled = true
+				c.ThreeDS.CallbackURL = "/callback"
+			},
+			wantErr: `THREEDS_CALLBACK_URL must be an absolute http or https URL, got "/callback"`,
+		},
+		{
+			name:    "zero webhooks workers",
+			change:  func(c *config.Config) { c.Webhooks.Workers = 0 },
+			wantErr: "WEBHOOKS_WORKERS must be positive",
+		},
+		{
+			name:    "zero webhooks dispatch interval",
+			change:  func(c *config.Config) { c.Webhooks.DispatchInterval = 0 },
+			wantErr: "WEBHOOKS_DISPATCH_INTERVAL must be positive",
+		},
+		{
+			name:    "zero webhooks max attempts",
+			change:  func(c *config.Config) { c.Webhooks.MaxAttempts = 0 },
+			wantErr: "WEBHOOKS_MAX_ATTEMPTS must be positive",
+		},
+		{
+			name:    "webhooks retry intervals reversed",
+			change:  func(c *config.Config) { c.Webhooks.RetryInitialInterval = 12 * time.Hour },
+			wantErr: "WEBHOOKS_RETRY_INITIAL_INTERVAL 12h0m0s exceeds WEBHOOKS_RETRY_MAX_INTERVAL 6h0m0s",
+		},
+		{
+			name:    "zero outbox relay interval",
+			change:  func(c *config.Config) { c.Outbox.RelayInterval = 0 },
+			wantErr: "OUTBOX_RELAY_INTERVAL must be positive",
+		},
+		{
+			name:    "zero outbox batch size",
+			change:  func(c *config.Config) { c.Outbox.BatchSize = 0 },
+			wantErr: "OUTBOX_BATCH_SIZE must be positive",
+		},
+		{
+			name:    "unknown outbox publisher",
+			change:  func(c *config.Config) { c.Outbox.Publishers = []string{"kafka"} },
+			wantErr: `OUTBOX_PUBLISHERS must be one of file, http, got "kafka"`,
+		},
+		{
+			name:    "http publisher without URL",
+			change:  func(c *config.Config) { c.Outbox.Publishers = []string{"http"} },
+			wantErr: `OUTBOX_HTTP_URL must be an absolute http or https URL, got ""`,
+		},
+		{
+			name:    "zero fraud reload interval",
+			change:  func(c *config.Config) { c.Fraud.ReloadInterval = 0 },
+			wantErr: "FRAUD_RELOAD_INTERVAL must be positive",
+		},
+		{
+			name:    "unknown tracing exporter",
+			change:  func(c *config.Config) { c.Tracing.Exporter = "jaeger" },
+			wantErr: `TRACING_EXPORTER must be one of none, stdout, otlp, got "jaeger"`,
+		},
+		{
+			name:    "sample ratio above one",
+			change:  func(c *config.Config) { c.Tracing.SampleRatio = 1.5 },
+			wantErr: "TRACING_SAMPLE_RATIO must be between 0 and 1, got 1.5",
+		},
+		{
+			name:    "zero breaker failures",
+			change:  func(c *config.Config) { c.BankSimulator.BreakerFailures = 0 },
+			wantErr: "BANK_SIMULATOR_BREAKER_FAILURES must be positive",
+		},
+		{
+			name:    "certificate without key",
+			change:  func(c *config.Config) { c.TLS.CertFile = "server.pem" },
+			wantErr: "TLS_CERT_FILE and TLS_KEY_FILE must be set together",
+		},
+		{
+			name: "client merchants without client CA",
+			change: func(c *config.Config) {
+				c.TLS.CertFile, c.TLS.KeyFile = "server.pem", "server.key"
+				c.TLS.ClientMerchants = map[string]string{"shop.example.com": "merchant_a"}
+			},
+			wantErr: "TLS_CLIENT_MERCHANTS requires TLS_CLIENT_CA_FILE",
+		},
+		{
+			name: "client CA without client merchants",
+			change: func(c *config.Config) {
+				c.TLS.CertFile, c.TLS.KeyFile = "server.pem", "server.key"
+				c.TLS.ClientCAFile = "clients.pem"
+			},
+			wantErr: "TLS_CLIENT_CA_FILE requires TLS_CLIENT_MERCHANTS",
+		},
+		{
+			name:    "unknown client auth",
+			change:  func(c *config.Config) { c.TLS.ClientAuth = "request" },
+			wantErr: `TLS_CLIENT_AUTH must be require or optional, got "request"`,
+		},
+		{
+			name:    "bank client certificate over plain HTTP",
+			change:  func(c *config.Config) { c.BankSimulator.CAFile = "bank-ca.pem" },
+			wantErr: `the TLS settings of the bank require an https BANK_SIMULATOR_URL, got "http://localhost:8080"`,
+		},
+		{
+			name: "invalid bank pin",
+			change: func(c *config.Config) {
+				c.BankSimulator.URL = "https://bank.example.com"
+				c.BankSimulator.PinnedKeys = []string{"not-a-pin"}
+			},
+			wantErr: `BANK_SIMULATOR_PINNED_KEYS must hold base64 SHA-256 digests, got "not-a-pin"`,
+		},
+		{
+			name:    "invalid signing key",
+			change:  func(c *config.Config) { c.BankSimulator.SigningKey = "not hex" },
+			wantErr: "BANK_SIMULATOR_SIGNING_KEY must be hex encoded",
+		},
+		{
+			name:    "production without merchant authentication",
+			change:  func(c *config.Config) { c.App.Environment = "production" },
+			wantErr: "production requires AUTH_MERCHANT_API_KEYS or TLS_CLIENT_CA_FILE",
+		},
+		{
+			name: "production with client CA but no client merchants",
+			change: func(c *config.Config) {
+				c.App.Environment = "production"
+				c.Audit.ChainKey = "00112233445566778899aabbccddeeff"
+				c.TLS.CertFile, c.TLS.KeyFile = "server.pem", "server.key"
+				c.TLS.ClientCAFile = "clients.pem"
+			},
+			wantErr: "production requires AUTH_MERCHANT_API_KEYS or TLS_CLIENT_CA_FILE with TLS_CLIENT_MERCHANTS",
+		},
+		{
+			name: "production with merchant API keys",
+			change: func(c *config.Config) {
+				c.App.Environment = "production"
+				c.Auth.MerchantAPIKeys = "merchant_a:0123456789abcdef"
+				c.Audit.ChainKey = "00112233445566778899aabbccddeeff"
+			},
+		},
+		{
+			name: "production without audit chain key",
+			change: func(c *config.Config) {
+				c.App.Environment = "production"
+				c.Auth.MerchantAPIKeys = "merchant_a:0123456789abcdef"
+			},
+			wantErr: "production requires AUDIT_CHAIN_KEY",
+		},
+		{
+			name: "production with the simulated ACS",
+			change: func(c *config.Config) {
+				c.App.Environment = "production"
+				c.Auth.MerchantAPIKeys = "merchant_a:0123456789abcdef"
+				c.Audit.ChainKey = "00112233445566778899aabbccddeeff"
+				c.ThreeDS.Enabled = true
+			},
+			wantErr: "THREEDS_ENABLED serves a simulated ACS and is refused in production",
+		},
+		{
+			name: "production with webhooks on private networks",
+			change: func(c *config.Config) {
+				c.App.Environment = "production"
+				c.Auth.MerchantAPIKeys = "merchant_a:0123456789abcdef"
+				c.Audit.ChainKey = "00112233445566778899aabbccddeeff"
+				c.Webhooks.AllowPrivateNetworks = true
+			},
+			wantErr: "WEBHOOKS_ALLOW_PRIVATE_NETWORKS is refused in production",
+		},
+		{
+			name:    "audit chain key not hex encoded",
+			change:  func(c *config.Config) { c.Audit.ChainKey = "not hex" },
+			wantErr: "AUDIT_CHAIN_KEY must be hex encoded",
+		},
+		{
+			name:    "malformed merchant API keys",
+			change:  func(c *config.Config) { c.Auth.MerchantAPIKeys = "0123456789abcdef" },
+			wantErr: "AUTH_MERCHANT_API_KEYS entry 1 must use the id:key format",
+		},
+		{
+			name:    "short merchant API key",
+			change:  func(c *config.Config) { c.Auth.MerchantAPIKeys = "merchant_a:secret" },
+			wantErr: "AUTH_MERCHANT_API_KEYS key of merchant_a must be at least 16 characters",
+		},
+		{
+			name: "shared merchant API key",
+			change: func(c *config.Config) {
+				c.Auth.MerchantAPIKeys = "merchant_a:0123456789abcdef,merchant_b:0123456789abcdef"
+			},
+			wantErr: "AUTH_MERCHANT_API_KEYS key of merchant_b is used twice",
+		},
+		{
+			name: "operator API key shared with a merchant",
+			change: func(c *config.Config) {
+				c.Auth.MerchantAPIKeys = "merchant_a:0123456789abcdef"
+				c.Auth.OperatorAPIKeys = "alice@risk:0123456789abcdef"
+			},
+			wantErr: "AUTH_OPERATOR_API_KEYS key of alice@risk is also the key of merchant merchant_a",
+		},
+		{
+			name:    "negative readiness delay",
+			change:  func(c *config.Config) { c.App.ShutdownReadinessDelay = -time.Second },
+			wantErr: "APP_SHUTDOWN_READINESS_DELAY must not be negative",
+		},
+		{
+			name:    "drain timeout not longer than bank timeout",
+			change:  func(c *config.Config) { c.App.ShutdownDrainTimeout = c.BankSimulator.Timeout },
+			wantErr: "APP_SHUTDOWN_DRAIN_TIMEOUT 10s must be longer than BANK_SIMULATOR_TIMEOUT 10s",
+		},
+		{
+			name:    "zero bank timeout",
+			change:  func(c *config.Config) { c.BankSimulator.Timeout = 0 },
+			wantErr: "BANK_SIMULATOR_TIMEOUT must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var c config.Config
+			require.NoError(t, envconfig.Process("test_defaults", &c))
+			tt.change(&c)
+
+			err := c.Validate()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, config.ErrInvalidConfig)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
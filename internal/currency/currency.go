@@ -0,0 +1,68 @@
+package currency
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownCurrency is returned when a code is not part of the ISO 4217 registry.
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// maxMajorUnits caps the amount accepted for a single payment, expressed in
+// major units (e.g. dollars), regardless of the currency exponent.
+const maxMajorUnits int64 = 999_999_999
+
+// Currency describes an ISO 4217 currency.
+type Currency struct {
+	Code     string // Alphabetic code (e.g. USD).
+	Numeric  string // Numeric code (e.g. 840).
+	Exponent int    // Number of minor unit digits (e.g. 2 for USD, 0 for JPY, 3 for KWD).
+	Name     string // English name of the currency.
+}
+
+// Lookup returns the currency registered for the given alphabetic code.
+func Lookup(code string) (Currency, bool) {
+	c, ok := registry[code]
+	return c, ok
+}
+
+// Codes returns the alphabetic codes of every registered currency.
+func Codes() []string {
+	codes := make([]string, 0, len(registry))
+	for code := range registry {
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// MaxAmount returns the largest amount accepted for this currency, in minor units.
+func (c Currency) MaxAmount() int64 {
+	return maxMajorUnits*c.scale() + c.scale() - 1
+}
+
+// Format renders an amount in minor units as a decimal string using the
+// currency exponent. Example: 1099 USD → "10.99", 1099 JPY → "1099".
+func (c Currency) Format(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	major := strconv.FormatInt(amount/c.scale(), 10)
+	if c.Exponent == 0 {
+		return sign + major
+	}
+
+	minor := strconv.FormatInt(amount%c.scale(), 10)
+	return sign + major + "." + strings.Repeat("0", c.Exponent-len(minor)) + minor
+}
+
+func (c Currency) scale() int64 {
+	s := int64(1)
+	for range c.Exponent {
+		s *= 10
+	}
+	return s
+}
@@ -0,0 +1,102 @@
+package currency_test
+
+import (
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/currency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		code     string
+		exponent int
+	}{
+		{code: "USD", exponent: 2},
+		{code: "JPY", exponent: 0},
+		{code: "KWD", exponent: 3},
+		{code: "CLF", exponent: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			t.Parallel()
+
+			c, ok := currency.Lookup(tt.code)
+			require.True(t, ok)
+			assert.Equal(t, tt.code, c.Code)
+			assert.Equal(t, tt.exponent, c.Exponent)
+		})
+	}
+
+	_, ok := currency.Lookup("ABC")
+	assert.False(t, ok)
+}
+
+func TestCurrency_Format(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		code     string
+		amount   int64
+		expected string
+	}{
+		{code: "USD", amount: 1099, expected: "10.99"},
+		{code: "USD", amount: 5, expected: "0.05"},
+		{code: "JPY", amount: 1099, expected: "1099"},
+		{code: "KWD", amount: 1099, expected: "1.099"},
+		{code: "KWD", amount: 10, expected: "0.010"},
+		{code: "EUR", amount: -250, expected: "-2.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code+" "+tt.expected, func(t *testing.T) {
+			t.Parallel()
+
+			c, ok := currency.Lookup(tt.code)
+			require.True(t, ok)
+			assert.Equal(t, tt.expected, c.Format(tt.amount))
+		})
+	}
+}
+
+func TestCurrency_MaxAmount(t *testing.T) {
+	t.Parallel()
+
+	jpy, _ := currency.Lookup("JPY")
+	usd, _ := currency.Lookup("USD")
+	kwd, _ := currency.Lookup("KWD")
+
+	assert.Equal(t, int64(999_999_999), jpy.MaxAmount())
+	assert.Equal(t, int64(999_999_999_99), usd.MaxAmount())
+	assert.Equal(t, int64(999_999_999_999), kwd.MaxAmount())
+}
+
+func TestEnabled(t *testing.T) {
+	t.Parallel()
+
+	enabled, err := currency.NewEnabled(
+		[]string{"USD", "EUR"},
+		map[string][]string{"merchant_jp": {"JPY"}},
+	)
+	require.NoError(t, err)
+
+	assert.True(t, enabled.IsEnabled("any", "USD"))
+	assert.False(t, enabled.IsEnabled("any", "JPY"))
+	assert.True(t, enabled.IsEnabled("merchant_jp", "JPY"))
+	assert.False(t, enabled.IsEnabled("merchant_jp", "USD"))
+	assert.Equal(t, []string{"EUR", "USD"}, enabled.Codes(""))
+}
+
+func TestNewEnabled_UnknownCurrency(t *testing.T) {
+	t.Parallel()
+
+	_, err := currency.NewEnabled([]string{"USD", "XYZ"}, nil)
+	require.ErrorIs(t, err, currency.ErrUnknownCurrency)
+
+	_, err = currency.NewEnabled(nil, map[string][]string{"m": {"ABC"}})
+	require.ErrorIs(t, err, currency.ErrUnknownCurrency)
+}
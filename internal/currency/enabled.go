@@ -0,0 +1,64 @@
+package currency
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Enabled keeps track of which currencies each merchant is allowed to process.
+//
+// Merchants without an explicit configuration fall back to the default set.
+type Enabled struct {
+	defaults  []string
+	merchants map[string][]string
+}
+
+// NewEnabled builds the enabled currencies set, failing if any code is not
+// part of the ISO 4217 registry.
+func NewEnabled(defaults []string, merchants map[string][]string) (*Enabled, error) {
+	e := &Enabled{
+		merchants: make(map[string][]string, len(merchants)),
+	}
+
+	codes, err := normalize(defaults)
+	if err != nil {
+		return nil, fmt.Errorf("default currencies: %w", err)
+	}
+	e.defaults = codes
+
+	for merchantID, merchantCodes := range merchants {
+		codes, err := normalize(merchantCodes)
+		if err != nil {
+			return nil, fmt.Errorf("merchant %q currencies: %w", merchantID, err)
+		}
+		e.merchants[merchantID] = codes
+	}
+
+	return e, nil
+}
+
+// IsEnabled reports whether the merchant can process payments in the given currency.
+func (e *Enabled) IsEnabled(merchantID, code string) bool {
+	return slices.Contains(e.Codes(merchantID), code)
+}
+
+// Codes returns the sorted currency codes enabled for the merchant.
+func (e *Enabled) Codes(merchantID string) []string {
+	if codes, ok := e.merchants[merchantID]; ok {
+		return codes
+	}
+	return e.defaults
+}
+
+func normalize(codes []string) ([]string, error) {
+	out := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if _, ok := Lookup(code); !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownCurrency, code)
+		}
+		out = append(out, code)
+	}
+
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
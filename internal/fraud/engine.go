@@ -0,0 +1,200 @@
+package fraud
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+)
+
+// Engine evaluates transactions against the current rules. Rules can be
+// replaced at any time, including while transactions are being screened.
+type Engine struct {
+	rules    atomic.Pointer[Rules]
+	counters VelocityCounters
+	clock    clock.Clock
+	// retention is how long the counters remember events, checked against
+	// the velocity windows of reloaded rules when set.
+	retention time.Duration
+}
+
+// Option configures optional dependencies of the Engine.
+type Option func(*Engine)
+
+// WithClock sets the clock used by velocity rules.
+func WithClock(clk clock.Clock) Option {
+	return func(e *Engine) {
+		e.clock = clk
+	}
+}
+
+// WithVelocityRetention sets how long the counters remember events, so
+// Watch rejects rules with longer velocity windows.
+func WithVelocityRetention(retention time.Duration) Option {
+	return func(e *Engine) {
+		e.retention = retention
+	}
+}
+
+func NewEngine(rules *Rules, counters VelocityCounters, opts ...Option) *Engine {
+	e := &Engine{
+		counters: counters,
+		clock:    clock.New(),
+	}
+	e.rules.Store(rules)
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// SetRules replaces the rules used by the next screenings.
+func (e *Engine) SetRules(rules *Rules) {
+	e.rules.Store(rules)
+}
+
+// Screen records the attempt and evaluates the transaction.
+func (e *Engine) Screen(ctx context.Context, tx Transaction) (Decision, error) {
+	rules := e.rules.Load()
+	now := e.clock.Now()
+
+	if tx.CardFingerprint != "" {
+		if err := e.counters.RecordAttempt(ctx, tx.MerchantID, tx.CardFingerprint, now); err != nil {
+			return Decision{}, fmt.Errorf("record attempt: %w", err)
+		}
+	}
+
+	country := rules.binCountry(tx.BIN)
+
+	if rules.Blocklists.matches(tx, country) {
+		return Decision{Outcome: OutcomeBlock, Score: MaxScore, Rules: []string{"blocklist"}}, nil
+	}
+
+	decision := Decision{Outcome: OutcomeAllow}
+	forced := OutcomeAllow
+
+	for _, rule := range rules.Rules {
+		matched, err := e.matches(ctx, rule, tx, country, now)
+		if err != nil {
+			return Decision{}, fmt.Errorf("evaluate rule %q: %w", rule.Name, err)
+		}
+		if !matched {
+			continue
+		}
+
+		decision.Score += rule.Score
+		decision.Rules = append(decision.Rules, rule.Name)
+		if rule.Action.severity() > forced.severity() {
+			forced = rule.Action
+		}
+	}
+
+	decision.Score = min(decision.Score, MaxScore)
+
+	switch {
+	case rules.BlockScore > 0 && decision.Score >= rules.BlockScore:
+		decision.Outcome = OutcomeBlock
+	case rules.ReviewScore > 0 && decision.Score >= rules.ReviewScore:
+		decision.Outcome = OutcomeReview
+	}
+
+	if forced.severity() > decision.Outcome.severity() {
+		decision.Outcome = forced
+	}
+
+	return decision, nil
+}
+
+// RecordAttempt counts a payment attempt without evaluating it, for the
+// payments that skip screening.
+func (e *Engine) RecordAttempt(ctx context.Context, tx Transaction) error {
+	if tx.CardFingerprint == "" {
+		return nil
+	}
+	return e.counters.RecordAttempt(ctx, tx.MerchantID, tx.CardFingerprint, e.clock.Now())
+}
+
+// RecordDecline counts a payment declined by the bank, for the velocity
+// rules on declines.
+func (e *Engine) RecordDecline(ctx context.Context, tx Transaction) error {
+	if tx.CardFingerprint == "" {
+		return nil
+	}
+	return e.counters.RecordDecline(ctx, tx.CardFingerprint, e.clock.Now())
+}
+
+// matches checks the velocity of the rule only once every other condition
+// matched, sparing the counters lookups.
+func (e *Engine) matches(ctx context.Context, rule Rule, tx Transaction, country string, now time.Time) (bool, error) {
+	if rule.AmountAbove > 0 && tx.Amount <= rule.AmountAbove {
+		return false, nil
+	}
+	if len(rule.Currencies) > 0 && !slices.Contains(rule.Currencies, tx.Currency) {
+		return false, nil
+	}
+	if len(rule.BINCountries) > 0 && !slices.Contains(rule.BINCountries, country) {
+		return false, nil
+	}
+	if len(rule.Merchants) > 0 && !slices.Contains(rule.Merchants, tx.MerchantID) {
+		return false, nil
+	}
+	if v := rule.Velocity; v != nil {
+		n, err := v.count(ctx, e.counters, tx, now)
+		if err != nil {
+			return false, err
+		}
+		if n < v.Max {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Watch reloads the rules file every time it changes, checking every
+// interval until the context is cancelled. Invalid files are logged and
+// ignored, keeping the previous rules.
+func (e *Engine) Watch(ctx context.Context, path string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Reload on the first check too, in case the file changed since the
+	// engine was created.
+	var last os.FileInfo
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil {
+				slog.Error("checking fraud rules", "path", path, "error", err)
+				continue
+			}
+
+			if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+				continue
+			}
+			last = info
+
+			rules, err := LoadRules(path)
+			if err == nil && e.retention > 0 {
+				err = rules.CheckVelocityRetention(e.retention)
+			}
+			if err != nil {
+				slog.Error("reloading fraud rules, keeping the previous ones", "path", path, "error", err)
+				continue
+			}
+
+			e.SetRules(rules)
+			slog.Info("reloaded fraud rules", "path", path, "rules", len(rules.Rules))
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package fraud_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/fraud"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRules = `
+review_score: 50
+block_score: 80
+bins:
+  "4111": US
+  "539999": NG
+blocklists:
+  bins: ["400000"]
+  countries: [KP]
+  card_fingerprints: [stolen]
+rules:
+  - name: large_amount
+    amount_above: 500000
+    score: 30
+  - name: high_risk_country
+    bin_countries: [NG]
+    score: 40
+  - name: brl_merchant
+    currencies: [BRL]
+    merchants: [merchant_br]
+    score: 20
+  - name: card_testing
+    velocity: {max: 3, window: 10m}
+    action: block
+`
+
+var engineNow = time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC)
+
+func newTestEngine(t *testing.T) (*fraud.Engine, *clock.Fake) {
+	t.Helper()
+
+	rules, err := fraud.ParseRules([]byte(testRules))
+	require.NoError(t, err)
+
+	clk := clock.NewFake(engineNow)
+	counters := repository.NewVelocityCountersInMemory(24 * time.Hour)
+	return fraud.NewEngine(rules, counters, fraud.WithClock(clk)), clk
+}
+
+func TestParseRules_JSON(t *testing.T) {
+	t.Parallel()
+
+	rules, err := fraud.ParseRules([]byte(`{
+		"review_score": 40,
+		"rules": [{"name": "velocity", "velocity": {"count": "card_declines", "max": 2, "window": "1h"}, "score": 10}]
+	}`))
+
+	require.NoError(t, err)
+	assert.Equal(t, 40, rules.ReviewScore)
+	require.Len(t, rules.Rules, 1)
+	assert.Equal(t, &fraud.Velocity{Count: fraud.CountCardDeclines, Max: 2, Window: time.Hour}, rules.Rules[0].Velocity)
+}
+
+func TestParseRules_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		rules string
+	}{
+		{name: "malformed", rules: "rules: [name: a"},
+		{name: "negative score", rules: "block_score: -1"},
+		{name: "missing name", rules: "rules: [{score: 10}]"},
+		{name: "duplicate name", rules: "rules: [{name: a}, {name: a}]"},
+		{name: "unknown action", rules: "rules: [{name: a, action: deny}]"},
+		{name: "velocity without window", rules: "rules: [{name: a, velocity: {max: 2}}]"},
+		{name: "unknown velocity count", rules: "rules: [{name: a, velocity: {count: refunds, max: 2, window: 1m}}]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := fraud.ParseRules([]byte(tt.rules))
+			require.ErrorIs(t, err, fraud.ErrInvalidRules)
+		})
+	}
+}
+
+func TestEngine_Screen(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		tx       fraud.Transaction
+		expected fraud.Decision
+	}{
+		{
+			name:     "no rule matches",
+			tx:       fraud.Transaction{BIN: "411111", Currency: "USD", Amount: 1000},
+			expected: fraud.Decision{Outcome: fraud.OutcomeAllow},
+		},
+		{
+			name: "score below the review threshold",
+			tx:   fraud.Transaction{BIN: "411111", Currency: "USD", Amount: 600000},
+			expected: fraud.Decision{
+				Outcome: fraud.OutcomeAllow,
+				Score:   30,
+				Rules:   []string{"large_amount"},
+			},
+		},
+		{
+			name: "score at the review threshold",
+			tx:   fraud.Transaction{BIN: "539999", Currency: "USD", Amount: 600000},
+			expected: fraud.Decision{
+				Outcome: fraud.OutcomeReview,
+				Score:   70,
+				Rules:   []string{"large_amount", "high_risk_country"},
+			},
+		},
+		{
+			name: "score at the block threshold",
+			tx:   fraud.Transaction{MerchantID: "merchant_br", BIN: "539999", Currency: "BRL", Amount: 600000},
+			expected: fraud.Decision{
+				Outcome: fraud.OutcomeBlock,
+				Score:   90,
+				Rules:   []string{"large_amount", "high_risk_country", "brl_merchant"},
+			},
+		},
+		{
+			name:     "blocklisted BIN",
+			tx:       fraud.Transaction{BIN: "400000", Currency: "USD", Amount: 1000},
+			expected: fraud.Decision{Outcome: fraud.OutcomeBlock, Score: fraud.MaxScore, Rules: []string{"blocklist"}},
+		},
+		{
+			name:     "blocklisted card",
+			tx:       fraud.Transaction{BIN: "411111", CardFingerprint: "stolen", Currency: "USD", Amount: 1000},
+			expected: fraud.Decision{Outcome: fraud.OutcomeBlock, Score: fraud.MaxScore, Rules: []string{"blocklist"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			engine, _ := newTestEngine(t)
+
+			decision, err := engine.Screen(context.Background(), tt.tx)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, decision)
+		})
+	}
+}
+
+func TestEngine_Screen_Velocity(t *testing.T) {
+	t.Parallel()
+
+	engine, clk := newTestEngine(t)
+	tx := fraud.Transaction{BIN: "411111", CardFingerprint: "card_a", Currency: "USD", Amount: 1000}
+
+	// Attempts recorded without screening, for allowlisted cards, count too.
+	require.NoError(t, engine.RecordAttempt(context.Background(), tx))
+	clk.Advance(time.Minute)
+
+	decision, err := engine.Screen(context.Background(), tx)
+	require.NoError(t, err)
+	require.Equal(t, fraud.OutcomeAllow, decision.Outcome)
+	clk.Advance(time.Minute)
+
+	decision, err = engine.Screen(context.Background(), tx)
+	require.NoError(t, err)
+	assert.Equal(t, fraud.OutcomeBlock, decision.Outcome)
+	assert.Equal(t, []string{"card_testing"}, decision.Rules)
+
+	other := tx
+	other.CardFingerprint = "card_b"
+	decision, err = engine.Screen(context.Background(), other)
+	require.NoError(t, err)
+	assert.Equal(t, fraud.OutcomeAllow, decision.Outcome, "attempts are counted per card")
+
+	// Past the window only the latest attempt remains.
+	clk.Advance(time.Hour)
+	decision, err = engine.Screen(context.Background(), tx)
+	require.NoError(t, err)
+	assert.Equal(t, fraud.OutcomeAllow, decision.Outcome)
+}
+
+func TestEngine_Screen_VelocityCounts(t *testing.T) {
+	t.Parallel()
+
+	rules, err := fraud.ParseRules([]byte(`
+rules:
+  - name: declined_card
+    velocity: {count: card_declines, max: 2, window: 1h}
+    action: block
+  - name: many_cards
+    velocity: {count: merchant_cards, max: 3, window: 1m}
+    action: review
+`))
+	require.NoError(t, err)
+
+	clk := clock.NewFake(engineNow)
+	engine := fraud.NewEngine(rules, repository.NewVelocityCountersInMemory(time.Hour), fraud.WithClock(clk))
+	ctx := context.Background()
+
+	card := func(fingerprint string) fraud.Transaction {
+		return fraud.Transaction{MerchantID: "merchant_a", BIN: "411111", CardFingerprint: fingerprint, Currency: "USD", Amount: 100}
+	}
+
+	// Two cards at the merchant within a minute are fine, the third is not.
+	for _, fingerprint := range []string{"card_a", "card_b", "card_a"} {
+		decision, err := engine.Screen(ctx, card(fingerprint))
+		require.NoError(t, err)
+		require.Equal(t, fraud.OutcomeAllow, decision.Outcome)
+	}
+	decision, err := engine.Screen(ctx, card("card_c"))
+	require.NoError(t, err)
+	assert.Equal(t, fraud.OutcomeReview, decision.Outcome)
+	assert.Equal(t, []string{"many_cards"}, decision.Rules)
+
+	// The cards used a minute ago no longer count.
+	clk.Advance(time.Minute)
+	require.NoError(t, engine.RecordDecline(ctx, card("card_a")))
+	decision, err = engine.Screen(ctx, card("card_a"))
+	require.NoError(t, err)
+	assert.Equal(t, fraud.OutcomeAllow, decision.Outcome)
+
+	require.NoError(t, engine.RecordDecline(ctx, card("card_a")))
+	decision, err = engine.Screen(ctx, card("card_a"))
+	require.NoError(t, err)
+	assert.Equal(t, fraud.OutcomeBlock, decision.Outcome)
+	assert.Equal(t, []string{"declined_card"}, decision.Rules)
+}
+
+func TestEngine_Watch(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("rules: []"), 0o600))
+
+	rules, err := fraud.LoadRules(path)
+	require.NoError(t, err)
+	engine := fraud.NewEngine(rules, repository.NewVelocityCountersInMemory(time.Hour),
+		fraud.WithVelocityRetention(time.Hour),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go engine.Watch(ctx, path, 10*time.Millisecond)
+
+	tx := fraud.Transaction{MerchantID: "merchant_a", BIN: "411111", Currency: "USD", Amount: 1000}
+	screen := func() fraud.Outcome {
+		decision, err := engine.Screen(context.Background(), tx)
+		require.NoError(t, err)
+		return decision.Outcome
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte("rules: [{name: a, merchants: [merchant_a], action: review}]"), 0o600))
+	require.Eventually(t, func() bool { return screen() == fraud.OutcomeReview }, time.Second, 10*time.Millisecond)
+
+	// An invalid file keeps the previous rules.
+	require.NoError(t, os.WriteFile(path, []byte("rules: [{name: a, action: deny}]"), 0o600))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, fraud.OutcomeReview, screen())
+
+	// So does a velocity window longer than the counters remember.
+	require.NoError(t, os.WriteFile(path, []byte(
+		"rules: [{name: b, merchants: [merchant_a], velocity: {count: card_attempts, max: 1, window: 2h}, action: block}]"), 0o600))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, fraud.OutcomeReview, screen())
+}
@@ -0,0 +1,60 @@
+// Package fraud screens payments against configurable risk rules before
+// they are sent to the bank.
+package fraud
+
+import (
+	"context"
+)
+
+// Outcome is the action to take on a screened payment.
+type Outcome string
+
+const (
+	OutcomeAllow  Outcome = "allow"
+	OutcomeReview Outcome = "review"
+	OutcomeBlock  Outcome = "block"
+)
+
+// severity orders outcomes from the most lenient to the strictest.
+func (o Outcome) severity() int {
+	switch o {
+	case OutcomeReview:
+		return 1
+	case OutcomeBlock:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// MaxScore is the highest risk score, given to blocklisted payments.
+const MaxScore = 100
+
+// Transaction is what the rules are evaluated against. It never carries the
+// full card number.
+type Transaction struct {
+	MerchantID string
+	// BIN is the bank identification number, the first six digits of the card.
+	BIN string
+	// CardFingerprint identifies the card without revealing its number.
+	CardFingerprint string
+	Currency        string
+	Amount          int64
+}
+
+// Decision is the result of screening a transaction.
+type Decision struct {
+	Outcome Outcome
+	Score   int      // Risk score, from 0 to MaxScore.
+	Rules   []string // Names of the rules that matched.
+}
+
+// Screener decides whether a transaction can be sent to the bank.
+type Screener interface {
+	Screen(ctx context.Context, tx Transaction) (Decision, error)
+	// RecordAttempt counts a transaction that is not screened, so it still
+	// counts towards the velocity rules.
+	RecordAttempt(ctx context.Context, tx Transaction) error
+	// RecordDecline reports a transaction declined by the bank.
+	RecordDecline(ctx context.Context, tx Transaction) error
+}
@@ -0,0 +1,157 @@
+package fraud
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidRules = errors.New("invalid fraud rules")
+
+// Rules is the content of a rules file, in YAML or JSON:
+//
+//	review_score: 50
+//	block_score: 80
+//	bins:
+//	  "539999": NG
+//	blocklists:
+//	  bins: ["400000"]
+//	  countries: [KP]
+//	rules:
+//	  - name: large_amount
+//	    amount_above: 500000
+//	    currencies: [USD, EUR]
+//	    score: 30
+//	  - name: card_testing
+//	    velocity: {count: card_attempts, max: 5, window: 10m}
+//	    action: block
+//
+// The scores of the matching rules add up: a total at or above ReviewScore
+// holds the payment for review, at or above BlockScore blocks it. A rule can
+// also force an outcome with its action.
+type Rules struct {
+	ReviewScore int `yaml:"review_score"`
+	BlockScore  int `yaml:"block_score"`
+	// BINs maps BIN prefixes to the ISO 3166-1 alpha-2 country of the
+	// issuer. The longest matching prefix wins.
+	BINs       map[string]string `yaml:"bins"`
+	Blocklists Blocklists        `yaml:"blocklists"`
+	Rules      []Rule            `yaml:"rules"`
+}
+
+// Blocklists are always blocked, regardless of the rules.
+type Blocklists struct {
+	BINs             []string `yaml:"bins"`      // BIN prefixes.
+	Countries        []string `yaml:"countries"` // Issuer countries.
+	CardFingerprints []string `yaml:"card_fingerprints"`
+}
+
+// Rule matches a transaction when all of its conditions do. Conditions left
+// empty always match.
+type Rule struct {
+	Name         string    `yaml:"name"`
+	AmountAbove  int64     `yaml:"amount_above"` // In minor units, exclusive.
+	Currencies   []string  `yaml:"currencies"`
+	BINCountries []string  `yaml:"bin_countries"`
+	Merchants    []string  `yaml:"merchants"`
+	Velocity     *Velocity `yaml:"velocity"`
+	Score        int       `yaml:"score"`
+	Action       Outcome   `yaml:"action"` // Outcome forced when the rule matches.
+}
+
+// LoadRules reads a rules file. JSON files are read as YAML, of which JSON
+// is a subset.
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fraud rules: %w", err)
+	}
+
+	return ParseRules(data)
+}
+
+func ParseRules(data []byte) (*Rules, error) {
+	var rules Rules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRules, err)
+	}
+
+	if err := rules.validate(); err != nil {
+		return nil, err
+	}
+
+	return &rules, nil
+}
+
+func (r *Rules) validate() error {
+	if r.ReviewScore < 0 || r.BlockScore < 0 {
+		return fmt.Errorf("%w: scores must not be negative", ErrInvalidRules)
+	}
+
+	names := map[string]bool{}
+	for i, rule := range r.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("%w: rule %d has no name", ErrInvalidRules, i)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("%w: duplicate rule %q", ErrInvalidRules, rule.Name)
+		}
+		names[rule.Name] = true
+
+		switch rule.Action {
+		case "", OutcomeAllow, OutcomeReview, OutcomeBlock:
+		default:
+			return fmt.Errorf("%w: rule %q has unknown action %q", ErrInvalidRules, rule.Name, rule.Action)
+		}
+
+		if rule.Velocity != nil {
+			if err := rule.Velocity.validate(); err != nil {
+				return fmt.Errorf("%w: rule %q: %v", ErrInvalidRules, rule.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// binCountry returns the issuer country of the BIN, or "" when unknown.
+func (r *Rules) binCountry(bin string) string {
+	country, longest := "", 0
+	for prefix, c := range r.BINs {
+		if len(prefix) > longest && strings.HasPrefix(bin, prefix) {
+			country, longest = c, len(prefix)
+		}
+	}
+	return country
+}
+
+// MaxVelocityWindow is how far back the velocity counters must remember.
+func (r *Rules) MaxVelocityWindow() time.Duration {
+	var window time.Duration
+	for _, rule := range r.Rules {
+		if rule.Velocity != nil && rule.Velocity.Window > window {
+			window = rule.Velocity.Window
+		}
+	}
+	return window
+}
+
+// CheckVelocityRetention returns an error when a velocity window is longer
+// than the counters remember, which would undercount it.
+func (r *Rules) CheckVelocityRetention(retention time.Duration) error {
+	if window := r.MaxVelocityWindow(); window > retention {
+		return fmt.Errorf("%w: velocity window %s exceeds the retention of the counters (%s)", ErrInvalidRules, window, retention)
+	}
+	return nil
+}
+
+func (b Blocklists) matches(tx Transaction, country string) bool {
+	return slices.ContainsFunc(b.BINs, func(prefix string) bool { return strings.HasPrefix(tx.BIN, prefix) }) ||
+		(country != "" && slices.Contains(b.Countries, country)) ||
+		slices.Contains(b.CardFingerprints, tx.CardFingerprint)
+}
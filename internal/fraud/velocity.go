@@ -0,0 +1,81 @@
+package fraud
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// VelocityCounters keeps sliding-window counters of how cards are used.
+// Implementations only need to remember events as far back as the longest
+// velocity window of the rules.
+type VelocityCounters interface {
+	// RecordAttempt counts a payment attempt of the card at the merchant.
+	RecordAttempt(ctx context.Context, merchantID, cardFingerprint string, at time.Time) error
+	// RecordDecline counts a payment of the card declined by the bank.
+	RecordDecline(ctx context.Context, cardFingerprint string, at time.Time) error
+	// CardAttempts returns how many attempts were made with the card since
+	// the given instant.
+	CardAttempts(ctx context.Context, cardFingerprint string, since time.Time) (int, error)
+	// CardDeclines returns how many payments of the card were declined since
+	// the given instant.
+	CardDeclines(ctx context.Context, cardFingerprint string, since time.Time) (int, error)
+	// MerchantCards returns how many distinct cards were used at the
+	// merchant since the given instant.
+	MerchantCards(ctx context.Context, merchantID string, since time.Time) (int, error)
+}
+
+// VelocityCount is what a velocity rule counts.
+type VelocityCount string
+
+const (
+	// CountCardAttempts counts the attempts of the card, this one included.
+	// It is the default.
+	CountCardAttempts VelocityCount = "card_attempts"
+	// CountCardDeclines counts the payments of the card declined by the bank.
+	CountCardDeclines VelocityCount = "card_declines"
+	// CountMerchantCards counts the distinct cards used at the merchant,
+	// this one included.
+	CountMerchantCards VelocityCount = "merchant_cards"
+)
+
+// Velocity matches when the counter reached Max within Window.
+type Velocity struct {
+	Count  VelocityCount `yaml:"count"`
+	Max    int           `yaml:"max"`
+	Window time.Duration `yaml:"window"`
+}
+
+func (v Velocity) validate() error {
+	switch v.Count {
+	case "", CountCardAttempts, CountCardDeclines, CountMerchantCards:
+	default:
+		return fmt.Errorf("unknown velocity count %q", v.Count)
+	}
+
+	if v.Max <= 0 || v.Window <= 0 {
+		return fmt.Errorf("velocity needs a positive max and window")
+	}
+
+	return nil
+}
+
+// count reads the counter of the velocity for the transaction.
+func (v Velocity) count(ctx context.Context, counters VelocityCounters, tx Transaction, now time.Time) (int, error) {
+	since := now.Add(-v.Window)
+
+	switch v.Count {
+	case CountCardDeclines:
+		if tx.CardFingerprint == "" {
+			return 0, nil
+		}
+		return counters.CardDeclines(ctx, tx.CardFingerprint, since)
+	case CountMerchantCards:
+		return counters.MerchantCards(ctx, tx.MerchantID, since)
+	default:
+		if tx.CardFingerprint == "" {
+			return 0, nil
+		}
+		return counters.CardAttempts(ctx, tx.CardFingerprint, since)
+	}
+}
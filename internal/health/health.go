@@ -0,0 +1,162 @@
+// Package health reports whether the gateway can serve traffic, checking its
+// dependencies for the readiness probe.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout bounds each dependency check when none is configured.
+const DefaultTimeout = 2 * time.Second
+
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// Checker reports whether a dependency is usable, returning why it is not.
+type Checker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to a Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) CheckHealth(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Report is the outcome of a readiness check.
+type Report struct {
+	Status       Status                 `json:"status" example:"up"`                     // "up" when every dependency is up and the gateway is not shutting down.
+	ShuttingDown bool                   `json:"shutting_down,omitempty" example:"false"` // Set once graceful shutdown started.
+	Checks       map[string]CheckResult `json:"checks"`                                  // Outcome per dependency.
+}
+
+// CheckResult is the outcome of the check of a single dependency.
+type CheckResult struct {
+	Status     Status `json:"status" example:"up"`
+	Error      string `json:"error,omitempty"`         // Why the dependency is down.
+	DurationMS int64  `json:"duration_ms" example:"3"` // Time taken by the check.
+}
+
+type namedChecker struct {
+	name    string
+	checker Checker
+}
+
+// Readiness runs the dependency checks deciding whether traffic should be
+// routed to the gateway.
+type Readiness struct {
+	checkers     []namedChecker
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+// Option configures optional settings of the Readiness.
+type Option func(*Readiness)
+
+// WithCheck adds a dependency to check, reported under the given name.
+func WithCheck(name string, checker Checker) Option {
+	return func(r *Readiness) {
+		r.checkers = append(r.checkers, namedChecker{name: name, checker: checker})
+	}
+}
+
+// WithTimeout bounds each dependency check.
+func WithTimeout(timeout time.Duration) Option {
+	return func(r *Readiness) {
+		r.timeout = timeout
+	}
+}
+
+func NewReadiness(opts ...Option) *Readiness {
+	r := &Readiness{
+		timeout: DefaultTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// StartShutdown reports the gateway as not ready from now on, so load
+// balancers stop routing new traffic while in-flight requests complete.
+func (r *Readiness) StartShutdown() {
+	r.shuttingDown.Store(true)
+}
+
+// Check runs every dependency check concurrently.
+func (r *Readiness) Check(ctx context.Context) Report {
+	report := Report{
+		Status:       StatusUp,
+		ShuttingDown: r.shuttingDown.Load(),
+		Checks:       make(map[string]CheckResult, len(r.checkers)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range r.checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := r.check(ctx, c.checker)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = result
+		}()
+	}
+	wg.Wait()
+
+	if report.ShuttingDown {
+		report.Status = StatusDown
+	}
+	for _, result := range report.Checks {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	return report
+}
+
+func (r *Readiness) check(ctx context.Context, checker Checker) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// Checks ignoring the context, e.g. waiting on a lock, must not hang
+	// the probe.
+	done := make(chan error, 1)
+	go func() { done <- checker.CheckHealth(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Status:     StatusUp,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,88 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	up   = health.CheckerFunc(func(context.Context) error { return nil })
+	down = health.CheckerFunc(func(context.Context) error { return errors.New("connection refused") })
+	// hung ignores the context, like a check stuck on a lock.
+	hung = health.CheckerFunc(func(context.Context) error { select {} })
+)
+
+func TestReadiness_Check(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		opts   []health.Option
+		status health.Status
+		checks map[string]health.Status
+	}{
+		{
+			name:   "no checks",
+			status: health.StatusUp,
+			checks: map[string]health.Status{},
+		},
+		{
+			name:   "all up",
+			opts:   []health.Option{health.WithCheck("repository", up), health.WithCheck("bank", up)},
+			status: health.StatusUp,
+			checks: map[string]health.Status{"repository": health.StatusUp, "bank": health.StatusUp},
+		},
+		{
+			name:   "one down",
+			opts:   []health.Option{health.WithCheck("repository", up), health.WithCheck("bank", down)},
+			status: health.StatusDown,
+			checks: map[string]health.Status{"repository": health.StatusUp, "bank": health.StatusDown},
+		},
+		{
+			name: "timed out",
+			opts: []health.Option{
+				health.WithCheck("repository", hung),
+				health.WithTimeout(10 * time.Millisecond),
+			},
+			status: health.StatusDown,
+			checks: map[string]health.Status{"repository": health.StatusDown},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			report := health.NewReadiness(tt.opts...).Check(context.Background())
+
+			assert.Equal(t, tt.status, report.Status)
+			assert.False(t, report.ShuttingDown)
+			require.Len(t, report.Checks, len(tt.checks))
+			for name, status := range tt.checks {
+				assert.Equal(t, status, report.Checks[name].Status, name)
+				if status == health.StatusDown {
+					assert.NotEmpty(t, report.Checks[name].Error, name)
+				}
+			}
+		})
+	}
+}
+
+func TestReadiness_StartShutdown(t *testing.T) {
+	t.Parallel()
+
+	readiness := health.NewReadiness(health.WithCheck("bank", up))
+	readiness.StartShutdown()
+
+	report := readiness.Check(context.Background())
+
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.True(t, report.ShuttingDown)
+	assert.Equal(t, health.StatusUp, report.Checks["bank"].Status)
+}
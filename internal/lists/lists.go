@@ -0,0 +1,122 @@
+// Package lists lets the risk team block or always allow cards, BINs,
+// countries and merchant references without a deploy.
+package lists
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrEntryNotFound = errors.New("list entry not found")
+
+// InvalidEntryErr is returned when an entry is invalid.
+type InvalidEntryErr struct {
+	Field   string
+	Message string
+}
+
+func (e *InvalidEntryErr) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// List is the list an entry belongs to.
+type List string
+
+const (
+	// Blocklist entries block matching payments before they reach the bank.
+	Blocklist List = "block"
+	// Allowlist entries skip the fraud rules and the country and merchant
+	// reference blocklists. Only cards and BINs can be allowlisted, the
+	// other values are supplied by the caller.
+	Allowlist List = "allow"
+)
+
+// Kind is what the value of an entry is compared to.
+type Kind string
+
+const (
+	KindCardFingerprint   Kind = "card_fingerprint"   // card_fingerprint of the payment.
+	KindBIN               Kind = "bin"                // Prefix of the card number, 6 to 8 digits.
+	KindCountry           Kind = "country"            // Billing address country, ISO 3166-1 alpha-2.
+	KindMerchantReference Kind = "merchant_reference" // reference of the payment.
+)
+
+// Kinds are all the kinds of entries.
+var Kinds = []Kind{KindCardFingerprint, KindBIN, KindCountry, KindMerchantReference}
+
+// identifiesCard reports whether the kind is derived from the card number,
+// which the caller cannot pick freely.
+func (k Kind) identifiesCard() bool {
+	return k == KindCardFingerprint || k == KindBIN
+}
+
+// Entry is a value blocked or allowed by the risk team.
+type Entry struct {
+	ID    string `json:"id" example:"019ba901-48a1-7138-824e-d0e65a8dc38a"`
+	List  List   `json:"list" example:"block" enums:"block,allow"`
+	Kind  Kind   `json:"kind" example:"bin" enums:"card_fingerprint,bin,country,merchant_reference"`
+	Value string `json:"value" example:"411111"`
+	// MerchantID limits the entry to the payments of a merchant, every
+	// merchant when empty.
+	MerchantID string     `json:"merchant_id,omitempty" example:"merchant_123"`
+	Reason     string     `json:"reason" example:"Card testing attack reported by the issuer"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" example:"2026-02-15T10:00:00Z"` // When the entry stops applying, never when empty.
+	CreatedBy  string     `json:"created_by" example:"analyst@risk"`                   // Who added the entry.
+	CreatedAt  time.Time  `json:"created_at" example:"2026-01-15T10:00:00Z"`
+	UpdatedBy  string     `json:"updated_by,omitempty" example:"analyst@risk"` // Who last changed the entry.
+	UpdatedAt  time.Time  `json:"updated_at" example:"2026-01-15T10:00:00Z"`
+}
+
+// Active reports whether the entry applies at the given instant.
+func (e *Entry) Active(at time.Time) bool {
+	return e.ExpiresAt == nil || at.Before(*e.ExpiresAt)
+}
+
+// Key identifies the entries of a kind holding a value.
+type Key struct {
+	Kind  Kind
+	Value string
+}
+
+// EntriesQuery filters the entries returned by a listing. Empty fields
+// match every entry.
+type EntriesQuery struct {
+	List       List
+	Kind       Kind
+	Value      string
+	MerchantID string
+	// Active excludes the expired entries.
+	Active bool
+}
+
+// Store persists list entries.
+type Store interface {
+	AddEntry(ctx context.Context, entry *Entry) error
+	// GetEntry returns nil when the entry does not exist.
+	GetEntry(ctx context.Context, id string) (*Entry, error)
+	UpdateEntry(ctx context.Context, entry *Entry) error
+	DeleteEntry(ctx context.Context, id string) error
+	// ListEntries returns the entries matching the query, oldest first.
+	// Active entries are those not expired at the given instant.
+	ListEntries(ctx context.Context, query EntriesQuery, at time.Time) ([]*Entry, error)
+	// FindEntries returns the entries holding any of the keys and still
+	// active at the given instant.
+	FindEntries(ctx context.Context, keys []Key, at time.Time) ([]*Entry, error)
+}
+
+// Subject is what a payment exposes to the lists.
+type Subject struct {
+	MerchantID      string
+	CardFingerprint string
+	BIN             string // First 8 digits of the card number.
+	Country         string
+	Reference       string
+}
+
+// Matcher finds the entry applying to a payment.
+type Matcher interface {
+	// Match returns the entry applying to the subject, or nil. Card and BIN
+	// blocklist entries win over allowlist ones, which win over the others.
+	Match(ctx context.Context, subject Subject) (*Entry, error)
+}
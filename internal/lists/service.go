@@ -0,0 +1,248 @@
+package lists
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+)
+
+// Service manages list entries and matches payments against them.
+type Service struct {
+	store Store
+	clock clock.Clock
+}
+
+// ServiceOption configures optional dependencies of the Service.
+type ServiceOption func(*Service)
+
+// WithClock sets the clock used to stamp and expire entries.
+func WithClock(clk clock.Clock) ServiceOption {
+	return func(s *Service) {
+		s.clock = clk
+	}
+}
+
+func NewService(store Store, opts ...ServiceOption) *Service {
+	s := &Service{
+		store: store,
+		clock: clock.New(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// NewEntry holds the fields of an entry set by its author.
+type NewEntry struct {
+	List       List
+	Kind       Kind
+	Value      string
+	MerchantID string
+	Reason     string
+	ExpiresAt  *time.Time
+}
+
+// CreateEntry adds an entry on behalf of the actor.
+func (s *Service) CreateEntry(ctx context.Context, actor string, req NewEntry) (*Entry, error) {
+	if req.List != Blocklist && req.List != Allowlist {
+		return nil, &InvalidEntryErr{Field: "list", Message: "list must be one of: block, allow"}
+	}
+
+	value, err := normalize(req.Kind, req.Value)
+	if err != nil {
+		return nil, err
+	}
+	if req.List == Allowlist && !req.Kind.identifiesCard() {
+		return nil, &InvalidEntryErr{Field: "kind", Message: "allowlist entries must be card_fingerprint or bin"}
+	}
+
+	now := s.clock.Now().UTC()
+	if err := validateChange(actor, req.Reason, req.ExpiresAt, now); err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{
+		List:       req.List,
+		Kind:       req.Kind,
+		Value:      value,
+		MerchantID: req.MerchantID,
+		Reason:     req.Reason,
+		ExpiresAt:  req.ExpiresAt,
+		CreatedBy:  actor,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.store.AddEntry(ctx, entry); err != nil {
+		return nil, fmt.Errorf("persist entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+func (s *Service) GetEntry(ctx context.Context, id string) (*Entry, error) {
+	entry, err := s.store.GetEntry(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get entry: %w", err)
+	}
+
+	if entry == nil {
+		return nil, ErrEntryNotFound
+	}
+
+	return entry, nil
+}
+
+// UpdateEntry replaces the reason and expiry of an entry on behalf of the
+// actor. Other fields cannot change, a new entry must be added instead.
+func (s *Service) UpdateEntry(ctx context.Context, actor, id, reason string, expiresAt *time.Time) (*Entry, error) {
+	entry, err := s.GetEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now().UTC()
+	if err := validateChange(actor, reason, expiresAt, now); err != nil {
+		return nil, err
+	}
+
+	entry.Reason = reason
+	entry.ExpiresAt = expiresAt
+	entry.UpdatedBy = actor
+	entry.UpdatedAt = now
+
+	if err := s.store.UpdateEntry(ctx, entry); err != nil {
+		return nil, fmt.Errorf("persist entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+func (s *Service) DeleteEntry(ctx context.Context, id string) error {
+	if _, err := s.GetEntry(ctx, id); err != nil {
+		return err
+	}
+
+	return s.store.DeleteEntry(ctx, id)
+}
+
+// ListEntries returns the entries matching the query, oldest first.
+func (s *Service) ListEntries(ctx context.Context, query EntriesQuery) ([]*Entry, error) {
+	entries, err := s.store.ListEntries(ctx, query, s.clock.Now())
+	if err != nil {
+		return nil, fmt.Errorf("list entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Match returns the active entry applying to the subject, or nil.
+func (s *Service) Match(ctx context.Context, subject Subject) (*Entry, error) {
+	var keys []Key
+	if subject.CardFingerprint != "" {
+		keys = append(keys, Key{KindCardFingerprint, subject.CardFingerprint})
+	}
+	for n := 6; n <= min(8, len(subject.BIN)); n++ {
+		keys = append(keys, Key{KindBIN, subject.BIN[:n]})
+	}
+	if subject.Country != "" {
+		keys = append(keys, Key{KindCountry, strings.ToUpper(subject.Country)})
+	}
+	if subject.Reference != "" {
+		keys = append(keys, Key{KindMerchantReference, subject.Reference})
+	}
+
+	entries, err := s.store.FindEntries(ctx, keys, s.clock.Now())
+	if err != nil {
+		return nil, fmt.Errorf("find entries: %w", err)
+	}
+
+	// Countries and references are supplied by the caller, so they never
+	// allow a payment, and nothing allows a blocked card or BIN.
+	var allowed, blocked *Entry
+	for _, entry := range entries {
+		if entry.MerchantID != "" && entry.MerchantID != subject.MerchantID {
+			continue
+		}
+		switch {
+		case entry.List == Allowlist && !entry.Kind.identifiesCard():
+		case entry.List == Allowlist:
+			if allowed == nil {
+				allowed = entry
+			}
+		case entry.Kind.identifiesCard():
+			return entry, nil
+		case blocked == nil:
+			blocked = entry
+		}
+	}
+
+	if allowed != nil {
+		return allowed, nil
+	}
+	return blocked, nil
+}
+
+func validateChange(actor, reason string, expiresAt *time.Time, now time.Time) error {
+	if actor == "" {
+		return &InvalidEntryErr{Field: "actor", Message: "the author of the change must be identified"}
+	}
+	if strings.TrimSpace(reason) == "" {
+		return &InvalidEntryErr{Field: "reason", Message: "reason is required"}
+	}
+	if len(reason) > 500 {
+		return &InvalidEntryErr{Field: "reason", Message: "reason must be at most 500 characters"}
+	}
+	if expiresAt != nil && !expiresAt.After(now) {
+		return &InvalidEntryErr{Field: "expires_at", Message: "expires_at must be in the future"}
+	}
+	return nil
+}
+
+// normalize validates the value of an entry and puts it in the form it is
+// matched in.
+func normalize(kind Kind, value string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	switch kind {
+	case KindCardFingerprint:
+		value = strings.ToLower(value)
+		if b, err := hex.DecodeString(value); err != nil || len(b) != 32 {
+			return "", &InvalidEntryErr{Field: "value", Message: "card fingerprint must be 64 hexadecimal characters"}
+		}
+
+	case KindBIN:
+		if len(value) < 6 || len(value) > 8 || strings.Trim(value, "0123456789") != "" {
+			return "", &InvalidEntryErr{Field: "value", Message: "bin must be 6 to 8 digits"}
+		}
+
+	case KindCountry:
+		value = strings.ToUpper(value)
+		if len(value) != 2 || strings.Trim(value, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return "", &InvalidEntryErr{Field: "value", Message: "country must be an ISO 3166-1 alpha-2 code"}
+		}
+
+	case KindMerchantReference:
+		if value == "" || len(value) > 50 {
+			return "", &InvalidEntryErr{Field: "value", Message: "merchant reference must be 1 to 50 characters"}
+		}
+
+	default:
+		kinds := make([]string, len(Kinds))
+		for i, k := range Kinds {
+			kinds[i] = string(k)
+		}
+		slices.Sort(kinds)
+		return "", &InvalidEntryErr{Field: "kind", Message: "kind must be one of: " + strings.Join(kinds, ", ")}
+	}
+
+	return value, nil
+}
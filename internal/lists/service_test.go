@@ -0,0 +1,254 @@
+package lists_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/lists"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var listsNow = time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC)
+
+func newListsService() (*lists.Service, *clock.Fake) {
+	clk := clock.NewFake(listsNow)
+	return lists.NewService(repository.NewListsRepositoryInMemory(), lists.WithClock(clk)), clk
+}
+
+func TestService_CreateEntry(t *testing.T) {
+	t.Parallel()
+
+	past := listsNow.Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		actor         string
+		entry         lists.NewEntry
+		expectedValue string
+		expectedField string
+	}{
+		{
+			name:          "bin",
+			actor:         "analyst",
+			entry:         lists.NewEntry{List: lists.Blocklist, Kind: lists.KindBIN, Value: " 411111 ", Reason: "fraud"},
+			expectedValue: "411111",
+		},
+		{
+			name:          "country is upper cased",
+			actor:         "analyst",
+			entry:         lists.NewEntry{List: lists.Blocklist, Kind: lists.KindCountry, Value: "kp", Reason: "sanctions"},
+			expectedValue: "KP",
+		},
+		{
+			name:          "card fingerprint is lower cased",
+			actor:         "analyst",
+			entry:         lists.NewEntry{List: lists.Allowlist, Kind: lists.KindCardFingerprint, Value: strings.Repeat("AB", 32), Reason: "vip"},
+			expectedValue: strings.Repeat("ab", 32),
+		},
+		{
+			name:          "allowlisted country",
+			actor:         "analyst",
+			entry:         lists.NewEntry{List: lists.Allowlist, Kind: lists.KindCountry, Value: "GB", Reason: "trusted"},
+			expectedField: "kind",
+		},
+		{
+			name:          "allowlisted merchant reference",
+			actor:         "analyst",
+			entry:         lists.NewEntry{List: lists.Allowlist, Kind: lists.KindMerchantReference, Value: "ORD-1", Reason: "trusted"},
+			expectedField: "kind",
+		},
+		{
+			name:          "unknown list",
+			actor:         "analyst",
+			entry:         lists.NewEntry{List: "grey", Kind: lists.KindBIN, Value: "411111", Reason: "fraud"},
+			expectedField: "list",
+		},
+		{
+			name:          "unknown kind",
+			actor:         "analyst",
+			entry:         lists.NewEntry{List: lists.Blocklist, Kind: "email", Value: "a@b.c", Reason: "fraud"},
+			expectedField: "kind",
+		},
+		{
+			name:          "bin too short",
+			actor:         "analyst",
+			entry:         lists.NewEntry{List: lists.Blocklist, Kind: lists.KindBIN, Value: "4111", Reason: "fraud"},
+			expectedField: "value",
+		},
+		{
+			name:          "invalid card fingerprint",
+			actor:         "analyst",
+			entry:         lists.NewEntry{List: lists.Blocklist, Kind: lists.KindCardFingerprint, Value: "4111111111111111", Reason: "fraud"},
+			expectedField: "value",
+		},
+		{
+			name:          "missing reason",
+			actor:         "analyst",
+			entry:         lists.NewEntry{List: lists.Blocklist, Kind: lists.KindBIN, Value: "411111"},
+			expectedField: "reason",
+		},
+		{
+			name:          "expired",
+			actor:         "analyst",
+			entry:         lists.NewEntry{List: lists.Blocklist, Kind: lists.KindBIN, Value: "411111", Reason: "fraud", ExpiresAt: &past},
+			expectedField: "expires_at",
+		},
+		{
+			name:          "anonymous",
+			entry:         lists.NewEntry{List: lists.Blocklist, Kind: lists.KindBIN, Value: "411111", Reason: "fraud"},
+			expectedField: "actor",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			service, _ := newListsService()
+
+			entry, err := service.CreateEntry(context.Background(), tt.actor, tt.entry)
+
+			if tt.expectedField != "" {
+				var invalidEntryErr *lists.InvalidEntryErr
+				require.ErrorAs(t, err, &invalidEntryErr)
+				assert.Equal(t, tt.expectedField, invalidEntryErr.Field)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotEmpty(t, entry.ID)
+			assert.Equal(t, tt.expectedValue, entry.Value)
+			assert.Equal(t, tt.actor, entry.CreatedBy)
+			assert.Equal(t, listsNow, entry.CreatedAt)
+		})
+	}
+}
+
+func TestService_UpdateEntry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	service, clk := newListsService()
+
+	entry, err := service.CreateEntry(ctx, "analyst_a", lists.NewEntry{
+		List: lists.Blocklist, Kind: lists.KindBIN, Value: "411111", Reason: "fraud",
+	})
+	require.NoError(t, err)
+
+	clk.Advance(time.Hour)
+	expiresAt := clk.Now().Add(24 * time.Hour)
+	updated, err := service.UpdateEntry(ctx, "analyst_b", entry.ID, "confirmed fraud", &expiresAt)
+	require.NoError(t, err)
+
+	got, err := service.GetEntry(ctx, entry.ID)
+	require.NoError(t, err)
+	assert.Equal(t, updated, got)
+	assert.Equal(t, "confirmed fraud", got.Reason)
+	assert.Equal(t, "analyst_a", got.CreatedBy)
+	assert.Equal(t, "analyst_b", got.UpdatedBy)
+	assert.Equal(t, listsNow.Add(time.Hour), got.UpdatedAt)
+
+	_, err = service.UpdateEntry(ctx, "analyst_b", "unknown", "reason", nil)
+	require.ErrorIs(t, err, lists.ErrEntryNotFound)
+
+	require.NoError(t, service.DeleteEntry(ctx, entry.ID))
+	_, err = service.GetEntry(ctx, entry.ID)
+	require.ErrorIs(t, err, lists.ErrEntryNotFound)
+	require.ErrorIs(t, service.DeleteEntry(ctx, entry.ID), lists.ErrEntryNotFound)
+}
+
+func TestService_Match(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	service, clk := newListsService()
+
+	add := func(list lists.List, kind lists.Kind, value, merchantID string, ttl time.Duration) *lists.Entry {
+		entry := lists.NewEntry{List: list, Kind: kind, Value: value, MerchantID: merchantID, Reason: "test"}
+		if ttl > 0 {
+			expiresAt := listsNow.Add(ttl)
+			entry.ExpiresAt = &expiresAt
+		}
+		created, err := service.CreateEntry(ctx, "analyst", entry)
+		require.NoError(t, err)
+		return created
+	}
+
+	binBlock := add(lists.Blocklist, lists.KindBIN, "4111112", "", time.Hour)
+	refBlock := add(lists.Blocklist, lists.KindMerchantReference, "ORD-1", "merchant_a", 0)
+	countryBlock := add(lists.Blocklist, lists.KindCountry, "KP", "", 0)
+	cardAllow := add(lists.Allowlist, lists.KindCardFingerprint, strings.Repeat("ab", 32), "", 0)
+	cardBlock := add(lists.Blocklist, lists.KindCardFingerprint, strings.Repeat("cd", 32), "", 0)
+	binAllow := add(lists.Allowlist, lists.KindBIN, "555555", "", 0)
+
+	tests := []struct {
+		name     string
+		subject  lists.Subject
+		expected *lists.Entry
+	}{
+		{
+			name:    "no entry",
+			subject: lists.Subject{MerchantID: "merchant_a", BIN: "42424242", Country: "GB"},
+		},
+		{
+			name:     "bin prefix",
+			subject:  lists.Subject{MerchantID: "merchant_a", BIN: "41111122"},
+			expected: binBlock,
+		},
+		{
+			name:     "country is case insensitive",
+			subject:  lists.Subject{MerchantID: "merchant_a", BIN: "42424242", Country: "kp"},
+			expected: countryBlock,
+		},
+		{
+			name:     "reference of the merchant",
+			subject:  lists.Subject{MerchantID: "merchant_a", BIN: "42424242", Reference: "ORD-1"},
+			expected: refBlock,
+		},
+		{
+			name:    "reference of another merchant",
+			subject: lists.Subject{MerchantID: "merchant_b", BIN: "42424242", Reference: "ORD-1"},
+		},
+		{
+			name:     "allowlisted card wins over blocklisted country",
+			subject:  lists.Subject{MerchantID: "merchant_a", BIN: "42424242", Country: "KP", CardFingerprint: strings.Repeat("ab", 32)},
+			expected: cardAllow,
+		},
+		{
+			name:     "blocklisted BIN wins over allowlisted card",
+			subject:  lists.Subject{MerchantID: "merchant_a", BIN: "41111122", CardFingerprint: strings.Repeat("ab", 32)},
+			expected: binBlock,
+		},
+		{
+			name:     "allowlisted BIN",
+			subject:  lists.Subject{MerchantID: "merchant_a", BIN: "55555512", Country: "KP"},
+			expected: binAllow,
+		},
+		{
+			name:     "blocklisted card wins over allowlisted BIN",
+			subject:  lists.Subject{MerchantID: "merchant_a", BIN: "55555555", CardFingerprint: strings.Repeat("cd", 32)},
+			expected: cardBlock,
+		},
+	}
+
+	for _, tt := range tests {
+		entry, err := service.Match(ctx, tt.subject)
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.expected, entry, tt.name)
+	}
+
+	// Expired entries no longer match.
+	clk.Advance(time.Hour)
+	entry, err := service.Match(ctx, lists.Subject{MerchantID: "merchant_a", BIN: "41111122"})
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	active, err := service.ListEntries(ctx, lists.EntriesQuery{List: lists.Blocklist, Active: true})
+	require.NoError(t, err)
+	assert.Equal(t, []*lists.Entry{refBlock, countryBlock, cardBlock}, active)
+}
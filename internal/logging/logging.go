@@ -0,0 +1,72 @@
+// Package logging builds the structured logger of the gateway from its
+// configuration.
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+var (
+	ErrInvalidLevel  = errors.New("invalid log level")
+	ErrInvalidFormat = errors.New("invalid log format")
+)
+
+// Format is how log records are written.
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatText Format = "text"
+)
+
+// ParseLevel reads a level name such as "debug", "info", "warn" or "error",
+// in any case. Offsets like "info+2" are accepted too.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("%w %q", ErrInvalidLevel, name)
+	}
+	return level, nil
+}
+
+// LevelName formats the level the way it is configured, e.g. "info".
+func LevelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+// NewHandler returns the handler writing records in the given format. The
+// level is read on every record, so changing it takes effect immediately.
+func NewHandler(w io.Writer, format Format, level slog.Leveler) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch format {
+	case FormatJSON, "":
+		return slog.NewJSONHandler(w, opts), nil
+	case FormatText:
+		return slog.NewTextHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrInvalidFormat, format)
+	}
+}
+
+// OpenOutput returns where logs are written: "stdout", "stderr", or the path
+// of a file logs are appended to. The returned function closes the file.
+func OpenOutput(output string) (io.Writer, func() error, error) {
+	switch output {
+	case "stdout", "":
+		return os.Stdout, func() error { return nil }, nil
+	case "stderr":
+		return os.Stderr, func() error { return nil }, nil
+	}
+
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open log file: %w", err)
+	}
+	return f, f.Close, nil
+}
@@ -0,0 +1,103 @@
+package logging_test
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		want    slog.Level
+		wantErr error
+	}{
+		{name: "debug", want: slog.LevelDebug},
+		{name: "INFO", want: slog.LevelInfo},
+		{name: "warn", want: slog.LevelWarn},
+		{name: "error", want: slog.LevelError},
+		{name: "info+2", want: slog.LevelInfo + 2},
+		{name: "verbose", wantErr: logging.ErrInvalidLevel},
+		{name: "", wantErr: logging.ErrInvalidLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			level, err := logging.ParseLevel(tt.name)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, level)
+		})
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		format logging.Format
+		want   string
+	}{
+		{format: logging.FormatJSON, want: `"msg":"hello"`},
+		{format: logging.FormatText, want: `msg=hello`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			level := new(slog.LevelVar)
+			level.Set(slog.LevelWarn)
+
+			handler, err := logging.NewHandler(&buf, tt.format, level)
+			require.NoError(t, err)
+			logger := slog.New(handler)
+
+			logger.Info("hidden")
+			assert.Empty(t, buf.String())
+
+			level.Set(slog.LevelInfo)
+			logger.Info("hello")
+			assert.Contains(t, buf.String(), tt.want)
+		})
+	}
+}
+
+func TestNewHandler_InvalidFormat(t *testing.T) {
+	t.Parallel()
+
+	_, err := logging.NewHandler(&bytes.Buffer{}, "xml", slog.LevelInfo)
+	assert.ErrorIs(t, err, logging.ErrInvalidFormat)
+}
+
+func TestOpenOutput_File(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "api.log")
+	require.NoError(t, os.WriteFile(path, []byte("existing\n"), 0o644))
+
+	w, closeOutput, err := logging.OpenOutput(path)
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("appended\n"))
+	require.NoError(t, err)
+	require.NoError(t, closeOutput())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "existing\nappended\n", string(data))
+}
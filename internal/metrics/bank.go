@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+)
+
+// Bank decorates a bank client with latency histograms and in-flight gauges.
+type Bank struct {
+	next    simulator.BankingSimulator
+	metrics *Metrics
+}
+
+// InstrumentBank wraps the bank client so every call is measured.
+func (m *Metrics) InstrumentBank(next simulator.BankingSimulator) *Bank {
+	return &Bank{next: next, metrics: m}
+}
+
+func (b *Bank) Authorize(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+	done := b.start("authorize")
+
+	res, err := b.next.Authorize(ctx, req)
+
+	outcome := bankOutcome(ctx, err)
+	if err == nil {
+		outcome = "authorized"
+		if !res.Authorized {
+			outcome = "declined"
+		}
+	}
+	done(outcome)
+
+	return res, err
+}
+
+func (b *Bank) Void(ctx context.Context, authorizationCode string) error {
+	done := b.start("void")
+	err := b.next.Void(ctx, authorizationCode)
+	done(bankOutcome(ctx, err))
+	return err
+}
+
+func (b *Bank) Capture(ctx context.Context, authorizationCode string, amount int64) error {
+	done := b.start("capture")
+	err := b.next.Capture(ctx, authorizationCode, amount)
+	done(bankOutcome(ctx, err))
+	return err
+}
+
+// start counts the call as in flight and returns the function recording
+// its outcome.
+func (b *Bank) start(operation string) func(outcome string) {
+	inFlight := b.metrics.bankInFlight.WithLabelValues(operation)
+	inFlight.Inc()
+	start := time.Now()
+
+	return func(outcome string) {
+		inFlight.Dec()
+		b.metrics.bankDuration.WithLabelValues(operation, outcome).Observe(time.Since(start).Seconds())
+	}
+}
+
+// bankOutcome names the error returned by the bank client.
+func bankOutcome(ctx context.Context, err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case ctx.Err() != nil:
+		// The client does not wrap context errors, check the caller gave up.
+		return "cancelled"
+	case errors.Is(err, simulator.ErrAuthorizationRejected),
+		errors.Is(err, simulator.ErrVoidRejected),
+		errors.Is(err, simulator.ErrCaptureRejected):
+		return "rejected"
+	case errors.Is(err, simulator.ErrAuthorizationUnavailable):
+		return "unavailable"
+	case errors.Is(err, simulator.ErrAuthorizationUnexpected):
+		return "unexpected"
+	default:
+		return "internal"
+	}
+}
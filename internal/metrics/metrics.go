@@ -0,0 +1,144 @@
+// Package metrics exposes Prometheus metrics about HTTP traffic, the bank
+// integration and payments.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/sync/errgroup"
+)
+
+const namespace = "payment_gateway"
+
+// Metrics holds every collector of the gateway, registered on its own
+// registry so tests do not share state.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests     *prometheus.CounterVec
+	httpDuration     *prometheus.HistogramVec
+	httpInFlight     prometheus.Gauge
+	bankDuration     *prometheus.HistogramVec
+	bankInFlight     *prometheus.GaugeVec
+	paymentsByStatus *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests served, by method, route and status code.",
+		}, []string{"method", "route", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Time spent serving HTTP requests, by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		httpInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "HTTP requests being served.",
+		}),
+		bankDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "bank",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the calls to the acquiring bank, by operation and outcome.",
+			Buckets:   []float64{.025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"operation", "outcome"}),
+		bankInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "bank",
+			Name:      "requests_in_flight",
+			Help:      "Calls to the acquiring bank waiting for a response, by operation.",
+		}, []string{"operation"}),
+		paymentsByStatus: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "payments_total",
+			Help:      "Payments that reached a status, by status and currency.",
+		}, []string{"status", "currency"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.httpInFlight,
+		m.bankDuration,
+		m.bankInFlight,
+		m.paymentsByStatus,
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ServerTimeouts bound how long the metrics server waits on clients.
+type ServerTimeouts struct {
+	// ReadHeader bounds reading the request headers.
+	ReadHeader time.Duration
+	// Read bounds reading the whole request.
+	Read time.Duration
+	// Write bounds the time from the end of the request headers to the end
+	// of the response.
+	Write time.Duration
+	// Idle bounds how long a keep-alive connection waits for the next
+	// request.
+	Idle time.Duration
+}
+
+// Run serves the metrics on /metrics until the context is cancelled. It is
+// kept apart from the API so the metrics port is never exposed publicly.
+func (m *Metrics) Run(ctx context.Context, addr string, timeouts ServerTimeouts) error {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", m.Handler())
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		BaseContext:       func(_ net.Listener) context.Context { return ctx },
+		ReadHeaderTimeout: timeouts.ReadHeader,
+		ReadTimeout:       timeouts.Read,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		<-ctx.Done()
+		fmt.Printf("shutting down metrics server\n")
+		return httpServer.Shutdown(context.Background())
+	})
+
+	g.Go(func() error {
+		fmt.Printf("starting metrics server on %s\n", addr)
+		err := httpServer.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			return err
+		}
+
+		return nil
+	})
+
+	return g.Wait()
+}
@@ -0,0 +1,166 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/metrics"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBank struct {
+	authorizeRes *simulator.AuthorizationResponse
+	err          error
+}
+
+func (b *fakeBank) Authorize(context.Context, simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+	return b.authorizeRes, b.err
+}
+
+func (b *fakeBank) Void(context.Context, string) error {
+	return b.err
+}
+
+func (b *fakeBank) Capture(context.Context, string, int64) error {
+	return b.err
+}
+
+// scrape returns the metrics in the exposition format.
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	m := metrics.New()
+
+	r := chi.NewRouter()
+	r.Use(m.Middleware)
+	r.Get("/api/v1/payments/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	r.Get("/api/v1/payments/{id}/events", func(w http.ResponseWriter, _ *http.Request) {
+		_, ok := w.(http.Flusher)
+		assert.True(t, ok, "the wrapped writer must keep http.Flusher")
+	})
+
+	for _, path := range []string{"/api/v1/payments/a", "/api/v1/payments/b", "/api/v1/payments/a/events", "/unknown"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `payment_gateway_http_requests_total{code="404",method="GET",route="/api/v1/payments/{id}"} 2`)
+	assert.Contains(t, body, `payment_gateway_http_requests_total{code="200",method="GET",route="/api/v1/payments/{id}/events"} 1`)
+	assert.Contains(t, body, `payment_gateway_http_requests_total{code="404",method="GET",route="unmatched"} 1`)
+	assert.Contains(t, body, `payment_gateway_http_request_duration_seconds_count{method="GET",route="/api/v1/payments/{id}"} 2`)
+	assert.Contains(t, body, `payment_gateway_http_requests_in_flight 0`)
+}
+
+func TestInstrumentBank(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		bank    *fakeBank
+		call    func(b *metrics.Bank) error
+		outcome string
+	}{
+		{
+			name: "authorized",
+			bank: &fakeBank{authorizeRes: &simulator.AuthorizationResponse{Authorized: true}},
+			call: func(b *metrics.Bank) error {
+				_, err := b.Authorize(context.Background(), simulator.AuthorizationRequest{})
+				return err
+			},
+			outcome: `operation="authorize",outcome="authorized"`,
+		},
+		{
+			name: "declined",
+			bank: &fakeBank{authorizeRes: &simulator.AuthorizationResponse{Authorized: false}},
+			call: func(b *metrics.Bank) error {
+				_, err := b.Authorize(context.Background(), simulator.AuthorizationRequest{})
+				return err
+			},
+			outcome: `operation="authorize",outcome="declined"`,
+		},
+		{
+			name: "unavailable",
+			bank: &fakeBank{err: simulator.ErrAuthorizationUnavailable},
+			call: func(b *metrics.Bank) error {
+				_, err := b.Authorize(context.Background(), simulator.AuthorizationRequest{})
+				return err
+			},
+			outcome: `operation="authorize",outcome="unavailable"`,
+		},
+		{
+			name: "void rejected",
+			bank: &fakeBank{err: simulator.ErrVoidRejected},
+			call: func(b *metrics.Bank) error {
+				return b.Void(context.Background(), "code")
+			},
+			outcome: `operation="void",outcome="rejected"`,
+		},
+		{
+			name: "capture cancelled",
+			bank: &fakeBank{err: simulator.ErrCaptureInternal},
+			call: func(b *metrics.Bank) error {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return b.Capture(ctx, "code", 100)
+			},
+			outcome: `operation="capture",outcome="cancelled"`,
+		},
+		{
+			name: "capture",
+			bank: &fakeBank{},
+			call: func(b *metrics.Bank) error {
+				return b.Capture(context.Background(), "code", 100)
+			},
+			outcome: `operation="capture",outcome="success"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := metrics.New()
+			err := tt.call(m.InstrumentBank(tt.bank))
+			assert.ErrorIs(t, err, tt.bank.err)
+
+			body := scrape(t, m)
+			assert.Contains(t, body, `payment_gateway_bank_request_duration_seconds_count{`+tt.outcome+`} 1`)
+		})
+	}
+}
+
+func TestPaymentChanged(t *testing.T) {
+	t.Parallel()
+
+	m := metrics.New()
+	m.PaymentChanged(&payments.Payment{Status: payments.StatusAuthorized, Currency: "USD"})
+	m.PaymentChanged(&payments.Payment{Status: payments.StatusAuthorized, Currency: "USD"})
+	m.PaymentChanged(&payments.Payment{Status: payments.StatusDeclined, Currency: "EUR"})
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `payment_gateway_payments_total{currency="USD",status="authorized"} 2`)
+	assert.Contains(t, body, `payment_gateway_payments_total{currency="EUR",status="declined"} 1`)
+	assert.Contains(t, body, "go_goroutines")
+}
@@ -0,0 +1,11 @@
+package metrics
+
+import (
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+)
+
+// PaymentChanged counts the payment in its new status, implementing
+// payments.Observer.
+func (m *Metrics) PaymentChanged(payment *payments.Payment) {
+	m.paymentsByStatus.WithLabelValues(payment.Status.String(), payment.Currency).Inc()
+}
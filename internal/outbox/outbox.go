@@ -0,0 +1,66 @@
+// Package outbox relays the payment events recorded by the repository to
+// event publishers.
+//
+// Repositories write an outbox record in the same atomic write as the
+// payment change it describes, so an event can never be lost once a payment
+// is persisted. The Relay then publishes unpublished records, marking them
+// as published only once the publisher accepted them: delivery is
+// at-least-once, and records of a payment are published in the order they
+// were written. Each publisher keeps its own published state, so a failing
+// publisher neither holds back nor duplicates the others, and records
+// published by all of them are purged.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+)
+
+// Record is an event waiting to be, or already, published.
+type Record struct {
+	ID         string             `json:"id"`
+	Sequence   int64              `json:"sequence"` // Monotonic write order across all payments.
+	PaymentID  string             `json:"payment_id"`
+	MerchantID string             `json:"merchant_id"`
+	Type       payments.EventType `json:"type"`
+	Payload    json.RawMessage    `json:"payload"` // The payment as it was when the event occurred.
+	OccurredAt time.Time          `json:"occurred_at"`
+}
+
+// Store is implemented by repositories holding an outbox. Records are
+// tracked per publisher, identified by name.
+type Store interface {
+	// ListUnpublished returns up to limit records not yet published by the
+	// publisher, ordered by sequence.
+	ListUnpublished(ctx context.Context, publisher string, limit int) ([]*Record, error)
+	MarkPublished(ctx context.Context, publisher, id string, at time.Time) error
+	// PurgePublished deletes the records published by all the publishers
+	// before the given instant, and returns how many were deleted.
+	PurgePublished(ctx context.Context, publishers []string, before time.Time) (int, error)
+}
+
+// EventPublisher sends records to a downstream consumer. Publish must only
+// return nil once the record is safely handed over; records may be published
+// more than once, consumers deduplicate on Record.ID.
+type EventPublisher interface {
+	Publish(ctx context.Context, record *Record) error
+}
+
+// Publishers are the publishers records are relayed to, by name. The name
+// keys the published state of the publisher in the store, so it must stay
+// the same across restarts.
+type Publishers map[string]EventPublisher
+
+// names returns the names of the publishers, sorted.
+func (ps Publishers) names() []string {
+	names := make([]string, 0, len(ps))
+	for name := range ps {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,125 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ChannelPublisher hands records to in-process consumers through a channel.
+type ChannelPublisher struct {
+	records chan *Record
+}
+
+// NewChannelPublisher returns a publisher whose channel buffers up to size
+// records. Publish blocks while the buffer is full.
+func NewChannelPublisher(size int) *ChannelPublisher {
+	return &ChannelPublisher{records: make(chan *Record, size)}
+}
+
+// Records returns the channel consumers read from.
+func (p *ChannelPublisher) Records() <-chan *Record {
+	return p.records
+}
+
+func (p *ChannelPublisher) Publish(ctx context.Context, record *Record) error {
+	select {
+	case p.records <- record:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FilePublisher appends records to a file, one JSON document per line.
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFilePublisher opens, or creates, the NDJSON file records are appended to.
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open outbox file: %w", err)
+	}
+	return &FilePublisher{file: f}, nil
+}
+
+func (p *FilePublisher) Publish(_ context.Context, record *Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encode record: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write record: %w", err)
+	}
+
+	// The record only counts as published once it reached the disk.
+	return p.file.Sync()
+}
+
+func (p *FilePublisher) Close() error {
+	return p.file.Close()
+}
+
+// DefaultHTTPTimeout bounds each POST of the HTTP publisher unless it is
+// given its own client.
+const DefaultHTTPTimeout = 10 * time.Second
+
+// ErrSinkRejected is returned when the HTTP sink answers with a non 2xx status.
+var ErrSinkRejected = errors.New("event sink rejected the record")
+
+// HTTPPublisher POSTs each record as JSON to a sink URL.
+type HTTPPublisher struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPPublisher returns a publisher posting to url with client, or with
+// a client timing out after DefaultHTTPTimeout when it is nil, so a stalled
+// sink cannot hold the relay.
+func NewHTTPPublisher(url string, client *http.Client) *HTTPPublisher {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultHTTPTimeout}
+	}
+	return &HTTPPublisher{url: url, client: client}
+}
+
+func (p *HTTPPublisher) Publish(ctx context.Context, record *Record) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encode record: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", record.ID)
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post record: %w", err)
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("%w: status %d", ErrSinkRejected, res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package outbox_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/outbox"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/stretchr/testify/require"
+)
+
+func testRecord(id string) *outbox.Record {
+	return &outbox.Record{
+		ID:         id,
+		Sequence:   1,
+		PaymentID:  "pay_1",
+		MerchantID: "merchant_a",
+		Type:       payments.EventPaymentAuthorized,
+		Payload:    json.RawMessage(`{"id":"pay_1"}`),
+		OccurredAt: relayNow,
+	}
+}
+
+func TestChannelPublisher(t *testing.T) {
+	t.Parallel()
+
+	publisher := outbox.NewChannelPublisher(1)
+	require.NoError(t, publisher.Publish(context.Background(), testRecord("rec_1")))
+	require.Equal(t, "rec_1", (<-publisher.Records()).ID)
+
+	// A full buffer blocks until the context is done.
+	require.NoError(t, publisher.Publish(context.Background(), testRecord("rec_2")))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, publisher.Publish(ctx, testRecord("rec_3")), context.Canceled)
+}
+
+func TestFilePublisher(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	publisher, err := outbox.NewFilePublisher(path)
+	require.NoError(t, err)
+	require.NoError(t, publisher.Publish(context.Background(), testRecord("rec_1")))
+	require.NoError(t, publisher.Publish(context.Background(), testRecord("rec_2")))
+	require.NoError(t, publisher.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record outbox.Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		ids = append(ids, record.ID)
+	}
+	require.Equal(t, []string{"rec_1", "rec_2"}, ids)
+}
+
+func TestHTTPPublisher(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "accepted", status: http.StatusAccepted},
+		{name: "rejected", status: http.StatusInternalServerError, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "rec_1", r.Header.Get("Idempotency-Key"))
+
+				var record outbox.Record
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&record))
+				require.Equal(t, payments.EventPaymentAuthorized, record.Type)
+
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := outbox.NewHTTPPublisher(server.URL, server.Client()).Publish(context.Background(), testRecord("rec_1"))
+			if tt.wantErr {
+				require.ErrorIs(t, err, outbox.ErrSinkRejected)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+)
+
+const (
+	defaultBatchSize = 100
+	defaultRetention = time.Hour
+)
+
+// Relay moves records from the outbox store to publishers.
+type Relay struct {
+	store      Store
+	publishers Publishers
+	clock      clock.Clock
+	batchSize  int
+	retention  time.Duration
+}
+
+// Option configures optional dependencies of the Relay.
+type Option func(*Relay)
+
+// WithClock sets the clock used to stamp published records.
+func WithClock(clk clock.Clock) Option {
+	return func(r *Relay) {
+		r.clock = clk
+	}
+}
+
+// WithBatchSize sets how many records are read from the store at once, per
+// publisher.
+func WithBatchSize(n int) Option {
+	return func(r *Relay) {
+		r.batchSize = n
+	}
+}
+
+// WithRetention sets how long records are kept once published by all the
+// publishers.
+func WithRetention(retention time.Duration) Option {
+	return func(r *Relay) {
+		r.retention = retention
+	}
+}
+
+func NewRelay(store Store, publishers Publishers, opts ...Option) *Relay {
+	r := &Relay{
+		store:      store,
+		publishers: publishers,
+		clock:      clock.New(),
+		batchSize:  defaultBatchSize,
+		retention:  defaultRetention,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Run relays records, then purges those published by all the publishers,
+// every interval until the context is cancelled.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := r.RelayPending(ctx); err != nil {
+				slog.Error("relaying outbox", "error", err)
+			}
+			if _, err := r.Purge(ctx); err != nil {
+				slog.Error("purging outbox", "error", err)
+			}
+		}
+	}
+}
+
+// RelayPending publishes a batch of unpublished records to each publisher
+// and returns how many publications succeeded. Publishers are relayed
+// independently: one failing does not hold back the others, nor makes them
+// publish records again.
+func (r *Relay) RelayPending(ctx context.Context) (int, error) {
+	published := 0
+	var errs []error
+
+	for _, name := range r.publishers.names() {
+		n, err := r.relayTo(ctx, name, r.publishers[name])
+		published += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("publisher %s: %w", name, err))
+		}
+	}
+
+	return published, errors.Join(errs...)
+}
+
+// relayTo publishes a batch of the records not yet published by the
+// publisher. When a record fails to publish, the following records of the
+// same payment are held back until it succeeds, keeping them in order;
+// records of other payments are not affected.
+func (r *Relay) relayTo(ctx context.Context, name string, publisher EventPublisher) (int, error) {
+	records, err := r.store.ListUnpublished(ctx, name, r.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("list unpublished records: %w", err)
+	}
+
+	published := 0
+	blocked := map[string]bool{}
+
+	for _, record := range records {
+		if blocked[record.PaymentID] {
+			continue
+		}
+
+		if err := publisher.Publish(ctx, record); err != nil {
+			blocked[record.PaymentID] = true
+			slog.WarnContext(ctx, "publishing outbox record",
+				"publisher", name,
+				"record_id", record.ID,
+				"payment_id", record.PaymentID,
+				"error", err,
+			)
+			continue
+		}
+
+		// A crash before this point publishes the record again on restart.
+		if err := r.store.MarkPublished(ctx, name, record.ID, r.clock.Now().UTC()); err != nil {
+			return published, fmt.Errorf("mark record %s published: %w", record.ID, err)
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+// Purge deletes the records published by all the publishers longer than
+// the retention ago, and returns how many were deleted.
+func (r *Relay) Purge(ctx context.Context) (int, error) {
+	purged, err := r.store.PurgePublished(ctx, r.publishers.names(), r.clock.Now().UTC().Add(-r.retention))
+	if err != nil {
+		return 0, fmt.Errorf("purge published records: %w", err)
+	}
+	return purged, nil
+}
@@ -0,0 +1,167 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/outbox"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+var relayNow = time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC)
+
+type recordingPublisher struct {
+	failFor   map[string]bool // payment IDs whose records fail to publish
+	published []*outbox.Record
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, record *outbox.Record) error {
+	if p.failFor[record.PaymentID] {
+		return errors.New("sink unavailable")
+	}
+	p.published = append(p.published, record)
+	return nil
+}
+
+func addPayments(t *testing.T, repo *repository.PaymentsRepositoryInMemory) (first, second *payments.Payment) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	first = &payments.Payment{MerchantID: "merchant_a", Status: payments.StatusRequiresAction}
+	require.NoError(t, repo.AddPayment(ctx, first))
+
+	second = &payments.Payment{MerchantID: "merchant_a", Status: payments.StatusAuthorized}
+	require.NoError(t, repo.AddPayment(ctx, second))
+
+	first.Status = payments.StatusAuthorized
+	require.NoError(t, repo.UpdatePayment(ctx, first))
+
+	return first, second
+}
+
+func TestRelay_RelayPending(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.NewPaymentsRepositoryInMemory()
+	first, second := addPayments(t, repo)
+
+	publisher := &recordingPublisher{}
+	relay := outbox.NewRelay(repo, outbox.Publishers{"sink": publisher}, outbox.WithClock(clock.NewFake(relayNow)))
+
+	n, err := relay.RelayPending(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	require.Len(t, publisher.published, 3)
+	require.Equal(t, first.ID, publisher.published[0].PaymentID)
+	require.Equal(t, payments.EventPaymentRequiresAction, publisher.published[0].Type)
+	require.Equal(t, second.ID, publisher.published[1].PaymentID)
+	require.Equal(t, first.ID, publisher.published[2].PaymentID)
+	require.Equal(t, payments.EventPaymentAuthorized, publisher.published[2].Type)
+
+	n, err = relay.RelayPending(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestRelay_RelayPending_KeepsOrderPerPaymentOnFailure(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.NewPaymentsRepositoryInMemory()
+	first, second := addPayments(t, repo)
+
+	publisher := &recordingPublisher{failFor: map[string]bool{first.ID: true}}
+	relay := outbox.NewRelay(repo, outbox.Publishers{"sink": publisher})
+
+	n, err := relay.RelayPending(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Len(t, publisher.published, 1)
+	require.Equal(t, second.ID, publisher.published[0].PaymentID)
+
+	// Once the sink recovers, the records of the first payment are
+	// published in the order they were written.
+	publisher.failFor = nil
+
+	n, err = relay.RelayPending(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, payments.EventPaymentRequiresAction, publisher.published[1].Type)
+	require.Equal(t, payments.EventPaymentAuthorized, publisher.published[2].Type)
+	require.Less(t, publisher.published[1].Sequence, publisher.published[2].Sequence)
+}
+
+func TestRelay_RelayPending_PublishersAreIndependent(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.NewPaymentsRepositoryInMemory()
+	first, _ := addPayments(t, repo)
+
+	healthy := &recordingPublisher{}
+	failing := &recordingPublisher{failFor: map[string]bool{first.ID: true}}
+	relay := outbox.NewRelay(repo, outbox.Publishers{"healthy": healthy, "failing": failing})
+
+	n, err := relay.RelayPending(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	require.Len(t, healthy.published, 3, "a failing publisher must not hold back the others")
+	require.Len(t, failing.published, 1)
+
+	failing.failFor = nil
+
+	n, err = relay.RelayPending(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Len(t, healthy.published, 3, "records must not be published again to the publishers that accepted them")
+	require.Len(t, failing.published, 3)
+}
+
+func TestRelay_Purge(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(relayNow)
+	repo := repository.NewPaymentsRepositoryInMemory()
+	first, _ := addPayments(t, repo)
+
+	failing := &recordingPublisher{failFor: map[string]bool{first.ID: true}}
+	relay := outbox.NewRelay(repo,
+		outbox.Publishers{"healthy": &recordingPublisher{}, "failing": failing},
+		outbox.WithClock(clk),
+		outbox.WithRetention(time.Hour),
+	)
+
+	_, err := relay.RelayPending(context.Background())
+	require.NoError(t, err)
+
+	clk.Advance(2 * time.Hour)
+
+	// The first record is still waiting for a publisher, so nothing after
+	// it is purged either.
+	n, err := relay.Purge(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	failing.failFor = nil
+	_, err = relay.RelayPending(context.Background())
+	require.NoError(t, err)
+
+	n, err = relay.Purge(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, n, "records are kept for the retention once published")
+
+	clk.Advance(2 * time.Hour)
+
+	n, err = relay.Purge(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	records, err := repo.ListUnpublished(context.Background(), "failing", 0)
+	require.NoError(t, err)
+	require.Empty(t, records)
+}
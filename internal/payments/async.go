@@ -0,0 +1,308 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+)
+
+// ErrQueueFull is returned when too many payments are waiting for
+// authorization to accept another one asynchronously.
+var ErrQueueFull = errors.New("authorization queue is full")
+
+// DefaultAuthorizationRetryDelay is how long a payment waits before being
+// authorized again when the bank is unavailable.
+const DefaultAuthorizationRetryDelay = 5 * time.Second
+
+// PendingAuthorizationsRepository persists payments accepted asynchronously
+// together with their sealed authorization request, so authorizations
+// survive a restart.
+type PendingAuthorizationsRepository interface {
+	// AddPendingPayment stores the payment and its sealed request atomically.
+	AddPendingPayment(ctx context.Context, payment *Payment, sealedRequest []byte) error
+	// GetPendingAuthorization returns the sealed request of the payment, or
+	// nil once the authorization completed.
+	GetPendingAuthorization(ctx context.Context, paymentID string) ([]byte, error)
+	// ListPendingAuthorizations returns the IDs of the payments still
+	// waiting for authorization, oldest first.
+	ListPendingAuthorizations(ctx context.Context) ([]string, error)
+	// CompletePendingAuthorization updates the payment and discards its
+	// sealed request atomically.
+	CompletePendingAuthorization(ctx context.Context, payment *Payment) error
+}
+
+// AsyncPolicy configures asynchronous payment creation.
+type AsyncPolicy struct {
+	// QueueSize bounds how many payments can wait for authorization.
+	// Payments beyond it are refused with ErrQueueFull.
+	QueueSize int
+	// RetryDelay is how long to wait before retrying when the bank is
+	// unavailable.
+	RetryDelay time.Duration
+}
+
+type asyncAuthorizer struct {
+	store  PendingAuthorizationsRepository
+	sealer *CardSealer
+	policy AsyncPolicy
+
+	// slots holds a token for every payment accepted and not yet completed,
+	// queue the IDs of the payments ready to be authorized. Both have the
+	// same capacity so sending to queue while holding a slot never blocks.
+	slots chan struct{}
+	queue chan string
+
+	// queued tracks the payments in the queue or being authorized, so the
+	// resumption never queues a payment twice.
+	mu     sync.Mutex
+	queued map[string]bool
+}
+
+// claim marks the payment as queued, reporting false if it already was.
+func (a *asyncAuthorizer) claim(paymentID string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.queued[paymentID] {
+		return false
+	}
+	a.queued[paymentID] = true
+	return true
+}
+
+// release frees the queue slot of a payment that left the queue for good.
+func (a *asyncAuthorizer) release(paymentID string) {
+	a.mu.Lock()
+	delete(a.queued, paymentID)
+	a.mu.Unlock()
+
+	<-a.slots
+}
+
+// WithAsyncAuthorization enables CreatePaymentAsync. Without it payments are
+// always authorized synchronously.
+func WithAsyncAuthorization(store PendingAuthorizationsRepository, sealer *CardSealer, policy AsyncPolicy) Option {
+	return func(s *Service) {
+		if policy.RetryDelay <= 0 {
+			policy.RetryDelay = DefaultAuthorizationRetryDelay
+		}
+
+		s.async = &asyncAuthorizer{
+			store:  store,
+			sealer: sealer,
+			policy: policy,
+			slots:  make(chan struct{}, policy.QueueSize),
+			queue:  make(chan string, policy.QueueSize),
+			queued: map[string]bool{},
+		}
+	}
+}
+
+// CreatePaymentAsync validates and persists the payment as pending, leaving
+// its authorization to the workers started by RunAuthorizationWorkers.
+// Payments needing cardholder authentication, and every payment when async
+// authorization is not enabled, are processed synchronously instead.
+func (s *Service) CreatePaymentAsync(ctx context.Context, paymentReq PaymentRequest) (payment *Payment, err error) {
+	if s.async == nil || s.requiresAuthentication(paymentReq) {
+		return s.CreatePayment(ctx, paymentReq)
+	}
+
+	ctx, span := tracer.Start(ctx, "payments.Service.CreatePaymentAsync", paymentRequestAttrs(paymentReq))
+	defer func() {
+		if err == nil {
+			auditChange(ctx, payment, "")
+		}
+		endSpan(span, payment, err)
+	}()
+
+	if err := s.validate(paymentReq); err != nil {
+		return nil, err
+	}
+
+	payment = s.newPayment(paymentReq)
+
+	blocked, err := s.screen(ctx, payment, paymentReq)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return payment, nil
+	}
+
+	select {
+	case s.async.slots <- struct{}{}:
+	default:
+		return nil, ErrQueueFull
+	}
+
+	if err := s.enqueue(ctx, payment, paymentReq); err != nil {
+		<-s.async.slots
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+func (s *Service) enqueue(ctx context.Context, payment *Payment, paymentReq PaymentRequest) error {
+	sealed, err := s.async.sealer.Seal(newAuthorizationRequest(paymentReq))
+	if err != nil {
+		return fmt.Errorf("seal authorization request: %w", err)
+	}
+
+	if err := s.async.store.AddPendingPayment(ctx, payment, sealed); err != nil {
+		return fmt.Errorf("persist payment: %w", err)
+	}
+	s.notify(payment)
+
+	if s.async.claim(payment.ID) {
+		s.async.queue <- payment.ID
+	} else {
+		// Already picked up by the resumption, which holds its own slot.
+		<-s.async.slots
+	}
+
+	return nil
+}
+
+// RunAuthorizationWorkers authorizes asynchronously created payments with
+// the given number of concurrent workers until the context is cancelled.
+// Payments left pending by a previous run are resumed first.
+func (s *Service) RunAuthorizationWorkers(ctx context.Context, workers int) error {
+	if s.async == nil {
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.authorizationWorker(ctx)
+		}()
+	}
+
+	if err := s.resumeAuthorizations(ctx); err != nil {
+		slog.ErrorContext(ctx, "resuming pending authorizations", "error", err)
+	}
+
+	wg.Wait()
+	return nil
+}
+
+// resumeAuthorizations queues the payments persisted before a restart,
+// waiting for room in the queue when there are more than it can hold.
+func (s *Service) resumeAuthorizations(ctx context.Context) error {
+	ids, err := s.async.store.ListPendingAuthorizations(ctx)
+	if err != nil {
+		return fmt.Errorf("list pending authorizations: %w", err)
+	}
+
+	resumed := 0
+	for _, id := range ids {
+		select {
+		case s.async.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+
+		if !s.async.claim(id) {
+			// Accepted after the workers started, already queued.
+			<-s.async.slots
+			continue
+		}
+
+		s.async.queue <- id
+		resumed++
+	}
+
+	if resumed > 0 {
+		slog.InfoContext(ctx, "resumed pending authorizations", "count", resumed)
+	}
+
+	return nil
+}
+
+func (s *Service) authorizationWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-s.async.queue:
+			// Shutdown stops the workers from taking new payments, the one
+			// being authorized is left to Drain.
+			err := s.authorizePending(context.WithoutCancel(ctx), id)
+			if errors.Is(err, simulator.ErrAuthorizationUnavailable) {
+				// Keep the slot and try again later.
+				time.AfterFunc(s.async.policy.RetryDelay, func() { s.async.queue <- id })
+				continue
+			}
+			if err != nil {
+				// The payment stays pending and is resumed on the next start.
+				slog.ErrorContext(ctx, "authorizing pending payment", "payment_id", id, "error", err)
+			}
+			s.async.release(id)
+		}
+	}
+}
+
+// authorizePending sends a pending payment to the bank and records the
+// outcome.
+func (s *Service) authorizePending(ctx context.Context, paymentID string) error {
+	ctx, done := s.inFlight.track(ctx)
+	defer done()
+
+	sealed, err := s.async.store.GetPendingAuthorization(ctx, paymentID)
+	if err != nil {
+		return fmt.Errorf("get pending authorization: %w", err)
+	}
+	if sealed == nil {
+		return nil // already completed
+	}
+
+	payment, err := s.GetPayment(ctx, paymentID)
+	if err != nil {
+		return err
+	}
+	before := payment.Status
+
+	authReq, err := s.async.sealer.Open(sealed)
+	if err != nil {
+		// The card data is lost, typically because the sealing key changed
+		// across a restart: the payment can never be authorized.
+		slog.WarnContext(ctx, "rejecting pending payment", "payment_id", paymentID, "error", err)
+		payment.Status = StatusRejected
+		payment.UpdatedAt = s.clock.Now().UTC()
+	} else {
+		// Nothing was sent to the bank yet, the payment is resumed later.
+		if err := notSent(ctx); err != nil {
+			return err
+		}
+
+		err := s.authorize(ctx, payment, authReq)
+		if errors.Is(err, errSoftDeclined) {
+			// The cardholder is not around to complete a challenge.
+			payment.Status = StatusDeclined
+			payment.UpdatedAt = s.clock.Now().UTC()
+		} else if interrupted(ctx, err) {
+			// Completing it discards the card data, so it is not authorized
+			// again on the next start.
+			s.holdForReconciliation(payment)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if err := s.async.store.CompletePendingAuthorization(context.WithoutCancel(ctx), payment); err != nil {
+		return fmt.Errorf("persist payment: %w", err)
+	}
+	warnReconciliation(ctx, payment)
+	s.notify(payment)
+	s.auditSystemChange(ctx, "authorize pending payment", payment, before)
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package payments_test
+
+import (
+	"bytes"
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+var testSealingKey = bytes.Repeat([]byte{0x42}, 32)
+
+func newTestSealer(t *testing.T) *payments.CardSealer {
+	t.Helper()
+
+	sealer, err := payments.NewCardSealer(testSealingKey)
+	require.NoError(t, err)
+	return sealer
+}
+
+func newAsyncService(
+	t *testing.T,
+	repo *repository.PaymentsRepositoryInMemory,
+	bank simulator.BankingSimulator,
+	queueSize int,
+) *payments.Service {
+	t.Helper()
+
+	return newTestService(repo, bank, payments.WithAsyncAuthorization(repo, newTestSealer(t), payments.AsyncPolicy{
+		QueueSize:  queueSize,
+		RetryDelay: 10 * time.Millisecond,
+	}))
+}
+
+func runWorkers(t *testing.T, service *payments.Service, workers int) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.RunAuthorizationWorkers(ctx, workers)
+		close(done)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func requireEventuallyStatus(t *testing.T, repo *repository.PaymentsRepositoryInMemory, id string, status payments.PaymentStatus) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		p, err := repo.GetPayment(context.Background(), id)
+		return err == nil && p.Status == status
+	}, time.Second, 5*time.Millisecond)
+}
+
+func authorizingBank() *mockBankingSimulator {
+	return &mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			return &simulator.AuthorizationResponse{Authorized: true, AuthorizationCode: "AUTH123"}, nil
+		},
+	}
+}
+
+func TestService_CreatePaymentAsync(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.NewPaymentsRepositoryInMemory()
+	service := newAsyncService(t, repo, authorizingBank(), 10)
+
+	payment, err := service.CreatePaymentAsync(context.Background(), validPaymentRequest())
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusPending, payment.Status)
+	require.NotEmpty(t, payment.ID)
+
+	runWorkers(t, service, 2)
+
+	requireEventuallyStatus(t, repo, payment.ID, payments.StatusAuthorized)
+
+	sealed, err := repo.GetPendingAuthorization(context.Background(), payment.ID)
+	require.NoError(t, err)
+	require.Nil(t, sealed, "card data must be discarded once authorized")
+}
+
+func TestService_CreatePaymentAsync_QueueFull(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.NewPaymentsRepositoryInMemory()
+	service := newAsyncService(t, repo, authorizingBank(), 1)
+
+	_, err := service.CreatePaymentAsync(context.Background(), validPaymentRequest())
+	require.NoError(t, err)
+
+	_, err = service.CreatePaymentAsync(context.Background(), validPaymentRequest())
+	require.ErrorIs(t, err, payments.ErrQueueFull)
+
+	found, err := repo.SearchPayments(context.Background(), payments.PaymentsQuery{AllMerchants: true})
+	require.NoError(t, err)
+	require.Len(t, found, 1, "refused payments must not be persisted")
+}
+
+func TestService_CreatePaymentAsync_ValidationError(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.NewPaymentsRepositoryInMemory()
+	service := newAsyncService(t, repo, authorizingBank(), 1)
+
+	req := validPaymentRequest()
+	req.CVV = "1"
+
+	_, err := service.CreatePaymentAsync(context.Background(), req)
+
+	var invalidErr *payments.InvalidPaymentRequestErr
+	require.ErrorAs(t, err, &invalidErr)
+
+	// The refused payment did not take the only queue slot.
+	_, err = service.CreatePaymentAsync(context.Background(), validPaymentRequest())
+	require.NoError(t, err)
+}
+
+func TestService_CreatePaymentAsync_Disabled(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.NewPaymentsRepositoryInMemory()
+	service := newTestService(repo, authorizingBank())
+
+	payment, err := service.CreatePaymentAsync(context.Background(), validPaymentRequest())
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusAuthorized, payment.Status)
+}
+
+func TestService_RunAuthorizationWorkers_ResumesPendingPayments(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.NewPaymentsRepositoryInMemory()
+
+	// A payment accepted before a restart, with its sealed request.
+	sealed, err := newTestSealer(t).Seal(simulator.AuthorizationRequest{
+		CardNumber: "4111111111111111",
+		ExpiryDate: "02/2026",
+		Currency:   "USD",
+		Amount:     1000,
+		CVV:        "123",
+	})
+	require.NoError(t, err)
+
+	pending := &payments.Payment{MerchantID: "merchant_a", Status: payments.StatusPending}
+	require.NoError(t, repo.AddPendingPayment(context.Background(), pending, sealed))
+
+	var calls atomic.Int32
+	bank := &mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			calls.Add(1)
+			require.Equal(t, "4111111111111111", req.CardNumber)
+			return &simulator.AuthorizationResponse{Authorized: true}, nil
+		},
+	}
+
+	service := newAsyncService(t, repo, bank, 10)
+	runWorkers(t, service, 1)
+
+	requireEventuallyStatus(t, repo, pending.ID, payments.StatusAuthorized)
+	require.EqualValues(t, 1, calls.Load())
+}
+
+func TestService_RunAuthorizationWorkers_RetriesWhenBankUnavailable(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	bank := &mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			if calls.Add(1) < 3 {
+				return nil, simulator.ErrAuthorizationUnavailable
+			}
+			return &simulator.AuthorizationResponse{Authorized: false}, nil
+		},
+	}
+
+	repo := repository.NewPaymentsRepositoryInMemory()
+	service := newAsyncService(t, repo, bank, 10)
+	runWorkers(t, service, 1)
+
+	payment, err := service.CreatePaymentAsync(context.Background(), validPaymentRequest())
+	require.NoError(t, err)
+
+	requireEventuallyStatus(t, repo, payment.ID, payments.StatusDeclined)
+	require.EqualValues(t, 3, calls.Load())
+}
+
+func TestService_RunAuthorizationWorkers_RejectsUnsealablePayments(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.NewPaymentsRepositoryInMemory()
+
+	// Sealed with a key that was rotated away.
+	otherSealer, err := payments.NewCardSealer(bytes.Repeat([]byte{0x24}, 32))
+	require.NoError(t, err)
+	sealed, err := otherSealer.Seal(simulator.AuthorizationRequest{CardNumber: "4111111111111111"})
+	require.NoError(t, err)
+
+	pending := &payments.Payment{MerchantID: "merchant_a", Status: payments.StatusPending}
+	require.NoError(t, repo.AddPendingPayment(context.Background(), pending, sealed))
+
+	service := newAsyncService(t, repo, &mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			t.Error("the bank must not be called without card data")
+			return nil, nil
+		},
+	}, 10)
+	runWorkers(t, service, 1)
+
+	requireEventuallyStatus(t, repo, pending.ID, payments.StatusRejected)
+}
+
+func TestCardSealer(t *testing.T) {
+	t.Parallel()
+
+	sealer := newTestSealer(t)
+	req := simulator.AuthorizationRequest{CardNumber: "4111111111111111", CVV: "123"}
+
+	sealed, err := sealer.Seal(req)
+	require.NoError(t, err)
+	require.NotContains(t, string(sealed), "4111111111111111")
+
+	opened, err := sealer.Open(sealed)
+	require.NoError(t, err)
+	require.Equal(t, req, opened)
+
+	sealed[len(sealed)-1] ^= 0xff
+	_, err = sealer.Open(sealed)
+	require.ErrorIs(t, err, payments.ErrUnsealable)
+}
@@ -0,0 +1,298 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/threeds"
+)
+
+// NextActionRedirect asks the client to redirect the cardholder to a URL.
+const NextActionRedirect = "redirect_to_url"
+
+// DefaultAuthenticationTTL is how long the cardholder has to complete a
+// challenge unless WithAuthenticationTTL is given.
+const DefaultAuthenticationTTL = 15 * time.Minute
+
+// ErrAuthenticationNotFound is returned when a 3-D Secure callback does not
+// match any payment waiting for authentication.
+var ErrAuthenticationNotFound = errors.New("authentication not found")
+
+// SCAPolicy decides which payments must go through strong customer
+// authentication before being sent to the bank.
+type SCAPolicy struct {
+	// Required applies to merchants without an explicit setting.
+	Required bool
+	// Merchants overrides Required per merchant.
+	Merchants map[string]bool
+}
+
+func (p SCAPolicy) required(merchantID string) bool {
+	if required, ok := p.Merchants[merchantID]; ok {
+		return required
+	}
+	return p.Required
+}
+
+// WithThreeDS enables 3-D Secure authentication. Cardholders are sent back
+// to callbackURL once the challenge is completed.
+func WithThreeDS(authenticator threeds.Authenticator, callbackURL string, policy SCAPolicy) Option {
+	return func(s *Service) {
+		s.authenticator = authenticator
+		s.callbackURL = callbackURL
+		s.sca = policy
+	}
+}
+
+// WithAuthenticationTTL sets how long the cardholder has to complete a
+// challenge. Past it, the payment expires and its card data is dropped.
+func WithAuthenticationTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		s.authenticationTTL = ttl
+	}
+}
+
+// pendingAuthentication holds what is needed to authorize a payment once its
+// challenge is completed. Card data only lives in memory and is dropped as
+// soon as the authentication finishes or expires.
+type pendingAuthentication struct {
+	paymentID string
+	authReq   simulator.AuthorizationRequest
+	expiresAt time.Time
+}
+
+type pendingAuthentications struct {
+	mu      sync.Mutex
+	pending map[string]pendingAuthentication // keyed by 3DS transaction ID
+}
+
+func (p *pendingAuthentications) put(transactionID string, pending pendingAuthentication) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pending == nil {
+		p.pending = map[string]pendingAuthentication{}
+	}
+	p.pending[transactionID] = pending
+}
+
+// take removes the authentication and returns it, so a single caller can
+// complete it.
+func (p *pendingAuthentications) take(transactionID string) (pendingAuthentication, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pending, ok := p.pending[transactionID]
+	delete(p.pending, transactionID)
+	return pending, ok
+}
+
+// takeExpired removes the authentications expired at the given instant and
+// returns them.
+func (p *pendingAuthentications) takeExpired(at time.Time) []pendingAuthentication {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var expired []pendingAuthentication
+	for transactionID, pending := range p.pending {
+		if !at.Before(pending.expiresAt) {
+			expired = append(expired, pending)
+			delete(p.pending, transactionID)
+		}
+	}
+	return expired
+}
+
+func (s *Service) requiresAuthentication(paymentReq PaymentRequest) bool {
+	if paymentReq.ThreeDS != nil && paymentReq.ThreeDS.Enabled {
+		return true
+	}
+	return s.authenticator != nil && s.sca.required(paymentReq.MerchantID)
+}
+
+// startAuthentication persists the payment as requiring action and returns
+// it with the challenge URL the cardholder must be redirected to.
+func (s *Service) startAuthentication(
+	ctx context.Context,
+	payment *Payment,
+	authReq simulator.AuthorizationRequest,
+) (*Payment, error) {
+	if s.authenticator == nil {
+		return nil, fmt.Errorf("payment validation: %w", &InvalidPaymentRequestErr{
+			Field:   "three_ds",
+			Message: "3-D Secure authentication is not available",
+		})
+	}
+
+	session, err := s.authenticator.Initiate(ctx, threeds.InitiateRequest{
+		MerchantID:  payment.MerchantID,
+		CardNumber:  authReq.CardNumber,
+		Currency:    payment.Currency,
+		Amount:      payment.Amount,
+		CallbackURL: s.callbackURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("initiate authentication: %w", err)
+	}
+
+	payment.Status = StatusRequiresAction
+	payment.NextAction = &NextAction{
+		Type:        NextActionRedirect,
+		RedirectURL: session.ChallengeURL,
+	}
+	payment.ThreeDS = &ThreeDSOutcome{
+		TransactionID: session.TransactionID,
+	}
+
+	if err := s.repo.AddPayment(ctx, payment); err != nil {
+		return nil, fmt.Errorf("persist payment: %w", err)
+	}
+	s.notify(payment)
+
+	s.pending.put(session.TransactionID, pendingAuthentication{
+		paymentID: payment.ID,
+		authReq:   authReq,
+		expiresAt: s.clock.Now().Add(s.authenticationTTL),
+	})
+
+	return payment, nil
+}
+
+// CompleteAuthentication is called once the cardholder finished the 3-D
+// Secure challenge. Successful authentications are sent to the bank for
+// authorization, failed ones decline the payment.
+func (s *Service) CompleteAuthentication(ctx context.Context, transactionID string) (_ *Payment, err error) {
+	// Taking the authentication leaves nothing for concurrent or replayed
+	// callbacks, so the payment is authorized once.
+	pending, ok := s.pending.take(transactionID)
+	if !ok {
+		return nil, ErrAuthenticationNotFound
+	}
+
+	// The callback can be retried after errors, unless the bank may have
+	// authorized the payment already.
+	retryable := true
+	defer func() {
+		if err != nil && retryable {
+			s.pending.put(transactionID, pending)
+		}
+	}()
+
+	ctx, done := s.inFlight.track(ctx)
+	defer done()
+
+	result, err := s.authenticator.Result(ctx, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("authentication result: %w", err)
+	}
+
+	payment, err := s.GetPayment(ctx, pending.paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != StatusRequiresAction {
+		retryable = false
+		return nil, ErrAuthenticationNotFound
+	}
+	before := payment.Status.String()
+
+	payment.ThreeDS = &ThreeDSOutcome{
+		TransactionID:       result.TransactionID,
+		Status:              string(result.Status),
+		ECI:                 result.ECI,
+		AuthenticationValue: result.AuthenticationValue,
+	}
+
+	if result.Status.Succeeded() {
+		authReq := pending.authReq
+		authReq.ThreeDS = &simulator.ThreeDS{
+			TransactionID:       result.TransactionID,
+			Status:              string(result.Status),
+			ECI:                 result.ECI,
+			AuthenticationValue: result.AuthenticationValue,
+		}
+
+		// Nothing was sent to the bank yet, the callback can be retried.
+		if err := notSent(ctx); err != nil {
+			return nil, err
+		}
+
+		if err := s.authorize(ctx, payment, authReq); interrupted(ctx, err) {
+			s.holdForReconciliation(payment)
+		} else if err != nil {
+			return nil, err
+		}
+	} else {
+		payment.Status = StatusDeclined
+		payment.UpdatedAt = s.clock.Now().UTC()
+	}
+
+	retryable = false
+	payment.NextAction = nil
+
+	if err := s.repo.UpdatePayment(context.WithoutCancel(ctx), payment); err != nil {
+		return nil, fmt.Errorf("persist payment: %w", err)
+	}
+	warnReconciliation(ctx, payment)
+	s.notify(payment)
+	auditChange(ctx, payment, before)
+
+	return payment, nil
+}
+
+// ExpireAuthentications drops the authentications the cardholder did not
+// complete in time, with their card data, and moves their payments to the
+// expired status. It returns how many payments were expired.
+func (s *Service) ExpireAuthentications(ctx context.Context) (int, error) {
+	now := s.clock.Now()
+	expired := 0
+	var errs []error
+
+	for _, pending := range s.pending.takeExpired(now) {
+		payment, err := s.GetPayment(ctx, pending.paymentID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("get payment %s: %w", pending.paymentID, err))
+			continue
+		}
+		if payment.Status != StatusRequiresAction {
+			continue
+		}
+
+		payment.Status = StatusExpired
+		payment.NextAction = nil
+		payment.UpdatedAt = now.UTC()
+
+		if err := s.repo.UpdatePayment(ctx, payment); err != nil {
+			errs = append(errs, fmt.Errorf("expire payment %s: %w", payment.ID, err))
+			continue
+		}
+		s.notify(payment)
+		s.auditSystemChange(ctx, "expire authentication", payment, StatusRequiresAction)
+		expired++
+	}
+
+	return expired, errors.Join(errs...)
+}
+
+// RunAuthenticationExpiry expires the authentications not completed in time
+// every interval until the context is cancelled.
+func (s *Service) RunAuthenticationExpiry(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := s.ExpireAuthentications(ctx); err != nil {
+				slog.Error("expiring authentications", "error", err)
+			}
+		}
+	}
+}
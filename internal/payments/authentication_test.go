@@ -0,0 +1,218 @@
+package payments_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/audit"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/threeds"
+	"github.com/stretchr/testify/require"
+)
+
+const callbackURL = "http://localhost:8090/api/v1/payments/3ds/callback"
+
+func TestService_ThreeDS_ChallengeSucceeded(t *testing.T) {
+	t.Parallel()
+
+	acs := threeds.NewSimulator("http://acs.test")
+	repo := repository.NewPaymentsRepositoryInMemory()
+
+	authorizeCalls := 0
+	bank := &mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			authorizeCalls++
+			require.NotNil(t, req.ThreeDS)
+			require.Equal(t, string(threeds.StatusAuthenticated), req.ThreeDS.Status)
+			require.NotEmpty(t, req.ThreeDS.AuthenticationValue)
+			require.Equal(t, "4111111111111111", req.CardNumber)
+
+			return &simulator.AuthorizationResponse{Authorized: true, AuthorizationCode: "AUTH123"}, nil
+		},
+	}
+
+	service := newTestService(repo, bank, payments.WithThreeDS(acs, callbackURL, payments.SCAPolicy{}))
+
+	req := validPaymentRequest()
+	req.ThreeDS = &payments.ThreeDSRequest{Enabled: true}
+
+	payment, err := service.CreatePayment(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusRequiresAction, payment.Status)
+	require.Equal(t, payments.NextActionRedirect, payment.NextAction.Type)
+	require.Contains(t, payment.NextAction.RedirectURL, "http://acs.test/challenges/")
+	require.Zero(t, authorizeCalls, "the bank must not be called before authentication")
+
+	_, err = service.CompleteAuthentication(context.Background(), payment.ThreeDS.TransactionID)
+	require.ErrorIs(t, err, threeds.ErrChallengeNotCompleted)
+
+	_, err = acs.Complete(payment.ThreeDS.TransactionID, true)
+	require.NoError(t, err)
+
+	completed, err := service.CompleteAuthentication(context.Background(), payment.ThreeDS.TransactionID)
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusAuthorized, completed.Status)
+	require.Nil(t, completed.NextAction)
+	require.Equal(t, "05", completed.ThreeDS.ECI)
+	require.Equal(t, 1, authorizeCalls)
+
+	stored, err := service.GetPayment(context.Background(), payment.ID)
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusAuthorized, stored.Status)
+
+	_, err = service.CompleteAuthentication(context.Background(), payment.ThreeDS.TransactionID)
+	require.ErrorIs(t, err, payments.ErrAuthenticationNotFound)
+}
+
+func TestService_ThreeDS_ChallengeFailed(t *testing.T) {
+	t.Parallel()
+
+	acs := threeds.NewSimulator("http://acs.test")
+	repo := repository.NewPaymentsRepositoryInMemory()
+	bank := &mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			t.Fatal("the bank must not be called when authentication fails")
+			return nil, nil
+		},
+	}
+
+	service := newTestService(repo, bank, payments.WithThreeDS(acs, callbackURL, payments.SCAPolicy{
+		Merchants: map[string]bool{"merchant_eu": true},
+	}))
+
+	req := validPaymentRequest()
+	req.MerchantID = "merchant_eu"
+
+	payment, err := service.CreatePayment(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusRequiresAction, payment.Status)
+
+	_, err = acs.Complete(payment.ThreeDS.TransactionID, false)
+	require.NoError(t, err)
+
+	completed, err := service.CompleteAuthentication(context.Background(), payment.ThreeDS.TransactionID)
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusDeclined, completed.Status)
+	require.Equal(t, string(threeds.StatusFailed), completed.ThreeDS.Status)
+}
+
+func TestService_ThreeDS_NotAvailable(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(nil, nil)
+
+	req := validPaymentRequest()
+	req.ThreeDS = &payments.ThreeDSRequest{Enabled: true}
+
+	_, err := service.CreatePayment(context.Background(), req)
+
+	var invalidErr *payments.InvalidPaymentRequestErr
+	require.ErrorAs(t, err, &invalidErr)
+	require.Equal(t, "three_ds", invalidErr.Field)
+}
+
+func TestService_ThreeDS_ConcurrentCallbacks(t *testing.T) {
+	t.Parallel()
+
+	acs := threeds.NewSimulator("http://acs.test")
+	repo := repository.NewPaymentsRepositoryInMemory()
+
+	var authorizeCalls atomic.Int32
+	bank := &mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			authorizeCalls.Add(1)
+			return &simulator.AuthorizationResponse{Authorized: true, AuthorizationCode: "AUTH123"}, nil
+		},
+	}
+
+	service := newTestService(repo, bank, payments.WithThreeDS(acs, callbackURL, payments.SCAPolicy{Required: true}))
+
+	payment, err := service.CreatePayment(context.Background(), validPaymentRequest())
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusRequiresAction, payment.Status)
+
+	_, err = acs.Complete(payment.ThreeDS.TransactionID, true)
+	require.NoError(t, err)
+
+	const callbacks = 8
+	errs := make(chan error, callbacks)
+	var wg sync.WaitGroup
+	for range callbacks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.CompleteAuthentication(context.Background(), payment.ThreeDS.TransactionID)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, payments.ErrAuthenticationNotFound)
+	}
+	require.Equal(t, 1, succeeded)
+	require.Equal(t, int32(1), authorizeCalls.Load(), "the payment must be authorized once")
+}
+
+func TestService_ExpireAuthentications(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(serviceNow)
+	acs := threeds.NewSimulator("http://acs.test", threeds.WithSimulatorClock(clk))
+	repo := repository.NewPaymentsRepositoryInMemory()
+	bank := &mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			t.Fatal("the bank must not be called for an expired authentication")
+			return nil, nil
+		},
+	}
+
+	trail := audit.NewTrail(repository.NewAuditRepositoryInMemory())
+	service := newTestService(repo, bank,
+		payments.WithClock(clk),
+		payments.WithThreeDS(acs, callbackURL, payments.SCAPolicy{Required: true}),
+		payments.WithAuthenticationTTL(10*time.Minute),
+		payments.WithAuditTrail(trail),
+	)
+
+	payment, err := service.CreatePayment(context.Background(), validPaymentRequest())
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusRequiresAction, payment.Status)
+
+	clk.Advance(5 * time.Minute)
+	n, err := service.ExpireAuthentications(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, n, "the cardholder still has time to complete the challenge")
+
+	clk.Advance(5 * time.Minute)
+	n, err = service.ExpireAuthentications(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	stored, err := service.GetPayment(context.Background(), payment.ID)
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusExpired, stored.Status)
+	require.Nil(t, stored.NextAction)
+
+	_, err = service.CompleteAuthentication(context.Background(), payment.ThreeDS.TransactionID)
+	require.ErrorIs(t, err, payments.ErrAuthenticationNotFound)
+
+	entries, err := trail.Query(context.Background(), audit.Query{ActorID: audit.SystemActor})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "expire authentication", entries[0].Operation)
+	require.Equal(t, "requires_action", entries[0].BeforeStatus)
+	require.Equal(t, "expired", entries[0].AfterStatus)
+}
@@ -0,0 +1,63 @@
+package payments
+
+import (
+	"sync"
+)
+
+// Broker is an in-process pub/sub of payment changes, used to stream the
+// status of a payment to clients waiting on it.
+//
+// Delivery is best effort and only keeps the latest state: a subscriber that
+// falls behind skips intermediate states but always receives the last one.
+// Subscribers must read the current state after subscribing so that changes
+// made before they subscribed are not missed.
+type Broker struct {
+	mu   sync.Mutex
+	subs map[string]map[chan *Payment]struct{} // keyed by payment ID
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: map[string]map[chan *Payment]struct{}{}}
+}
+
+// Subscribe returns a channel receiving the changes of the payment, and a
+// function to call once the subscriber is done.
+func (b *Broker) Subscribe(paymentID string) (<-chan *Payment, func()) {
+	ch := make(chan *Payment, 1)
+
+	b.mu.Lock()
+	if b.subs[paymentID] == nil {
+		b.subs[paymentID] = map[chan *Payment]struct{}{}
+	}
+	b.subs[paymentID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.subs[paymentID], ch)
+		if len(b.subs[paymentID]) == 0 {
+			delete(b.subs, paymentID)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish sends a copy of the payment to its subscribers without blocking.
+func (b *Broker) Publish(payment *Payment) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs[payment.ID] {
+		p := *payment
+
+		// Replace an unread state with the newer one.
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- &p
+	}
+}
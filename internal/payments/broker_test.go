@@ -0,0 +1,42 @@
+package payments_test
+
+import (
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker(t *testing.T) {
+	t.Parallel()
+
+	broker := payments.NewBroker()
+
+	changes, unsubscribe := broker.Subscribe("pay_1")
+	other, unsubscribeOther := broker.Subscribe("pay_2")
+	defer unsubscribeOther()
+
+	// A subscriber that falls behind only gets the latest state.
+	broker.Publish(&payments.Payment{ID: "pay_1", Status: payments.StatusRequiresAction})
+	broker.Publish(&payments.Payment{ID: "pay_1", Status: payments.StatusAuthorized})
+
+	p := <-changes
+	require.Equal(t, payments.StatusAuthorized, p.Status)
+	require.Empty(t, other)
+
+	unsubscribe()
+	broker.Publish(&payments.Payment{ID: "pay_1", Status: payments.StatusVoided})
+	require.Empty(t, changes)
+}
+
+func TestPaymentStatus_IsTerminal(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, payments.StatusPending.IsTerminal())
+	require.False(t, payments.StatusRequiresAction.IsTerminal())
+	require.False(t, payments.StatusAuthorized.IsTerminal())
+	require.True(t, payments.StatusDeclined.IsTerminal())
+	require.True(t, payments.StatusRejected.IsTerminal())
+	require.True(t, payments.StatusExpired.IsTerminal())
+	require.True(t, payments.StatusVoided.IsTerminal())
+}
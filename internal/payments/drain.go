@@ -0,0 +1,136 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// ErrInterrupted is returned by Drain when authorizations were still in
+// flight at its deadline.
+var ErrInterrupted = errors.New("authorizations interrupted")
+
+// ErrShuttingDown is returned for authorizations refused once Drain
+// interrupted the ones in flight.
+var ErrShuttingDown = errors.New("shutting down")
+
+// inFlight tracks the authorizations waiting on the bank, so shutdown can
+// let them complete.
+type inFlight struct {
+	mu    sync.Mutex
+	count int
+	idle  chan struct{} // Closed when count drops to zero.
+
+	// interrupted is cancelled once Drain stops waiting, cancelling the
+	// authorizations still in flight and refusing new ones.
+	interrupted context.Context
+	interrupt   context.CancelFunc
+}
+
+func newInFlight() *inFlight {
+	f := &inFlight{idle: make(chan struct{})}
+	close(f.idle)
+	f.interrupted, f.interrupt = context.WithCancel(context.Background())
+	return f
+}
+
+// track counts an authorization as in flight until done is called. The
+// returned context is cancelled when Drain interrupts it.
+func (f *inFlight) track(ctx context.Context) (_ context.Context, done func()) {
+	f.mu.Lock()
+	if f.count == 0 {
+		f.idle = make(chan struct{})
+	}
+	f.count++
+	f.mu.Unlock()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	interrupt := func() { cancel(ErrShuttingDown) }
+	if f.interrupted.Err() != nil {
+		interrupt()
+	}
+	stop := context.AfterFunc(f.interrupted, interrupt)
+
+	return ctx, func() {
+		stop()
+		cancel(nil)
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.count--
+		if f.count == 0 {
+			close(f.idle)
+		}
+	}
+}
+
+// wait returns once nothing is in flight, or with the number still in
+// flight when ctx is done first.
+func (f *inFlight) wait(ctx context.Context) int {
+	for {
+		f.mu.Lock()
+		count, idle := f.count, f.idle
+		f.mu.Unlock()
+
+		if count == 0 {
+			return 0
+		}
+
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return count
+		}
+	}
+}
+
+// Drain waits for the authorizations in flight to complete, for a graceful
+// shutdown. Those still waiting on the bank when ctx is done are
+// interrupted: their payments are persisted as pending for reconciliation,
+// and Drain returns ErrInterrupted once they are. Authorizations are refused
+// from then on.
+func (s *Service) Drain(ctx context.Context) error {
+	if s.inFlight.wait(ctx) == 0 {
+		return nil
+	}
+
+	s.inFlight.interrupt()
+	count := s.inFlight.wait(context.Background())
+	return fmt.Errorf("%w: %d still waiting on the bank", ErrInterrupted, count)
+}
+
+// interrupted reports whether the bank call failed because its context was
+// cancelled, e.g. by Drain or the client going away. The bank may have
+// authorized the payment anyway.
+func interrupted(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() != nil
+}
+
+// notSent returns the reason ctx is done before anything was sent to the
+// bank, so the authorization can simply be refused.
+func notSent(ctx context.Context) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	return context.Cause(ctx)
+}
+
+// holdForReconciliation leaves a payment whose authorization was
+// interrupted pending. Retrying could authorize it twice, so it waits for
+// its outcome to be reconciled with the bank instead.
+func (s *Service) holdForReconciliation(payment *Payment) {
+	payment.Status = StatusPending
+	payment.ReconciliationRequired = true
+	payment.UpdatedAt = s.clock.Now().UTC()
+}
+
+// warnReconciliation logs the payments held for reconciliation, once
+// persisted.
+func warnReconciliation(ctx context.Context, payment *Payment) {
+	if payment.ReconciliationRequired {
+		slog.WarnContext(ctx, "authorization interrupted, payment left pending for reconciliation",
+			"payment_id", payment.ID)
+	}
+}
@@ -0,0 +1,157 @@
+package payments_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingBank authorizes payments once release is closed, or fails when
+// the authorization is cancelled first. calls receives every authorization
+// as it starts.
+func blockingBank(release <-chan struct{}) (*mockBankingSimulator, <-chan struct{}) {
+	calls := make(chan struct{}, 10)
+	return &mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			calls <- struct{}{}
+			select {
+			case <-release:
+				return &simulator.AuthorizationResponse{Authorized: true, AuthorizationCode: "AUTH123"}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+	}, calls
+}
+
+func TestService_Drain_WaitsForAuthorizations(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	bank, calls := blockingBank(release)
+	service := newTestService(repository.NewPaymentsRepositoryInMemory(), bank)
+
+	created := make(chan *payments.Payment, 1)
+	go func() {
+		payment, err := service.CreatePayment(context.Background(), validPaymentRequest())
+		assert.NoError(t, err)
+		created <- payment
+	}()
+	<-calls
+
+	drained := make(chan error, 1)
+	go func() { drained <- service.Drain(context.Background()) }()
+
+	select {
+	case <-drained:
+		t.Fatal("drained with an authorization in flight")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-drained)
+	assert.Equal(t, payments.StatusAuthorized, (<-created).Status)
+}
+
+func TestService_Drain_Idle(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(repository.NewPaymentsRepositoryInMemory(), authorizingBank())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, service.Drain(ctx))
+}
+
+func TestService_Drain_InterruptsAtDeadline(t *testing.T) {
+	t.Parallel()
+
+	bank, calls := blockingBank(nil)
+	repo := repository.NewPaymentsRepositoryInMemory()
+	service := newTestService(repo, bank)
+
+	created := make(chan *payments.Payment, 1)
+	go func() {
+		payment, err := service.CreatePayment(context.Background(), validPaymentRequest())
+		assert.NoError(t, err)
+		created <- payment
+	}()
+	<-calls
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := service.Drain(ctx)
+	require.ErrorIs(t, err, payments.ErrInterrupted)
+
+	// The bank may have authorized it, the payment is kept for
+	// reconciliation rather than dropped.
+	payment := <-created
+	assert.Equal(t, payments.StatusPending, payment.Status)
+	assert.True(t, payment.ReconciliationRequired)
+
+	stored, err := repo.GetPayment(context.Background(), payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, payments.StatusPending, stored.Status)
+	assert.True(t, stored.ReconciliationRequired)
+
+	// Nothing reaches the bank once interrupted.
+	_, err = service.CreatePayment(context.Background(), validPaymentRequest())
+	require.ErrorIs(t, err, payments.ErrShuttingDown)
+	assert.Empty(t, calls)
+}
+
+func TestService_CreatePayment_CallerGoneAway(t *testing.T) {
+	t.Parallel()
+
+	bank, calls := blockingBank(nil)
+	repo := repository.NewPaymentsRepositoryInMemory()
+	service := newTestService(repo, bank)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-calls
+		cancel()
+	}()
+
+	payment, err := service.CreatePayment(ctx, validPaymentRequest())
+	require.NoError(t, err)
+	assert.Equal(t, payments.StatusPending, payment.Status)
+	assert.True(t, payment.ReconciliationRequired)
+
+	stored, err := repo.GetPayment(context.Background(), payment.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.ReconciliationRequired)
+}
+
+func TestService_Drain_InterruptsPendingAuthorization(t *testing.T) {
+	t.Parallel()
+
+	bank, calls := blockingBank(nil)
+	repo := repository.NewPaymentsRepositoryInMemory()
+	service := newAsyncService(t, repo, bank, 10)
+	runWorkers(t, service, 1)
+
+	payment, err := service.CreatePaymentAsync(context.Background(), validPaymentRequest())
+	require.NoError(t, err)
+	<-calls
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, service.Drain(ctx), payments.ErrInterrupted)
+
+	stored, err := repo.GetPayment(context.Background(), payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, payments.StatusPending, stored.Status)
+	assert.True(t, stored.ReconciliationRequired)
+
+	// The card data is discarded, it is not authorized again on restart.
+	ids, err := repo.ListPendingAuthorizations(context.Background())
+	require.NoError(t, err)
+	assert.NotContains(t, ids, payment.ID)
+}
@@ -0,0 +1,49 @@
+package payments
+
+// EventType identifies a change in the lifecycle of a payment. There is no
+// refund event, as the gateway does not refund payments yet.
+type EventType string
+
+const (
+	EventPaymentRequiresAction EventType = "payment.requires_action"
+	EventPaymentAuthorized     EventType = "payment.authorized"
+	EventPaymentDeclined       EventType = "payment.declined"
+	EventPaymentRejected       EventType = "payment.rejected"
+	EventPaymentVoided         EventType = "payment.voided"
+	EventPaymentExpired        EventType = "payment.expired"
+	EventPaymentBlocked        EventType = "payment.blocked"
+	EventPaymentHeldForReview  EventType = "payment.held_for_review"
+	EventPaymentCaptured       EventType = "payment.captured"
+)
+
+// EventTypes lists every event emitted for payments.
+var EventTypes = []EventType{
+	EventPaymentRequiresAction,
+	EventPaymentAuthorized,
+	EventPaymentDeclined,
+	EventPaymentRejected,
+	EventPaymentVoided,
+	EventPaymentExpired,
+	EventPaymentBlocked,
+	EventPaymentHeldForReview,
+	EventPaymentCaptured,
+}
+
+var statusEvents = map[PaymentStatus]EventType{
+	StatusRequiresAction: EventPaymentRequiresAction,
+	StatusAuthorized:     EventPaymentAuthorized,
+	StatusDeclined:       EventPaymentDeclined,
+	StatusRejected:       EventPaymentRejected,
+	StatusVoided:         EventPaymentVoided,
+	StatusExpired:        EventPaymentExpired,
+	StatusBlocked:        EventPaymentBlocked,
+	StatusHeldForReview:  EventPaymentHeldForReview,
+	StatusCaptured:       EventPaymentCaptured,
+}
+
+// EventTypeFor returns the event emitted when a payment reaches the status.
+// Repositories record it in their outbox, in the same write as the payment.
+func EventTypeFor(status PaymentStatus) (EventType, bool) {
+	eventType, ok := statusEvents[status]
+	return eventType, ok
+}
@@ -0,0 +1,91 @@
+package payments
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+)
+
+// errSoftDeclined signals that the issuer requires the cardholder to be
+// authenticated before authorizing the payment.
+var errSoftDeclined = errors.New("authorization soft declined")
+
+// Exemption is a reason to skip strong customer authentication.
+type Exemption string
+
+const (
+	ExemptionNone              Exemption = ""
+	ExemptionLowValue          Exemption = "low_value"
+	ExemptionTRA               Exemption = "transaction_risk_analysis"
+	ExemptionRecurring         Exemption = "recurring"
+	ExemptionMerchantInitiated Exemption = "merchant_initiated"
+)
+
+// ExemptionPolicy holds the limits under which exemptions can be requested.
+// Amounts are expressed in minor units, per currency; currencies without a
+// limit never qualify.
+type ExemptionPolicy struct {
+	LowValueLimits map[string]int64
+	TRALimits      map[string]int64
+	// TRAMerchants lists the merchants whose fraud rates allow transaction
+	// risk analysis exemptions.
+	TRAMerchants []string
+}
+
+// ExemptionEngine decides which exemption, if any, to request for a payment.
+type ExemptionEngine struct {
+	policy ExemptionPolicy
+}
+
+func NewExemptionEngine(policy ExemptionPolicy) *ExemptionEngine {
+	return &ExemptionEngine{policy: policy}
+}
+
+// Decide returns the exemption to request, from the strongest to the
+// weakest: merchant-initiated transactions are out of scope of SCA, then
+// subsequent recurring payments, low-value payments and finally transaction
+// risk analysis.
+func (e *ExemptionEngine) Decide(req PaymentRequest) Exemption {
+	switch {
+	case req.MerchantInitiated:
+		return ExemptionMerchantInitiated
+	case req.Recurring:
+		return ExemptionRecurring
+	case withinLimit(e.policy.LowValueLimits, req.Currency, req.Amount):
+		return ExemptionLowValue
+	case slices.Contains(e.policy.TRAMerchants, req.MerchantID) &&
+		withinLimit(e.policy.TRALimits, req.Currency, req.Amount):
+		return ExemptionTRA
+	default:
+		return ExemptionNone
+	}
+}
+
+func withinLimit(limits map[string]int64, currency string, amount int64) bool {
+	limit, ok := limits[currency]
+	return ok && amount <= limit
+}
+
+// WithExemptions enables requesting SCA exemptions for payments that would
+// otherwise be challenged.
+func WithExemptions(engine *ExemptionEngine) Option {
+	return func(s *Service) {
+		s.exemptions = engine
+	}
+}
+
+// exemption returns the exemption to request for a payment subject to SCA.
+// A challenge explicitly asked by the merchant is never skipped.
+func (s *Service) exemption(paymentReq PaymentRequest) Exemption {
+	if s.exemptions == nil || (paymentReq.ThreeDS != nil && paymentReq.ThreeDS.Enabled) {
+		return ExemptionNone
+	}
+	return s.exemptions.Decide(paymentReq)
+}
+
+// canChallenge reports whether a soft-declined authorization can be retried
+// with a challenge: the cardholder must be present and not yet authenticated.
+func (s *Service) canChallenge(authReq simulator.AuthorizationRequest) bool {
+	return s.authenticator != nil && authReq.ThreeDS == nil && !authReq.MerchantInitiated
+}
@@ -0,0 +1,226 @@
+package payments_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/threeds"
+	"github.com/stretchr/testify/require"
+)
+
+var testExemptionPolicy = payments.ExemptionPolicy{
+	LowValueLimits: map[string]int64{"EUR": 3000},
+	TRALimits:      map[string]int64{"EUR": 10000},
+	TRAMerchants:   []string{"merchant_tra"},
+}
+
+func TestExemptionEngine_Decide(t *testing.T) {
+	t.Parallel()
+
+	engine := payments.NewExemptionEngine(testExemptionPolicy)
+
+	tests := []struct {
+		name     string
+		req      func() payments.PaymentRequest
+		expected payments.Exemption
+	}{
+		{
+			name: "low value in a currency with a limit",
+			req: func() payments.PaymentRequest {
+				r := validPaymentRequest()
+				r.Currency = "EUR"
+				r.Amount = 3000
+				return r
+			},
+			expected: payments.ExemptionLowValue,
+		},
+		{
+			name: "low value in a currency without a limit",
+			req: func() payments.PaymentRequest {
+				r := validPaymentRequest()
+				r.Currency = "USD"
+				r.Amount = 100
+				return r
+			},
+			expected: payments.ExemptionNone,
+		},
+		{
+			name: "above the low value limit for a TRA merchant",
+			req: func() payments.PaymentRequest {
+				r := validPaymentRequest()
+				r.MerchantID = "merchant_tra"
+				r.Currency = "EUR"
+				r.Amount = 10000
+				return r
+			},
+			expected: payments.ExemptionTRA,
+		},
+		{
+			name: "above the TRA limit",
+			req: func() payments.PaymentRequest {
+				r := validPaymentRequest()
+				r.MerchantID = "merchant_tra"
+				r.Currency = "EUR"
+				r.Amount = 10001
+				return r
+			},
+			expected: payments.ExemptionNone,
+		},
+		{
+			name: "above the low value limit for a merchant not eligible for TRA",
+			req: func() payments.PaymentRequest {
+				r := validPaymentRequest()
+				r.Currency = "EUR"
+				r.Amount = 5000
+				return r
+			},
+			expected: payments.ExemptionNone,
+		},
+		{
+			name: "recurring payment",
+			req: func() payments.PaymentRequest {
+				r := validPaymentRequest()
+				r.Recurring = true
+				r.Amount = 1_000_000
+				return r
+			},
+			expected: payments.ExemptionRecurring,
+		},
+		{
+			name: "merchant initiated payment takes precedence",
+			req: func() payments.PaymentRequest {
+				r := validPaymentRequest()
+				r.Recurring = true
+				r.MerchantInitiated = true
+				return r
+			},
+			expected: payments.ExemptionMerchantInitiated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, engine.Decide(tt.req()))
+		})
+	}
+}
+
+func newSCAService(bank simulator.BankingSimulator) (*payments.Service, *threeds.Simulator) {
+	acs := threeds.NewSimulator("http://acs.test")
+	service := newTestService(
+		repository.NewPaymentsRepositoryInMemory(),
+		bank,
+		payments.WithThreeDS(acs, callbackURL, payments.SCAPolicy{Required: true}),
+		payments.WithExemptions(payments.NewExemptionEngine(testExemptionPolicy)),
+	)
+	return service, acs
+}
+
+func lowValueEURRequest() payments.PaymentRequest {
+	req := validPaymentRequest()
+	req.Currency = "EUR"
+	req.Amount = 2000
+	return req
+}
+
+func TestService_Exemption_Authorized(t *testing.T) {
+	t.Parallel()
+
+	service, _ := newSCAService(&mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			require.Equal(t, string(payments.ExemptionLowValue), req.Exemption)
+			require.Nil(t, req.ThreeDS)
+			return &simulator.AuthorizationResponse{Authorized: true}, nil
+		},
+	})
+
+	payment, err := service.CreatePayment(context.Background(), lowValueEURRequest())
+
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusAuthorized, payment.Status)
+	require.Equal(t, payments.ExemptionLowValue, payment.Exemption)
+	require.Nil(t, payment.NextAction)
+}
+
+func TestService_Exemption_SoftDeclineFallsBackToChallenge(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	service, acs := newSCAService(&mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			calls++
+			if calls == 1 {
+				require.Equal(t, string(payments.ExemptionLowValue), req.Exemption)
+				return &simulator.AuthorizationResponse{
+					ResponseCode: simulator.ResponseCodeAuthenticationRequired,
+				}, nil
+			}
+
+			require.Empty(t, req.Exemption)
+			require.NotNil(t, req.ThreeDS)
+			return &simulator.AuthorizationResponse{Authorized: true}, nil
+		},
+	})
+
+	payment, err := service.CreatePayment(context.Background(), lowValueEURRequest())
+
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusRequiresAction, payment.Status)
+	require.Equal(t, payments.ExemptionLowValue, payment.Exemption)
+	require.NotNil(t, payment.NextAction)
+
+	_, err = acs.Complete(payment.ThreeDS.TransactionID, true)
+	require.NoError(t, err)
+
+	completed, err := service.CompleteAuthentication(context.Background(), payment.ThreeDS.TransactionID)
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusAuthorized, completed.Status)
+	require.Equal(t, 2, calls)
+}
+
+func TestService_Exemption_MerchantInitiatedSoftDeclineIsDeclined(t *testing.T) {
+	t.Parallel()
+
+	service, _ := newSCAService(&mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			require.True(t, req.MerchantInitiated)
+			return &simulator.AuthorizationResponse{
+				ResponseCode: simulator.ResponseCodeAuthenticationRequired,
+			}, nil
+		},
+	})
+
+	req := validPaymentRequest()
+	req.MerchantInitiated = true
+
+	payment, err := service.CreatePayment(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusDeclined, payment.Status)
+	require.Equal(t, payments.ExemptionMerchantInitiated, payment.Exemption)
+}
+
+func TestService_Exemption_ForcedChallengeSkipsExemptions(t *testing.T) {
+	t.Parallel()
+
+	service, _ := newSCAService(&mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			t.Fatal("the bank must not be called before the challenge")
+			return nil, nil
+		},
+	})
+
+	req := lowValueEURRequest()
+	req.ThreeDS = &payments.ThreeDSRequest{Enabled: true}
+
+	payment, err := service.CreatePayment(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusRequiresAction, payment.Status)
+	require.Equal(t, payments.ExemptionNone, payment.Exemption)
+}
@@ -11,6 +11,13 @@ import (
 type PaymentsRepository interface {
 	GetPayment(ctx context.Context, id string) (*Payment, error)
 	AddPayment(ctx context.Context, payment *Payment) error
+	UpdatePayment(ctx context.Context, payment *Payment) error
+	// SearchPayments returns the payments matching every filter set in the
+	// query, ordered by creation time.
+	SearchPayments(ctx context.Context, query PaymentsQuery) ([]*Payment, error)
+	// ListExpiredAuthorizations returns the authorized payments whose
+	// ExpiresAt is not after the given instant.
+	ListExpiredAuthorizations(ctx context.Context, at time.Time) ([]*Payment, error)
 }
 
 var (
@@ -33,6 +40,12 @@ const (
 	StatusAuthorized
 	StatusDeclined
 	StatusRejected
+	StatusExpired
+	StatusVoided
+	StatusRequiresAction
+	StatusBlocked
+	StatusHeldForReview
+	StatusCaptured
 )
 
 func (s PaymentStatus) String() string {
@@ -45,6 +58,18 @@ func (s PaymentStatus) String() string {
 		return "declined"
 	case StatusRejected:
 		return "rejected"
+	case StatusExpired:
+		return "expired"
+	case StatusVoided:
+		return "voided"
+	case StatusRequiresAction:
+		return "requires_action"
+	case StatusBlocked:
+		return "blocked"
+	case StatusHeldForReview:
+		return "held_for_review"
+	case StatusCaptured:
+		return "captured"
 	default:
 		return "unknown"
 	}
@@ -54,84 +79,165 @@ func (s PaymentStatus) MarshalJSON() ([]byte, error) {
 	return json.Marshal(s.String())
 }
 
+// IsTerminal reports whether a payment in this status can no longer change.
+func (s PaymentStatus) IsTerminal() bool {
+	switch s {
+	case StatusDeclined, StatusRejected, StatusExpired, StatusVoided, StatusBlocked, StatusCaptured:
+		return true
+	default:
+		return false
+	}
+}
+
 type Payment struct {
-	ID     string        `json:"id" example:"019ba901-48a1-7138-824e-d0e65a8dc38a"`                                             // Unique identifier of the payment.
-	Status PaymentStatus `json:"status" swaggertype:"string" example:"authorized" enums:"authorized,declined,rejected,pending"` // Current status of the payment.
+	ID     string        `json:"id" example:"019ba901-48a1-7138-824e-d0e65a8dc38a"`                                                                                                             // Unique identifier of the payment.
+	Status PaymentStatus `json:"status" swaggertype:"string" example:"authorized" enums:"authorized,declined,rejected,pending,expired,voided,requires_action,blocked,held_for_review,captured"` // Current status of the payment.
 	// TODO: StatusDescription  string one possiblity to distinguich between errors better
 	// StatusErrorCode int
 	// 1 -> represents the card dont have enough money
 	// 2 -> could be validation
 	// and so on...
-	CardNumberLastFour string `json:"card_number_last_four" example:"8877"`       // Last four digits of the card number used in the payment.
-	ExpiryMonth        int    `json:"expiry_month" example:"12"`                  // Expiration month (1–12).
-	ExpiryYear         int    `json:"expiry_year" example:"2050"`                 // Expiration year (four digits).
-	Currency           string `json:"currency" example:"USD" enums:"USD,EUR,BRL"` // Currency code in ISO 4217 format (e.g. USD, EUR, BRL).
-	Amount             int64  `json:"amount" example:"1000"`                      // Amount expressed in minor units of the given currency. Example: $10.99 USD → 1099
+
+	MerchantID         string `json:"merchant_id,omitempty" example:"merchant_123"` // Identifier of the merchant that created the payment.
+	CardNumberLastFour string `json:"card_number_last_four" example:"8877"`         // Last four digits of the card number used in the payment.
+	CardFingerprint    string `json:"-"`                                            // Keyed fingerprint identifying the card, kept from merchants: it is the same for all of them.
+	ExpiryMonth        int    `json:"expiry_month" example:"12"`                    // Expiration month (1–12).
+	ExpiryYear         int    `json:"expiry_year" example:"2050"`                   // Expiration year (four digits).
+	Currency           string `json:"currency" example:"USD"`                       // Currency code in ISO 4217 format (e.g. USD, JPY, KWD).
+	Amount             int64  `json:"amount" example:"1000"`                        // Amount expressed in minor units of the given currency. Example: $10.99 USD → 1099
+	DisplayAmount      string `json:"display_amount" example:"10.00"`               // Amount formatted in major units using the currency exponent. Example: 1099 USD → "10.99"
+
+	Reference           string            `json:"reference,omitempty" example:"ORD-5023-4E89"`                  // Merchant reference used to link the payment to an order.
+	Description         string            `json:"description,omitempty" example:"Set of 3 masks"`               // Free-text description of the payment.
+	StatementDescriptor string            `json:"statement_descriptor,omitempty" example:"ACME*MASKS"`          // Text shown on the cardholder statement.
+	Metadata            map[string]string `json:"metadata,omitempty" example:"order_channel:web,coupon:SUMMER"` // Merchant key/value pairs stored with the payment.
+
+	CardholderName    string          `json:"cardholder_name,omitempty" example:"Jane Doe"`       // Name of the cardholder as printed on the card.
+	BillingAddress    *BillingAddress `json:"billing_address,omitempty"`                          // Billing address of the cardholder.
+	AuthorizationCode string          `json:"authorization_code,omitempty" example:"A1B2C3"`      // Code returned by the bank for an authorization.
+	AVSResult         AVSResult       `json:"avs_result,omitempty" example:"Y" enums:"Y,A,Z,N,U"` // Address Verification result returned by the bank.
+	CVVResult         CVVResult       `json:"cvv_result,omitempty" example:"M" enums:"M,N,U"`     // Card verification value check result returned by the bank.
+
+	NextAction *NextAction     `json:"next_action,omitempty"`                                                                                                // Action the cardholder must take before the payment can proceed.
+	ThreeDS    *ThreeDSOutcome `json:"three_ds,omitempty"`                                                                                                   // Outcome of the 3-D Secure authentication.
+	Exemption  Exemption       `json:"sca_exemption,omitempty" example:"low_value" enums:"low_value,transaction_risk_analysis,recurring,merchant_initiated"` // Strong customer authentication exemption requested to the bank.
+
+	RiskScore   int     `json:"risk_score" example:"20"`                                           // Fraud risk score, from 0 to 100.
+	RiskOutcome string  `json:"risk_outcome,omitempty" example:"allow" enums:"allow,review,block"` // Outcome of the fraud screening.
+	Review      *Review `json:"review,omitempty"`                                                  // Manual review of a payment held by the fraud screening.
+
+	ReconciliationRequired bool `json:"reconciliation_required,omitempty" example:"false"` // The authorization was interrupted: the payment stays pending until its outcome is reconciled with the bank.
+
+	CreatedAt    time.Time  `json:"created_at" example:"2026-01-15T10:00:00Z"`              // When the payment was created.
+	UpdatedAt    time.Time  `json:"updated_at" example:"2026-01-15T10:00:00Z"`              // When the payment was last changed.
+	AuthorizedAt *time.Time `json:"authorized_at,omitempty" example:"2026-01-15T10:00:00Z"` // When the bank authorized the payment.
+	ExpiresAt    *time.Time `json:"expires_at,omitempty" example:"2026-01-22T10:00:00Z"`    // When an uncaptured authorization expires.
 }
 
 type PaymentRequest struct {
-	CardNumber  string `json:"card_number" example:"2222405343248877"`     // Card number containing between 14 and 19 digits.
-	ExpiryMonth int    `json:"expiry_month" example:"12"`                  // Expiration month (1–12).
-	ExpiryYear  int    `json:"expiry_year" example:"2050"`                 // Expiration year (four digits).
-	Currency    string `json:"currency" example:"USD" enums:"USD,EUR,BRL"` // Currency code in ISO 4217 format (e.g. USD, EUR, BRL).
-	Amount      int64  `json:"amount" example:"1000"`                      // Amount expressed in minor units of the given currency. Example: $10.99 USD → 1099
-	CVV         string `json:"cvv" example:"123"`                          // Card verification value (3 or 4 digits).
-}
-
-func (req PaymentRequest) Validate() error {
-	if len(req.CardNumber) < 14 ||
-		len(req.CardNumber) > 19 ||
-		!isDigitsOnly(req.CardNumber) {
-		return &InvalidPaymentRequestErr{
-			Field:   "card_number",
-			Message: "card number must contain between 14 and 19 numeric digits",
-		}
-	}
+	MerchantID  string `json:"-" swaggerignore:"true"`                 // Identifier of the merchant, resolved by the API layer.
+	CardNumber  string `json:"card_number" example:"2222405343248877"` // Card number containing between 14 and 19 digits.
+	ExpiryMonth int    `json:"expiry_month" example:"12"`              // Expiration month (1–12).
+	ExpiryYear  int    `json:"expiry_year" example:"2050"`             // Expiration year (four digits).
+	Currency    string `json:"currency" example:"USD"`                 // Currency code in ISO 4217 format (e.g. USD, JPY, KWD).
+	Amount      int64  `json:"amount" example:"1000"`                  // Amount expressed in minor units of the given currency. Example: $10.99 USD → 1099
+	CVV         string `json:"cvv" example:"123"`                      // Card verification value (3 or 4 digits).
+
+	Reference           string            `json:"reference,omitempty" example:"ORD-5023-4E89"`                  // Merchant reference (up to 50 characters).
+	Description         string            `json:"description,omitempty" example:"Set of 3 masks"`               // Free-text description (up to 255 characters).
+	StatementDescriptor string            `json:"statement_descriptor,omitempty" example:"ACME*MASKS"`          // Text shown on the cardholder statement (up to 22 characters).
+	Metadata            map[string]string `json:"metadata,omitempty" example:"order_channel:web,coupon:SUMMER"` // Up to 20 key/value pairs (keys up to 40 and values up to 500 characters).
+
+	CardholderName string          `json:"cardholder_name,omitempty" example:"Jane Doe"` // Name of the cardholder as printed on the card (up to 100 characters).
+	BillingAddress *BillingAddress `json:"billing_address,omitempty"`                    // Billing address of the cardholder, sent to the bank for fraud scoring.
+
+	ThreeDS           *ThreeDSRequest `json:"three_ds,omitempty"`                           // 3-D Secure preferences for the payment.
+	MerchantInitiated bool            `json:"merchant_initiated,omitempty" example:"false"` // Payment initiated by the merchant without the cardholder being present.
+	Recurring         bool            `json:"recurring,omitempty" example:"false"`          // Subsequent payment of a recurring series already authenticated.
+}
 
-	if req.ExpiryMonth < 1 || req.ExpiryMonth > 12 {
-		return &InvalidPaymentRequestErr{
-			Field:   "expiry_month",
-			Message: "expiry month must be between 1 and 12",
-		}
-	}
+// ReviewDecision is the outcome of a manual review.
+type ReviewDecision string
 
-	// TODO: ensure that we test this before ship to production
-	// tip: unit test to enforce different timezones and ensure that UTC is covering everything...
-	now := time.Now().UTC()
-	if req.ExpiryYear < now.Year() ||
-		(req.ExpiryYear == now.Year() && req.ExpiryMonth < int(now.Month())) {
-		return &InvalidPaymentRequestErr{
-			Field:   "expiry_date",
-			Message: "expiry date must be in the future",
-		}
-	}
+const (
+	ReviewApproved ReviewDecision = "approved" // The payment was captured.
+	ReviewRejected ReviewDecision = "rejected" // The payment was voided.
+	ReviewExpired  ReviewDecision = "expired"  // Nobody reviewed it in time, it was voided.
+)
 
-	switch req.Currency {
-	case "USD", "EUR", "BRL":
-	default:
-		return &InvalidPaymentRequestErr{
-			Field:   "currency",
-			Message: "currency must be one of: USD, EUR, BRL",
-		}
-	}
+type Review struct {
+	Deadline   time.Time      `json:"deadline" example:"2026-01-16T10:00:00Z"`                                 // When the payment is voided if still not reviewed.
+	Decision   ReviewDecision `json:"decision,omitempty" example:"approved" enums:"approved,rejected,expired"` // Outcome of the review, empty while pending.
+	ReviewedBy string         `json:"reviewed_by,omitempty" example:"analyst@risk"`                            // Who took the decision.
+	ReviewedAt *time.Time     `json:"reviewed_at,omitempty" example:"2026-01-15T12:00:00Z"`                    // When the decision was taken.
+}
 
-	if req.Amount <= 0 {
-		return &InvalidPaymentRequestErr{
-			Field:   "amount",
-			Message: "amount must be greater than zero",
-		}
-	}
+type ThreeDSRequest struct {
+	Enabled bool `json:"enabled" example:"true"` // Force a 3-D Secure challenge before authorization, skipping exemptions.
+}
 
-	if len(req.CVV) < 3 ||
-		len(req.CVV) > 4 ||
-		!isDigitsOnly(req.CVV) {
-		return &InvalidPaymentRequestErr{
-			Field:   "cvv",
-			Message: "cvv must contain 3 or 4 numeric digits",
-		}
-	}
+type NextAction struct {
+	Type        string `json:"type" example:"redirect_to_url"`                                                 // Kind of action required.
+	RedirectURL string `json:"redirect_url" example:"http://localhost:8090/acs/challenges/3f0c5c1e-1b8a-4f55"` // Where the cardholder must be redirected.
+}
+
+type ThreeDSOutcome struct {
+	TransactionID       string `json:"transaction_id" example:"3f0c5c1e-1b8a-4f55"`  // Identifier of the authentication at the ACS.
+	Status              string `json:"status,omitempty" example:"Y" enums:"Y,A,N,R"` // 3-D Secure transaction status, empty while pending.
+	ECI                 string `json:"eci,omitempty" example:"05"`                   // Electronic Commerce Indicator.
+	AuthenticationValue string `json:"-"`                                            // Cryptogram proving the authentication, never exposed.
+}
+
+type BillingAddress struct {
+	Line1      string `json:"line1,omitempty" example:"1 Main Street"`  // First address line.
+	Line2      string `json:"line2,omitempty" example:"Apartment 2"`    // Second address line.
+	City       string `json:"city,omitempty" example:"London"`          // City or locality.
+	State      string `json:"state,omitempty" example:"Greater London"` // State, county or province.
+	PostalCode string `json:"postal_code,omitempty" example:"W1 8QS"`   // Postal or ZIP code.
+	Country    string `json:"country" example:"GB"`                     // Country code in ISO 3166-1 alpha-2 format.
+}
+
+// AVSResult is the Address Verification result code returned by the bank.
+type AVSResult string
+
+const (
+	AVSFullMatch    AVSResult = "Y" // Street address and postal code match.
+	AVSAddressMatch AVSResult = "A" // Street address matches, postal code does not.
+	AVSPostalMatch  AVSResult = "Z" // Postal code matches, street address does not.
+	AVSNoMatch      AVSResult = "N" // Neither street address nor postal code match.
+	AVSUnavailable  AVSResult = "U" // The issuer could not verify the address.
+)
+
+// IsMismatch reports whether the bank found that the billing address does
+// not belong to the cardholder.
+func (r AVSResult) IsMismatch() bool {
+	return r == AVSNoMatch
+}
+
+// CVVResult is the card verification value check result returned by the bank.
+type CVVResult string
+
+const (
+	CVVMatch       CVVResult = "M" // The CVV matches.
+	CVVNoMatch     CVVResult = "N" // The CVV does not match.
+	CVVUnavailable CVVResult = "U" // The issuer could not check the CVV.
+)
 
-	return nil
+// PaymentsQuery filters payments returned by a search. A search only
+// matches the payments of MerchantID; other empty fields match every
+// payment.
+type PaymentsQuery struct {
+	MerchantID string
+	// AllMerchants matches the payments of every merchant instead of those
+	// of MerchantID, for back-office use only.
+	AllMerchants bool
+	Reference    string
+	// Statuses matches payments in any of the given statuses.
+	Statuses []PaymentStatus
+	// Metadata matches payments holding every given key/value pair.
+	Metadata map[string]string
+	// Limit caps the number of results; zero means no limit.
+	Limit int
 }
 
 func isDigitsOnly(s string) bool {
@@ -0,0 +1,62 @@
+package payments
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/audit"
+)
+
+// Observer is told about every payment change once it is persisted, e.g.
+// to keep metrics. It must return quickly and must not modify the payment.
+type Observer interface {
+	PaymentChanged(payment *Payment)
+}
+
+// WithObserver adds an observer of payment changes.
+func WithObserver(observer Observer) Option {
+	return func(s *Service) {
+		s.observers = append(s.observers, observer)
+	}
+}
+
+// notify shares a persisted payment change with the event streams and the
+// observers.
+func (s *Service) notify(payment *Payment) {
+	s.broker.Publish(payment)
+
+	for _, o := range s.observers {
+		o.PaymentChanged(payment)
+	}
+}
+
+// WithAuditTrail records in the trail the payment changes made without a
+// call to attribute them to: expired authorizations and reviews, and
+// asynchronous authorizations.
+func WithAuditTrail(trail *audit.Trail) Option {
+	return func(s *Service) {
+		s.auditTrail = trail
+	}
+}
+
+// auditSystemChange records the status change of a persisted payment made by
+// the gateway on its own. Failures are logged: the change is already done.
+func (s *Service) auditSystemChange(ctx context.Context, operation string, payment *Payment, before PaymentStatus) {
+	if s.auditTrail == nil {
+		return
+	}
+
+	_, err := s.auditTrail.RecordSystemChange(context.WithoutCancel(ctx), operation,
+		payment.MerchantID, payment.ID, before.String(), payment.Status.String())
+	if err != nil {
+		slog.ErrorContext(ctx, "recording audit entry", "operation", operation, "payment_id", payment.ID, "error", err)
+	}
+}
+
+// auditChange notes the status change of a persisted payment for the audit
+// trail of the call. The before status is empty when the payment was created.
+func auditChange(ctx context.Context, payment *Payment, before string) {
+	if payment != nil && payment.ID != "" {
+		audit.RecordPaymentChange(ctx, payment.ID, before, payment.Status.String())
+	}
+}
@@ -0,0 +1,223 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultReviewSLA is how long a payment held for review waits for a
+// decision before it is voided.
+const DefaultReviewSLA = 24 * time.Hour
+
+// ErrNotHeldForReview is returned when reviewing a payment that is not, or
+// no longer, held for review.
+var ErrNotHeldForReview = errors.New("payment is not held for review")
+
+// WithReviewSLA sets how long payments held for review wait for a decision
+// before they are voided.
+func WithReviewSLA(sla time.Duration) Option {
+	return func(s *Service) {
+		s.reviewSLA = sla
+	}
+}
+
+// ListHeldPayments returns the payments of every merchant waiting for a
+// manual review, oldest first.
+func (s *Service) ListHeldPayments(ctx context.Context, limit int) ([]*Payment, error) {
+	held, err := s.repo.SearchPayments(ctx, PaymentsQuery{
+		AllMerchants: true,
+		Statuses:     []PaymentStatus{StatusHeldForReview},
+		Limit:        limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list held payments: %w", err)
+	}
+
+	return held, nil
+}
+
+// ApproveReview captures a payment held for review.
+func (s *Service) ApproveReview(ctx context.Context, id, reviewer string) (*Payment, error) {
+	return s.review(ctx, id, reviewer, ReviewApproved)
+}
+
+// RejectReview voids a payment held for review.
+func (s *Service) RejectReview(ctx context.Context, id, reviewer string) (*Payment, error) {
+	return s.review(ctx, id, reviewer, ReviewRejected)
+}
+
+func (s *Service) review(ctx context.Context, id, reviewer string, decision ReviewDecision) (*Payment, error) {
+	if reviewer == "" {
+		return nil, &InvalidPaymentRequestErr{Field: "reviewer", Message: "the reviewer must be identified"}
+	}
+
+	unlock := s.reviewLocks.lock(id)
+	defer unlock()
+
+	payment, err := s.GetPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Status != StatusHeldForReview {
+		return nil, ErrNotHeldForReview
+	}
+
+	if err := s.decide(ctx, payment, reviewer, decision); err != nil {
+		return nil, err
+	}
+	auditChange(ctx, payment, StatusHeldForReview.String())
+
+	return payment, nil
+}
+
+// decide captures or voids the held payment with the bank and persists the
+// decision.
+func (s *Service) decide(ctx context.Context, payment *Payment, reviewer string, decision ReviewDecision) error {
+	status := StatusVoided
+	if decision == ReviewApproved {
+		status = StatusCaptured
+		if err := s.bank.Capture(ctx, payment.AuthorizationCode, payment.Amount); err != nil {
+			return fmt.Errorf("capture payment %s: %w", payment.ID, err)
+		}
+	} else if err := s.bank.Void(ctx, payment.AuthorizationCode); err != nil {
+		return fmt.Errorf("void payment %s: %w", payment.ID, err)
+	}
+
+	now := s.clock.Now().UTC()
+
+	payment.Status = status
+	payment.UpdatedAt = now
+	payment.Review.Decision = decision
+	payment.Review.ReviewedBy = reviewer
+	payment.Review.ReviewedAt = &now
+
+	if err := s.repo.UpdatePayment(ctx, payment); err != nil {
+		return fmt.Errorf("persist payment %s: %w", payment.ID, err)
+	}
+	s.notify(payment)
+
+	return nil
+}
+
+// ExpireReviews voids the held payments whose review deadline passed. It
+// returns how many payments were voided.
+func (s *Service) ExpireReviews(ctx context.Context) (int, error) {
+	held, err := s.ListHeldPayments(ctx, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	now := s.clock.Now()
+	voided := 0
+	var errs []error
+
+	for _, p := range held {
+		if p.Review == nil || p.Review.Deadline.After(now) {
+			continue
+		}
+
+		// Keep going so one payment the bank cannot void does not hold
+		// back the others; it is retried on the next sweep.
+		expired, err := s.expireReview(ctx, p.ID, now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if expired {
+			voided++
+		}
+	}
+
+	return voided, errors.Join(errs...)
+}
+
+// expireReview voids the held payment unless it was reviewed since it was
+// listed. It reports whether the payment was voided.
+func (s *Service) expireReview(ctx context.Context, id string, now time.Time) (bool, error) {
+	unlock := s.reviewLocks.lock(id)
+	defer unlock()
+
+	payment, err := s.GetPayment(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	if payment.Status != StatusHeldForReview || payment.Review == nil || payment.Review.Deadline.After(now) {
+		return false, nil
+	}
+
+	if err := s.decide(ctx, payment, "", ReviewExpired); err != nil {
+		return false, err
+	}
+	s.auditSystemChange(ctx, "expire review", payment, StatusHeldForReview)
+
+	return true, nil
+}
+
+// paymentLocks serializes the decisions on each payment, so a payment
+// approved while its review expires is never both captured and voided,
+// while the bank calls for different payments still run concurrently. The
+// zero value is ready to use.
+type paymentLocks struct {
+	mu    sync.Mutex
+	locks map[string]*paymentLock
+}
+
+type paymentLock struct {
+	mu sync.Mutex
+	// waiters counts the holder and the callers waiting for the lock, so
+	// it is dropped once nobody needs it.
+	waiters int
+}
+
+// lock blocks until the payment is free and returns the function that
+// frees it.
+func (l *paymentLocks) lock(id string) (unlock func()) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*paymentLock)
+	}
+	pl, ok := l.locks[id]
+	if !ok {
+		pl = &paymentLock{}
+		l.locks[id] = pl
+	}
+	pl.waiters++
+	l.mu.Unlock()
+
+	pl.mu.Lock()
+
+	return func() {
+		pl.mu.Unlock()
+
+		l.mu.Lock()
+		pl.waiters--
+		if pl.waiters == 0 {
+			delete(l.locks, id)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// RunReviewExpiry voids the payments past their review deadline every
+// interval until the context is cancelled.
+func (s *Service) RunReviewExpiry(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := s.ExpireReviews(ctx); err != nil {
+				slog.Error("expiring reviews", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package payments_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/fraud"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+type reviewFixture struct {
+	service  *payments.Service
+	clock    *clock.Fake
+	captured []string
+	voided   []string
+	// beforeVoid, when set, runs before the bank voids a payment.
+	beforeVoid func()
+}
+
+// newReviewFixture returns a service holding every payment for review.
+func newReviewFixture(t *testing.T) *reviewFixture {
+	t.Helper()
+
+	f := &reviewFixture{clock: clock.NewFake(serviceNow)}
+
+	bank := &mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			return &simulator.AuthorizationResponse{Authorized: true, AuthorizationCode: "AUTH123"}, nil
+		},
+		captureFn: func(ctx context.Context, authorizationCode string, amount int64) error {
+			require.Equal(t, int64(1000), amount)
+			f.captured = append(f.captured, authorizationCode)
+			return nil
+		},
+		voidFn: func(ctx context.Context, authorizationCode string) error {
+			if f.beforeVoid != nil {
+				f.beforeVoid()
+			}
+			f.voided = append(f.voided, authorizationCode)
+			return nil
+		},
+	}
+	screener := &mockScreener{
+		screenFn: func(ctx context.Context, tx fraud.Transaction) (fraud.Decision, error) {
+			return fraud.Decision{Outcome: fraud.OutcomeReview, Score: 60}, nil
+		},
+	}
+
+	f.service = payments.NewService(
+		repository.NewPaymentsRepositoryInMemory(),
+		bank,
+		payments.WithClock(f.clock),
+		payments.WithFraudScreening(screener),
+		payments.WithReviewSLA(time.Hour),
+	)
+
+	return f
+}
+
+func (f *reviewFixture) createHeldPayment(t *testing.T) *payments.Payment {
+	t.Helper()
+
+	payment, err := f.service.CreatePayment(context.Background(), validPaymentRequest())
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusHeldForReview, payment.Status)
+	require.Equal(t, serviceNow.Add(time.Hour), payment.Review.Deadline)
+	require.NotNil(t, payment.AuthorizedAt)
+
+	return payment
+}
+
+func TestService_ApproveReview(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	f := newReviewFixture(t)
+	held := f.createHeldPayment(t)
+
+	listed, err := f.service.ListHeldPayments(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	require.Equal(t, held.ID, listed[0].ID)
+
+	_, err = f.service.ApproveReview(ctx, held.ID, "")
+	var invalidErr *payments.InvalidPaymentRequestErr
+	require.ErrorAs(t, err, &invalidErr)
+
+	approved, err := f.service.ApproveReview(ctx, held.ID, "analyst")
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusCaptured, approved.Status)
+	require.Equal(t, payments.ReviewApproved, approved.Review.Decision)
+	require.Equal(t, "analyst", approved.Review.ReviewedBy)
+	require.Equal(t, []string{"AUTH123"}, f.captured)
+
+	_, err = f.service.RejectReview(ctx, held.ID, "analyst")
+	require.ErrorIs(t, err, payments.ErrNotHeldForReview)
+
+	_, err = f.service.ApproveReview(ctx, "unknown", "analyst")
+	require.ErrorIs(t, err, payments.NotFoundPaymentErr)
+
+	listed, err = f.service.ListHeldPayments(ctx, 0)
+	require.NoError(t, err)
+	require.Empty(t, listed)
+}
+
+func TestService_RejectReview(t *testing.T) {
+	t.Parallel()
+
+	f := newReviewFixture(t)
+	held := f.createHeldPayment(t)
+
+	rejected, err := f.service.RejectReview(context.Background(), held.ID, "analyst")
+
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusVoided, rejected.Status)
+	require.Equal(t, payments.ReviewRejected, rejected.Review.Decision)
+	require.Equal(t, []string{"AUTH123"}, f.voided)
+	require.Empty(t, f.captured)
+}
+
+func TestService_ExpireReviews(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	f := newReviewFixture(t)
+	first := f.createHeldPayment(t)
+
+	f.clock.Advance(30 * time.Minute)
+	second, err := f.service.CreatePayment(ctx, validPaymentRequest())
+	require.NoError(t, err)
+
+	f.clock.Advance(30 * time.Minute)
+	voided, err := f.service.ExpireReviews(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, voided)
+
+	expired, err := f.service.GetPayment(ctx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusVoided, expired.Status)
+	require.Equal(t, payments.ReviewExpired, expired.Review.Decision)
+
+	stillHeld, err := f.service.GetPayment(ctx, second.ID)
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusHeldForReview, stillHeld.Status)
+}
+
+func TestService_ExpireReviews_DoesNotBlockOtherReviews(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	f := newReviewFixture(t)
+	f.createHeldPayment(t)
+
+	f.clock.Advance(30 * time.Minute)
+	other, err := f.service.CreatePayment(ctx, validPaymentRequest())
+	require.NoError(t, err)
+	f.clock.Advance(30 * time.Minute)
+
+	voiding, release := make(chan struct{}), make(chan struct{})
+	f.beforeVoid = func() {
+		close(voiding)
+		<-release
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := f.service.ExpireReviews(ctx)
+		done <- err
+	}()
+	<-voiding
+
+	// The bank voiding the expired payment does not hold back a decision
+	// on another one.
+	approved, err := f.service.ApproveReview(ctx, other.ID, "analyst")
+	require.NoError(t, err)
+	require.Equal(t, payments.StatusCaptured, approved.Status)
+
+	close(release)
+	require.NoError(t, <-done)
+}
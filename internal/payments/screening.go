@@ -0,0 +1,162 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/fraud"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/lists"
+)
+
+// WithFraudScreening screens every payment before it is sent to the bank.
+func WithFraudScreening(screener fraud.Screener) Option {
+	return func(s *Service) {
+		s.screener = screener
+	}
+}
+
+// WithLists checks every payment against the blocklists and allowlists
+// before it is screened for fraud.
+func WithLists(matcher lists.Matcher) Option {
+	return func(s *Service) {
+		s.lists = matcher
+	}
+}
+
+// WithCardFingerprintKey sets the key of the card fingerprints. Without it a
+// random key is used, so fingerprints change on every restart.
+func WithCardFingerprintKey(key []byte) Option {
+	return func(s *Service) {
+		s.fingerprintKey = key
+	}
+}
+
+// screen evaluates the payment against the lists and the fraud rules and
+// records the result on it. It reports whether the payment was blocked, in
+// which case it is persisted and must not reach the bank.
+func (s *Service) screen(ctx context.Context, payment *Payment, paymentReq PaymentRequest) (bool, error) {
+	if s.lists != nil {
+		entry, err := s.lists.Match(ctx, listsSubject(payment, paymentReq))
+		if err != nil {
+			return false, fmt.Errorf("match lists: %w", err)
+		}
+
+		switch {
+		case entry == nil:
+		case entry.List == lists.Allowlist:
+			payment.RiskOutcome = string(fraud.OutcomeAllow)
+			return false, s.recordAttempt(ctx, payment)
+		default:
+			slog.InfoContext(ctx, "payment blocklisted", "entry_id", entry.ID, "kind", entry.Kind)
+			payment.RiskScore = fraud.MaxScore
+			payment.RiskOutcome = string(fraud.OutcomeBlock)
+			return true, s.block(ctx, payment)
+		}
+	}
+
+	if s.screener == nil {
+		return false, nil
+	}
+
+	decision, err := s.screener.Screen(ctx, fraud.Transaction{
+		MerchantID:      payment.MerchantID,
+		BIN:             paymentReq.CardNumber[:6],
+		CardFingerprint: payment.CardFingerprint,
+		Currency:        payment.Currency,
+		Amount:          payment.Amount,
+	})
+	if err != nil {
+		return false, fmt.Errorf("screen payment: %w", err)
+	}
+
+	payment.RiskScore = decision.Score
+	payment.RiskOutcome = string(decision.Outcome)
+
+	if decision.Outcome != fraud.OutcomeBlock {
+		return false, nil
+	}
+
+	return true, s.block(ctx, payment)
+}
+
+func (s *Service) block(ctx context.Context, payment *Payment) error {
+	payment.Status = StatusBlocked
+	if err := s.repo.AddPayment(ctx, payment); err != nil {
+		return fmt.Errorf("persist payment: %w", err)
+	}
+	s.notify(payment)
+
+	return nil
+}
+
+func listsSubject(payment *Payment, paymentReq PaymentRequest) lists.Subject {
+	subject := lists.Subject{
+		MerchantID:      payment.MerchantID,
+		CardFingerprint: payment.CardFingerprint,
+		BIN:             paymentReq.CardNumber[:8],
+		Reference:       payment.Reference,
+	}
+	if payment.BillingAddress != nil {
+		subject.Country = payment.BillingAddress.Country
+	}
+	return subject
+}
+
+// recordAttempt lets the velocity rules count the payments that skip
+// screening.
+func (s *Service) recordAttempt(ctx context.Context, payment *Payment) error {
+	if s.screener == nil {
+		return nil
+	}
+
+	err := s.screener.RecordAttempt(ctx, fraud.Transaction{
+		MerchantID:      payment.MerchantID,
+		CardFingerprint: payment.CardFingerprint,
+		Currency:        payment.Currency,
+		Amount:          payment.Amount,
+	})
+	if err != nil {
+		return fmt.Errorf("record attempt: %w", err)
+	}
+
+	return nil
+}
+
+// recordDecline lets the velocity rules count the payments declined by the
+// bank. Failures are only logged, the payment outcome is already known.
+func (s *Service) recordDecline(ctx context.Context, payment *Payment) {
+	if s.screener == nil {
+		return
+	}
+
+	err := s.screener.RecordDecline(ctx, fraud.Transaction{
+		MerchantID:      payment.MerchantID,
+		CardFingerprint: payment.CardFingerprint,
+		Currency:        payment.Currency,
+		Amount:          payment.Amount,
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "recording declined payment", "payment_id", payment.ID, "error", err)
+	}
+}
+
+// cardFingerprint identifies a card without revealing its number: it is
+// the HMAC-SHA256 of the number, so it cannot be brute-forced from the
+// small space of valid card numbers without the key.
+func (s *Service) cardFingerprint(cardNumber string) string {
+	mac := hmac.New(sha256.New, s.fingerprintKey)
+	mac.Write([]byte(cardNumber))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newFingerprintKey() []byte {
+	key := make([]byte, 32)
+	// rand.Read never returns an error.
+	_, _ = rand.Read(key)
+	return key
+}
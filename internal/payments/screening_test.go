@@ -0,0 +1,243 @@
+package payments_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/fraud"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/lists"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+type mockScreener struct {
+	screenFn        func(ctx context.Context, tx fraud.Transaction) (fraud.Decision, error)
+	recordAttemptFn func(ctx context.Context, tx fraud.Transaction) error
+	recordDeclineFn func(ctx context.Context, tx fraud.Transaction) error
+}
+
+func (m *mockScreener) Screen(ctx context.Context, tx fraud.Transaction) (fraud.Decision, error) {
+	return m.screenFn(ctx, tx)
+}
+
+func (m *mockScreener) RecordAttempt(ctx context.Context, tx fraud.Transaction) error {
+	if m.recordAttemptFn == nil {
+		return nil
+	}
+	return m.recordAttemptFn(ctx, tx)
+}
+
+func (m *mockScreener) RecordDecline(ctx context.Context, tx fraud.Transaction) error {
+	return m.recordDeclineFn(ctx, tx)
+}
+
+func TestService_CreatePayment_FraudScreening(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		decision       fraud.Decision
+		expectedStatus payments.PaymentStatus
+		bankCalled     bool
+	}{
+		{
+			name:           "allowed",
+			decision:       fraud.Decision{Outcome: fraud.OutcomeAllow, Score: 10},
+			expectedStatus: payments.StatusAuthorized,
+			bankCalled:     true,
+		},
+		{
+			name:           "flagged for review is authorized and held",
+			decision:       fraud.Decision{Outcome: fraud.OutcomeReview, Score: 60},
+			expectedStatus: payments.StatusHeldForReview,
+			bankCalled:     true,
+		},
+		{
+			name:           "blocked",
+			decision:       fraud.Decision{Outcome: fraud.OutcomeBlock, Score: 90},
+			expectedStatus: payments.StatusBlocked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := repository.NewPaymentsRepositoryInMemory()
+			bankCalled := false
+			bank := &mockBankingSimulator{
+				authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+					bankCalled = true
+					return &simulator.AuthorizationResponse{Authorized: true, AuthorizationCode: "AUTH123"}, nil
+				},
+			}
+			screener := &mockScreener{
+				screenFn: func(ctx context.Context, tx fraud.Transaction) (fraud.Decision, error) {
+					require.Equal(t, "411111", tx.BIN)
+					require.NotEmpty(t, tx.CardFingerprint)
+					require.NotContains(t, tx.CardFingerprint, "4111111111111111")
+					return tt.decision, nil
+				},
+			}
+
+			service := newTestService(repo, bank, payments.WithFraudScreening(screener))
+
+			payment, err := service.CreatePayment(context.Background(), validPaymentRequest())
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedStatus, payment.Status)
+			require.Equal(t, tt.decision.Score, payment.RiskScore)
+			require.Equal(t, string(tt.decision.Outcome), payment.RiskOutcome)
+			require.Equal(t, tt.bankCalled, bankCalled)
+
+			stored, err := service.GetPayment(context.Background(), payment.ID)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedStatus, stored.Status)
+		})
+	}
+}
+
+func TestService_CreatePayment_CardFingerprint(t *testing.T) {
+	t.Parallel()
+
+	bank := &mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			return &simulator.AuthorizationResponse{Authorized: false}, nil
+		},
+	}
+
+	var declined []fraud.Transaction
+	screener := &mockScreener{
+		screenFn: func(ctx context.Context, tx fraud.Transaction) (fraud.Decision, error) {
+			return fraud.Decision{Outcome: fraud.OutcomeAllow}, nil
+		},
+		recordDeclineFn: func(ctx context.Context, tx fraud.Transaction) error {
+			declined = append(declined, tx)
+			return nil
+		},
+	}
+
+	newService := func(key string) *payments.Service {
+		return newTestService(
+			repository.NewPaymentsRepositoryInMemory(),
+			bank,
+			payments.WithFraudScreening(screener),
+			payments.WithCardFingerprintKey([]byte(key)),
+		)
+	}
+
+	first, err := newService("key_a").CreatePayment(context.Background(), validPaymentRequest())
+	require.NoError(t, err)
+	second, err := newService("key_a").CreatePayment(context.Background(), validPaymentRequest())
+	require.NoError(t, err)
+	otherKey, err := newService("key_b").CreatePayment(context.Background(), validPaymentRequest())
+	require.NoError(t, err)
+
+	require.Len(t, first.CardFingerprint, 64)
+	require.Equal(t, first.CardFingerprint, second.CardFingerprint)
+	require.NotEqual(t, first.CardFingerprint, otherKey.CardFingerprint)
+
+	// Every decline is reported with the card fingerprint.
+	require.Len(t, declined, 3)
+	require.Equal(t, first.CardFingerprint, declined[0].CardFingerprint)
+}
+
+func TestService_CreatePayment_Lists(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		entry           *lists.NewEntry
+		expectedStatus  payments.PaymentStatus
+		expectedOutcome string
+		screened        bool
+		recorded        bool
+	}{
+		{
+			name:           "no entry",
+			expectedStatus: payments.StatusAuthorized,
+			screened:       true,
+		},
+		{
+			name:            "blocklisted BIN",
+			entry:           &lists.NewEntry{List: lists.Blocklist, Kind: lists.KindBIN, Value: "41111111"},
+			expectedStatus:  payments.StatusBlocked,
+			expectedOutcome: "block",
+		},
+		{
+			name:            "blocklisted billing country",
+			entry:           &lists.NewEntry{List: lists.Blocklist, Kind: lists.KindCountry, Value: "GB"},
+			expectedStatus:  payments.StatusBlocked,
+			expectedOutcome: "block",
+		},
+		{
+			name:            "blocklisted reference of another merchant",
+			entry:           &lists.NewEntry{List: lists.Blocklist, Kind: lists.KindMerchantReference, Value: "ORD-1", MerchantID: "merchant_b"},
+			expectedStatus:  payments.StatusAuthorized,
+			expectedOutcome: "",
+			screened:        true,
+		},
+		{
+			name:            "allowlisted BIN skips fraud screening but counts the attempt",
+			entry:           &lists.NewEntry{List: lists.Allowlist, Kind: lists.KindBIN, Value: "411111"},
+			expectedStatus:  payments.StatusAuthorized,
+			expectedOutcome: "allow",
+			recorded:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			listsSvc := lists.NewService(repository.NewListsRepositoryInMemory())
+			if tt.entry != nil {
+				tt.entry.Reason = "test"
+				_, err := listsSvc.CreateEntry(context.Background(), "analyst", *tt.entry)
+				require.NoError(t, err)
+			}
+
+			bankCalled := false
+			bank := &mockBankingSimulator{
+				authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+					bankCalled = true
+					return &simulator.AuthorizationResponse{Authorized: true, AuthorizationCode: "AUTH123"}, nil
+				},
+			}
+			screened, recorded := false, false
+			screener := &mockScreener{
+				screenFn: func(ctx context.Context, tx fraud.Transaction) (fraud.Decision, error) {
+					screened = true
+					return fraud.Decision{}, nil
+				},
+				recordAttemptFn: func(ctx context.Context, tx fraud.Transaction) error {
+					recorded = true
+					return nil
+				},
+			}
+
+			service := newTestService(
+				repository.NewPaymentsRepositoryInMemory(),
+				bank,
+				payments.WithLists(listsSvc),
+				payments.WithFraudScreening(screener),
+			)
+
+			req := validPaymentRequest()
+			req.MerchantID = "merchant_a"
+			req.Reference = "ORD-1"
+			req.BillingAddress = &payments.BillingAddress{Country: "GB"}
+
+			payment, err := service.CreatePayment(context.Background(), req)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedStatus, payment.Status)
+			require.Equal(t, tt.expectedOutcome, payment.RiskOutcome)
+			require.Equal(t, tt.expectedStatus != payments.StatusBlocked, bankCalled)
+			require.Equal(t, tt.screened, screened)
+			require.Equal(t, tt.recorded, recorded)
+		})
+	}
+}
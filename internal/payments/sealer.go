@@ -0,0 +1,74 @@
+package payments
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+)
+
+// ErrUnsealable is returned when sealed card data cannot be decrypted, for
+// example because it was sealed with another key.
+var ErrUnsealable = errors.New("sealed authorization request cannot be opened")
+
+// CardSealer encrypts authorization requests, which carry full card data,
+// so they can be persisted until the bank authorizes the payment.
+type CardSealer struct {
+	aead cipher.AEAD
+}
+
+// NewCardSealer builds a sealer using AES-GCM with the given 16, 24 or 32
+// bytes key.
+func NewCardSealer(key []byte) (*CardSealer, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("card sealer key: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("card sealer cipher: %w", err)
+	}
+
+	return &CardSealer{aead: aead}, nil
+}
+
+// Seal encrypts the request.
+func (s *CardSealer) Seal(req simulator.AuthorizationRequest) ([]byte, error) {
+	plaintext, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("encode authorization request: %w", err)
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	return s.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open decrypts a sealed request.
+func (s *CardSealer) Open(sealed []byte) (simulator.AuthorizationRequest, error) {
+	var req simulator.AuthorizationRequest
+
+	if len(sealed) < s.aead.NonceSize() {
+		return req, ErrUnsealable
+	}
+
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return req, ErrUnsealable
+	}
+
+	if err := json.Unmarshal(plaintext, &req); err != nil {
+		return req, fmt.Errorf("%w: %v", ErrUnsealable, err)
+	}
+
+	return req, nil
+}
@@ -4,31 +4,296 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"strings"
+	"time"
 
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/audit"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/currency"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/fraud"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/lists"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/threeds"
 )
 
+// DefaultAuthorizationTTL is how long an authorization remains valid when no
+// explicit window is configured.
+const DefaultAuthorizationTTL = 7 * 24 * time.Hour
+
+// DefaultCurrencies are the currencies enabled for every merchant when no
+// explicit configuration is given.
+var DefaultCurrencies = []string{"BRL", "EUR", "USD"}
+
 type Service struct {
-	repo PaymentsRepository
-	bank simulator.BankingSimulator
+	repo       PaymentsRepository
+	bank       simulator.BankingSimulator
+	currencies *currency.Enabled
+	clock      clock.Clock
+	expiry     ExpiryPolicy
+	validator  *Validator
+	authTTL    time.Duration
+	avs        AVSPolicy
+
+	authenticator threeds.Authenticator
+	callbackURL   string
+	sca           SCAPolicy
+	pending       pendingAuthentications
+	// authenticationTTL is how long the cardholder has to complete a
+	// challenge.
+	authenticationTTL time.Duration
+	exemptions        *ExemptionEngine
+
+	async          *asyncAuthorizer
+	screener       fraud.Screener
+	lists          lists.Matcher
+	fingerprintKey []byte
+
+	reviewSLA   time.Duration
+	reviewLocks paymentLocks
+
+	broker    *Broker
+	observers []Observer
+	// auditTrail records the changes made by the sweeps and workers, which
+	// no audited call covers.
+	auditTrail *audit.Trail
+	inFlight   *inFlight
+}
+
+// AVSPolicy decides what happens to an authorized payment whose billing
+// address does not match the one known by the issuer.
+type AVSPolicy struct {
+	// VoidOnMismatch applies to merchants without an explicit setting.
+	VoidOnMismatch bool
+	// Merchants overrides VoidOnMismatch per merchant.
+	Merchants map[string]bool
+}
+
+func (p AVSPolicy) voidOnMismatch(merchantID string) bool {
+	if void, ok := p.Merchants[merchantID]; ok {
+		return void
+	}
+	return p.VoidOnMismatch
+}
+
+// Option configures optional dependencies of the Service.
+type Option func(*Service)
+
+// WithEnabledCurrencies sets the currencies each merchant is allowed to process.
+func WithEnabledCurrencies(enabled *currency.Enabled) Option {
+	return func(s *Service) {
+		s.currencies = enabled
+	}
+}
+
+// WithClock sets the clock used by every time-dependent rule of the service.
+func WithClock(clk clock.Clock) Option {
+	return func(s *Service) {
+		s.clock = clk
+	}
 }
 
-func NewService(repo PaymentsRepository, bank simulator.BankingSimulator) *Service {
-	return &Service{repo, bank}
+// WithExpiryPolicy sets the rules used to decide whether a card has expired.
+func WithExpiryPolicy(policy ExpiryPolicy) Option {
+	return func(s *Service) {
+		s.expiry = policy
+	}
+}
+
+// WithAuthorizationTTL sets how long an uncaptured authorization remains
+// valid before it is expired.
+func WithAuthorizationTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		s.authTTL = ttl
+	}
+}
+
+// WithAVSPolicy sets whether AVS mismatches void otherwise authorized payments.
+func WithAVSPolicy(policy AVSPolicy) Option {
+	return func(s *Service) {
+		s.avs = policy
+	}
+}
+
+func NewService(repo PaymentsRepository, bank simulator.BankingSimulator, opts ...Option) *Service {
+	s := &Service{
+		repo:      repo,
+		bank:      bank,
+		authTTL:   DefaultAuthorizationTTL,
+		reviewSLA: DefaultReviewSLA,
+
+		authenticationTTL: DefaultAuthenticationTTL,
+		broker:            NewBroker(),
+		inFlight:          newInFlight(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.currencies == nil {
+		// DefaultCurrencies are all part of the registry, so this cannot fail.
+		s.currencies, _ = currency.NewEnabled(DefaultCurrencies, nil)
+	}
+
+	if s.clock == nil {
+		s.clock = clock.New()
+	}
+
+	if s.fingerprintKey == nil {
+		s.fingerprintKey = newFingerprintKey()
+	}
+
+	s.validator = NewValidator(s.clock, s.expiry)
+
+	return s
 }
 
-func (s *Service) CreatePayment(ctx context.Context, paymentReq PaymentRequest) (*Payment, error) {
-	if err := paymentReq.Validate(); err != nil {
-		return nil, fmt.Errorf("payment validation: %w", err)
+func (s *Service) CreatePayment(ctx context.Context, paymentReq PaymentRequest) (payment *Payment, err error) {
+	ctx, span := tracer.Start(ctx, "payments.Service.CreatePayment", paymentRequestAttrs(paymentReq))
+	defer func() {
+		if err == nil {
+			auditChange(ctx, payment, "")
+		}
+		endSpan(span, payment, err)
+	}()
+
+	ctx, done := s.inFlight.track(ctx)
+	defer done()
+
+	if err := s.validate(paymentReq); err != nil {
+		return nil, err
+	}
+
+	payment = s.newPayment(paymentReq)
+
+	blocked, err := s.screen(ctx, payment, paymentReq)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return payment, nil
+	}
+
+	authReq := newAuthorizationRequest(paymentReq)
+
+	if s.requiresAuthentication(paymentReq) {
+		exemption := s.exemption(paymentReq)
+		if exemption == ExemptionNone {
+			return s.startAuthentication(ctx, payment, authReq)
+		}
+
+		payment.Exemption = exemption
+		authReq.Exemption = string(exemption)
 	}
 
-	res, err := s.bank.Authorize(ctx, simulator.AuthorizationRequest{
-		CardNumber: paymentReq.CardNumber,
-		ExpiryDate: fmt.Sprintf("%02d/%d", paymentReq.ExpiryMonth, paymentReq.ExpiryYear),
-		Currency:   paymentReq.Currency,
-		Amount:     paymentReq.Amount,
-		CVV:        paymentReq.CVV,
-	})
+	// Nothing was sent to the bank yet, the payment can be dropped.
+	if err := notSent(ctx); err != nil {
+		return nil, err
+	}
+
+	err = s.authorize(ctx, payment, authReq)
+	if errors.Is(err, errSoftDeclined) {
+		// The issuer refused the exemption, fall back to a challenge.
+		authReq.Exemption = ""
+		return s.startAuthentication(ctx, payment, authReq)
+	}
+	if interrupted(ctx, err) {
+		s.holdForReconciliation(payment)
+	} else if err != nil {
+		return nil, err
+	}
+
+	// The bank was called, its outcome is recorded even if the caller went
+	// away.
+	if err := s.repo.AddPayment(context.WithoutCancel(ctx), payment); err != nil {
+		return nil, fmt.Errorf("persist payment: %w", err)
+	}
+	warnReconciliation(ctx, payment)
+	s.notify(payment)
+
+	return payment, nil
+}
+
+func (s *Service) validate(paymentReq PaymentRequest) error {
+	if err := s.validator.Validate(paymentReq); err != nil {
+		return fmt.Errorf("payment validation: %w", err)
+	}
+
+	if !s.currencies.IsEnabled(paymentReq.MerchantID, paymentReq.Currency) {
+		return fmt.Errorf("payment validation: %w", &InvalidPaymentRequestErr{
+			Field: "currency",
+			Message: fmt.Sprintf(
+				"currency must be one of: %s",
+				strings.Join(s.currencies.Codes(paymentReq.MerchantID), ", "),
+			),
+		})
+	}
+
+	return nil
+}
+
+// newPayment builds a pending payment from the request, without any card
+// data other than the last four digits and the fingerprint.
+func (s *Service) newPayment(paymentReq PaymentRequest) *Payment {
+	cur, _ := currency.Lookup(paymentReq.Currency)
+	now := s.clock.Now().UTC()
+
+	return &Payment{
+		MerchantID:         paymentReq.MerchantID,
+		Status:             StatusPending,
+		CardNumberLastFour: paymentReq.CardNumber[len(paymentReq.CardNumber)-4:],
+		CardFingerprint:    s.cardFingerprint(paymentReq.CardNumber),
+		ExpiryMonth:        paymentReq.ExpiryMonth,
+		ExpiryYear:         paymentReq.ExpiryYear,
+		Currency:           paymentReq.Currency,
+		Amount:             paymentReq.Amount,
+		DisplayAmount:      cur.Format(paymentReq.Amount),
+
+		Reference:           paymentReq.Reference,
+		Description:         paymentReq.Description,
+		StatementDescriptor: paymentReq.StatementDescriptor,
+		Metadata:            maps.Clone(paymentReq.Metadata),
+
+		CardholderName: paymentReq.CardholderName,
+		BillingAddress: paymentReq.BillingAddress,
+
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func newAuthorizationRequest(paymentReq PaymentRequest) simulator.AuthorizationRequest {
+	authReq := simulator.AuthorizationRequest{
+		CardNumber:     paymentReq.CardNumber,
+		ExpiryDate:     fmt.Sprintf("%02d/%d", paymentReq.ExpiryMonth, paymentReq.ExpiryYear),
+		Currency:       paymentReq.Currency,
+		Amount:         paymentReq.Amount,
+		CVV:            paymentReq.CVV,
+		CardholderName: paymentReq.CardholderName,
+
+		MerchantInitiated: paymentReq.MerchantInitiated,
+	}
+
+	if addr := paymentReq.BillingAddress; addr != nil {
+		authReq.BillingAddress = &simulator.Address{
+			Line1:      addr.Line1,
+			Line2:      addr.Line2,
+			City:       addr.City,
+			State:      addr.State,
+			PostalCode: addr.PostalCode,
+			Country:    addr.Country,
+		}
+	}
+
+	return authReq
+}
+
+// authorize asks the bank to authorize the payment and records the outcome
+// on it. Errors are only returned when the outcome is unknown.
+func (s *Service) authorize(ctx context.Context, payment *Payment, authReq simulator.AuthorizationRequest) error {
+	res, err := s.bank.Authorize(ctx, authReq)
 
 	paymentStatus := StatusAuthorized
 
@@ -38,30 +303,60 @@ func (s *Service) CreatePayment(ctx context.Context, paymentReq PaymentRequest)
 			paymentStatus = StatusRejected
 
 		case errors.Is(err, simulator.ErrAuthorizationUnavailable):
-			return nil, err // retry higher up
+			return err // retry higher up
 
 		default:
-			return nil, fmt.Errorf("authorize payment: %w", err)
+			return fmt.Errorf("authorize payment: %w", err)
 		}
 	} else if !res.Authorized {
+		if res.SoftDeclined() && s.canChallenge(authReq) {
+			return errSoftDeclined
+		}
 		paymentStatus = StatusDeclined
 	}
 
-	payment := &Payment{
-		Status:             paymentStatus,
-		CardNumberLastFour: paymentReq.CardNumber[len(paymentReq.CardNumber)-4:],
-		ExpiryMonth:        paymentReq.ExpiryMonth,
-		ExpiryYear:         paymentReq.ExpiryYear,
-		Currency:           paymentReq.Currency,
-		Amount:             paymentReq.Amount,
+	if paymentStatus == StatusAuthorized &&
+		AVSResult(res.AVSResult).IsMismatch() &&
+		s.avs.voidOnMismatch(payment.MerchantID) {
+		if err := s.bank.Void(ctx, res.AuthorizationCode); err != nil {
+			slog.WarnContext(ctx, "voiding payment after AVS mismatch", "error", err)
+		} else {
+			paymentStatus = StatusVoided
+		}
 	}
 
-	err = s.repo.AddPayment(ctx, payment)
-	if err != nil {
-		return nil, fmt.Errorf("persist payment: %w", err)
+	// Payments flagged by the fraud screening keep their authorization but
+	// are only captured once someone approves them.
+	if paymentStatus == StatusAuthorized && payment.RiskOutcome == string(fraud.OutcomeReview) {
+		paymentStatus = StatusHeldForReview
 	}
 
-	return payment, nil
+	if paymentStatus == StatusDeclined {
+		s.recordDecline(ctx, payment)
+	}
+
+	now := s.clock.Now().UTC()
+
+	payment.Status = paymentStatus
+	payment.UpdatedAt = now
+
+	if res != nil {
+		payment.AuthorizationCode = res.AuthorizationCode
+		payment.AVSResult = AVSResult(res.AVSResult)
+		payment.CVVResult = CVVResult(res.CVVResult)
+	}
+
+	if paymentStatus == StatusAuthorized || paymentStatus == StatusHeldForReview {
+		expiresAt := now.Add(s.authTTL)
+		payment.AuthorizedAt = &now
+		payment.ExpiresAt = &expiresAt
+	}
+
+	if paymentStatus == StatusHeldForReview {
+		payment.Review = &Review{Deadline: now.Add(s.reviewSLA)}
+	}
+
+	return nil
 }
 
 func (s *Service) GetPayment(ctx context.Context, id string) (*Payment, error) {
@@ -76,3 +371,62 @@ func (s *Service) GetPayment(ctx context.Context, id string) (*Payment, error) {
 
 	return p, nil
 }
+
+// SubscribePayment streams the changes of a payment made from now on. The
+// returned function must be called once the caller stops listening.
+func (s *Service) SubscribePayment(id string) (<-chan *Payment, func()) {
+	return s.broker.Subscribe(id)
+}
+
+// SearchPayments returns the payments of a merchant matching the query.
+func (s *Service) SearchPayments(ctx context.Context, query PaymentsQuery) ([]*Payment, error) {
+	found, err := s.repo.SearchPayments(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("search payments: %w", err)
+	}
+
+	return found, nil
+}
+
+// ExpireAuthorizations moves every authorization past its ExpiresAt to the
+// expired status. It returns how many payments were expired.
+func (s *Service) ExpireAuthorizations(ctx context.Context) (int, error) {
+	now := s.clock.Now().UTC()
+
+	expired, err := s.repo.ListExpiredAuthorizations(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("list expired authorizations: %w", err)
+	}
+
+	for i, p := range expired {
+		before := p.Status
+		p.Status = StatusExpired
+		p.UpdatedAt = now
+
+		if err := s.repo.UpdatePayment(ctx, p); err != nil {
+			return i, fmt.Errorf("expire payment %s: %w", p.ID, err)
+		}
+		s.notify(p)
+		s.auditSystemChange(ctx, "expire authorization", p, before)
+	}
+
+	return len(expired), nil
+}
+
+// RunAuthorizationExpiry expires authorizations every interval until the
+// context is cancelled.
+func (s *Service) RunAuthorizationExpiry(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := s.ExpireAuthorizations(ctx); err != nil {
+				slog.Error("expiring authorizations", "error", err)
+			}
+		}
+	}
+}
@@ -6,14 +6,22 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/audit"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/banks/simulator"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/currency"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
 	"github.com/stretchr/testify/require"
 )
 
 type mockPaymentsRepository struct {
 	addFn func(ctx context.Context, payment *payments.Payment) error
 	getFn func(ctx context.Context, id string) (*payments.Payment, error)
+
+	updateFn      func(ctx context.Context, payment *payments.Payment) error
+	listExpiredFn func(ctx context.Context, at time.Time) ([]*payments.Payment, error)
+	searchFn      func(ctx context.Context, query payments.PaymentsQuery) ([]*payments.Payment, error)
 }
 
 func (m *mockPaymentsRepository) AddPayment(
@@ -30,11 +38,25 @@ func (m *mockPaymentsRepository) GetPayment(
 	return m.getFn(ctx, id)
 }
 
+func (m *mockPaymentsRepository) UpdatePayment(ctx context.Context, payment *payments.Payment) error {
+	return m.updateFn(ctx, payment)
+}
+
+func (m *mockPaymentsRepository) ListExpiredAuthorizations(ctx context.Context, at time.Time) ([]*payments.Payment, error) {
+	return m.listExpiredFn(ctx, at)
+}
+
+func (m *mockPaymentsRepository) SearchPayments(ctx context.Context, query payments.PaymentsQuery) ([]*payments.Payment, error) {
+	return m.searchFn(ctx, query)
+}
+
 type mockBankingSimulator struct {
 	authorizeFn func(
 		ctx context.Context,
 		req simulator.AuthorizationRequest,
 	) (*simulator.AuthorizationResponse, error)
+	voidFn    func(ctx context.Context, authorizationCode string) error
+	captureFn func(ctx context.Context, authorizationCode string, amount int64) error
 }
 
 func (m *mockBankingSimulator) Authorize(
@@ -44,8 +66,28 @@ func (m *mockBankingSimulator) Authorize(
 	return m.authorizeFn(ctx, req)
 }
 
+func (m *mockBankingSimulator) Void(ctx context.Context, authorizationCode string) error {
+	return m.voidFn(ctx, authorizationCode)
+}
+
+func (m *mockBankingSimulator) Capture(ctx context.Context, authorizationCode string, amount int64) error {
+	return m.captureFn(ctx, authorizationCode, amount)
+}
+
+// serviceNow pins the clock used by the service tests.
+var serviceNow = time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC)
+
+func newTestService(
+	repo payments.PaymentsRepository,
+	bank simulator.BankingSimulator,
+	opts ...payments.Option,
+) *payments.Service {
+	opts = append([]payments.Option{payments.WithClock(clock.NewFake(serviceNow))}, opts...)
+	return payments.NewService(repo, bank, opts...)
+}
+
 func validPaymentRequest() payments.PaymentRequest {
-	now := time.Now()
+	now := serviceNow
 
 	return payments.PaymentRequest{
 		CardNumber:  "4111111111111111",
@@ -63,7 +105,7 @@ func TestService_CreatePayment_ValidationError(t *testing.T) {
 	repo := &mockPaymentsRepository{}
 	bank := &mockBankingSimulator{}
 
-	service := payments.NewService(repo, bank)
+	service := newTestService(repo, bank)
 
 	req := validPaymentRequest()
 	req.CardNumber = "123"
@@ -87,7 +129,7 @@ func TestService_CreatePayment_BankError(t *testing.T) {
 		},
 	}
 
-	service := payments.NewService(repo, bank)
+	service := newTestService(repo, bank)
 
 	payment, err := service.CreatePayment(context.Background(), validPaymentRequest())
 
@@ -115,9 +157,11 @@ func TestService_CreatePayment_Authorized(t *testing.T) {
 		},
 	}
 
-	service := payments.NewService(repo, bank)
+	service := newTestService(repo, bank)
 
 	paymentReq := validPaymentRequest()
+	paymentReq.Reference = "ORD-1"
+	paymentReq.Metadata = map[string]string{"channel": "web"}
 	payment, err := service.CreatePayment(context.Background(), paymentReq)
 
 	require.NoError(t, err)
@@ -130,6 +174,55 @@ func TestService_CreatePayment_Authorized(t *testing.T) {
 	require.Equal(t, paymentReq.ExpiryYear, payment.ExpiryYear)
 	require.Equal(t, paymentReq.Currency, payment.Currency)
 	require.Equal(t, paymentReq.Amount, payment.Amount)
+	require.Equal(t, "10.00", payment.DisplayAmount)
+	require.Equal(t, "ORD-1", payment.Reference)
+	require.Equal(t, map[string]string{"channel": "web"}, payment.Metadata)
+	require.Equal(t, serviceNow, payment.CreatedAt)
+	require.Equal(t, serviceNow, payment.UpdatedAt)
+	require.Equal(t, serviceNow, *payment.AuthorizedAt)
+	require.Equal(t, serviceNow.Add(payments.DefaultAuthorizationTTL), *payment.ExpiresAt)
+}
+
+func TestService_CreatePayment_CurrencyNotEnabled(t *testing.T) {
+	t.Parallel()
+
+	enabled, err := currency.NewEnabled(
+		[]string{"USD"},
+		map[string][]string{"merchant_kw": {"KWD"}},
+	)
+	require.NoError(t, err)
+
+	repo := &mockPaymentsRepository{
+		addFn: func(ctx context.Context, p *payments.Payment) error {
+			return nil
+		},
+	}
+	bank := &mockBankingSimulator{
+		authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+			return &simulator.AuthorizationResponse{Authorized: true}, nil
+		},
+	}
+
+	service := newTestService(repo, bank, payments.WithEnabledCurrencies(enabled))
+
+	req := validPaymentRequest()
+	req.Currency = "KWD"
+
+	payment, err := service.CreatePayment(context.Background(), req)
+
+	require.Nil(t, payment)
+	var invalidErr *payments.InvalidPaymentRequestErr
+	require.ErrorAs(t, err, &invalidErr)
+	require.Equal(t, "currency", invalidErr.Field)
+
+	req.MerchantID = "merchant_kw"
+	req.Amount = 1099
+
+	payment, err = service.CreatePayment(context.Background(), req)
+
+	require.NoError(t, err)
+	require.Equal(t, "merchant_kw", payment.MerchantID)
+	require.Equal(t, "1.099", payment.DisplayAmount)
 }
 
 func TestService_CreatePayment_Declined(t *testing.T) {
@@ -148,12 +241,15 @@ func TestService_CreatePayment_Declined(t *testing.T) {
 		},
 	}
 
-	service := payments.NewService(repo, bank)
+	service := newTestService(repo, bank)
 
 	payment, err := service.CreatePayment(context.Background(), validPaymentRequest())
 
 	require.NoError(t, err)
 	require.Equal(t, payments.StatusDeclined, payment.Status)
+	require.Equal(t, serviceNow, payment.CreatedAt)
+	require.Nil(t, payment.AuthorizedAt)
+	require.Nil(t, payment.ExpiresAt)
 }
 
 func TestService_CreatePayment_RepositoryError(t *testing.T) {
@@ -171,7 +267,7 @@ func TestService_CreatePayment_RepositoryError(t *testing.T) {
 		},
 	}
 
-	service := payments.NewService(repo, bank)
+	service := newTestService(repo, bank)
 
 	payment, err := service.CreatePayment(context.Background(), validPaymentRequest())
 
@@ -191,10 +287,134 @@ func TestService_GetPayment(t *testing.T) {
 		},
 	}
 
-	service := payments.NewService(repo, nil)
+	service := newTestService(repo, nil)
 
 	payment, err := service.GetPayment(context.Background(), "123")
 
 	require.NoError(t, err)
 	require.Equal(t, expected, payment)
 }
+
+func TestService_ExpireAuthorizations(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := serviceNow.Add(-time.Minute)
+	updated := map[string]payments.PaymentStatus{}
+
+	repo := &mockPaymentsRepository{
+		listExpiredFn: func(ctx context.Context, at time.Time) ([]*payments.Payment, error) {
+			require.Equal(t, serviceNow, at)
+			return []*payments.Payment{
+				{ID: "1", MerchantID: "merchant_a", Status: payments.StatusAuthorized, ExpiresAt: &expiresAt},
+				{ID: "2", MerchantID: "merchant_a", Status: payments.StatusAuthorized, ExpiresAt: &expiresAt},
+			}, nil
+		},
+		updateFn: func(ctx context.Context, p *payments.Payment) error {
+			updated[p.ID] = p.Status
+			return nil
+		},
+	}
+
+	trail := audit.NewTrail(repository.NewAuditRepositoryInMemory())
+	service := newTestService(repo, nil, payments.WithAuthorizationTTL(time.Hour), payments.WithAuditTrail(trail))
+
+	n, err := service.ExpireAuthorizations(context.Background())
+
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, map[string]payments.PaymentStatus{
+		"1": payments.StatusExpired,
+		"2": payments.StatusExpired,
+	}, updated)
+
+	// The sweep has no caller, the changes are recorded for the system.
+	entries, err := trail.Query(context.Background(), audit.Query{ActorID: audit.SystemActor})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "expire authorization", entries[0].Operation)
+	require.Equal(t, "merchant_a", entries[0].MerchantID)
+	require.Equal(t, "1", entries[0].PaymentID)
+	require.Equal(t, "authorized", entries[0].BeforeStatus)
+	require.Equal(t, "expired", entries[0].AfterStatus)
+}
+
+func TestService_CreatePayment_AVSMismatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		policy         payments.AVSPolicy
+		merchantID     string
+		expectedStatus payments.PaymentStatus
+		expectVoid     bool
+	}{
+		{
+			name:           "kept authorized when the policy is disabled",
+			policy:         payments.AVSPolicy{},
+			expectedStatus: payments.StatusAuthorized,
+		},
+		{
+			name:           "voided when the policy is enabled",
+			policy:         payments.AVSPolicy{VoidOnMismatch: true},
+			expectedStatus: payments.StatusVoided,
+			expectVoid:     true,
+		},
+		{
+			name: "kept authorized when the merchant opted out",
+			policy: payments.AVSPolicy{
+				VoidOnMismatch: true,
+				Merchants:      map[string]bool{"merchant_123": false},
+			},
+			merchantID:     "merchant_123",
+			expectedStatus: payments.StatusAuthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			voided := false
+
+			repo := &mockPaymentsRepository{
+				addFn: func(ctx context.Context, p *payments.Payment) error {
+					return nil
+				},
+			}
+			bank := &mockBankingSimulator{
+				authorizeFn: func(ctx context.Context, req simulator.AuthorizationRequest) (*simulator.AuthorizationResponse, error) {
+					require.Equal(t, "Jane Doe", req.CardholderName)
+					require.Equal(t, &simulator.Address{Line1: "1 Main Street", PostalCode: "00000", Country: "GB"}, req.BillingAddress)
+
+					return &simulator.AuthorizationResponse{
+						Authorized:        true,
+						AuthorizationCode: "AUTH123",
+						AVSResult:         "N",
+						CVVResult:         "M",
+					}, nil
+				},
+				voidFn: func(ctx context.Context, authorizationCode string) error {
+					require.Equal(t, "AUTH123", authorizationCode)
+					voided = true
+					return nil
+				},
+			}
+
+			service := newTestService(repo, bank, payments.WithAVSPolicy(tt.policy))
+
+			req := validPaymentRequest()
+			req.MerchantID = tt.merchantID
+			req.CardholderName = "Jane Doe"
+			req.BillingAddress = &payments.BillingAddress{Line1: "1 Main Street", PostalCode: "00000", Country: "GB"}
+
+			payment, err := service.CreatePayment(context.Background(), req)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedStatus, payment.Status)
+			require.Equal(t, tt.expectVoid, voided)
+			require.Equal(t, payments.AVSNoMatch, payment.AVSResult)
+			require.Equal(t, payments.CVVMatch, payment.CVVResult)
+			require.Equal(t, "AUTH123", payment.AuthorizationCode)
+		})
+	}
+}
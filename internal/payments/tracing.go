@@ -0,0 +1,34 @@
+package payments
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments")
+
+// paymentRequestAttrs describes the request on spans, leaving out the card.
+func paymentRequestAttrs(paymentReq PaymentRequest) trace.SpanStartOption {
+	return trace.WithAttributes(
+		attribute.String("payment.merchant_id", paymentReq.MerchantID),
+		attribute.String("payment.currency", paymentReq.Currency),
+		attribute.Int64("payment.amount", paymentReq.Amount),
+	)
+}
+
+// endSpan records the outcome of the operation and ends the span.
+func endSpan(span trace.Span, payment *Payment, err error) {
+	if payment != nil {
+		span.SetAttributes(
+			attribute.String("payment.id", payment.ID),
+			attribute.String("payment.status", payment.Status.String()),
+		)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
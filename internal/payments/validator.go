@@ -0,0 +1,225 @@
+package payments
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/currency"
+)
+
+// Limits applied to the merchant-provided details of a payment.
+const (
+	MaxReferenceLength           = 50
+	MaxDescriptionLength         = 255
+	MaxStatementDescriptorLength = 22
+	MaxMetadataKeys              = 20
+	MaxMetadataKeyLength         = 40
+	MaxMetadataValueLength       = 500
+	MaxCardholderNameLength      = 100
+	MaxAddressLineLength         = 100
+	MaxPostalCodeLength          = 16
+)
+
+// ExpiryPolicy defines until when a card is accepted.
+//
+// A card is valid through the last day of its expiry month in the issuer's
+// region (Location), optionally extended by a Grace period to absorb clock
+// and timezone differences between the gateway and the issuer.
+type ExpiryPolicy struct {
+	Location *time.Location
+	Grace    time.Duration
+}
+
+// ExpiresAt returns the first instant at which a card expiring in the given
+// month and year is no longer accepted.
+func (p ExpiryPolicy) ExpiresAt(month, year int) time.Time {
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	// time.Date normalizes month 13 into January of the following year.
+	return time.Date(year, time.Month(month)+1, 1, 0, 0, 0, 0, loc).Add(p.Grace)
+}
+
+// Validator checks payment requests before they are sent to the bank.
+type Validator struct {
+	clock  clock.Clock
+	expiry ExpiryPolicy
+}
+
+func NewValidator(clk clock.Clock, expiry ExpiryPolicy) *Validator {
+	return &Validator{
+		clock:  clk,
+		expiry: expiry,
+	}
+}
+
+func (v *Validator) Validate(req PaymentRequest) error {
+	if len(req.CardNumber) < 14 ||
+		len(req.CardNumber) > 19 ||
+		!isDigitsOnly(req.CardNumber) {
+		return &InvalidPaymentRequestErr{
+			Field:   "card_number",
+			Message: "card number must contain between 14 and 19 numeric digits",
+		}
+	}
+
+	if req.ExpiryMonth < 1 || req.ExpiryMonth > 12 {
+		return &InvalidPaymentRequestErr{
+			Field:   "expiry_month",
+			Message: "expiry month must be between 1 and 12",
+		}
+	}
+
+	if !v.clock.Now().Before(v.expiry.ExpiresAt(req.ExpiryMonth, req.ExpiryYear)) {
+		return &InvalidPaymentRequestErr{
+			Field:   "expiry_date",
+			Message: "expiry date must be in the future",
+		}
+	}
+
+	cur, ok := currency.Lookup(req.Currency)
+	if !ok {
+		return &InvalidPaymentRequestErr{
+			Field:   "currency",
+			Message: "currency must be a valid ISO 4217 code",
+		}
+	}
+
+	if req.Amount <= 0 {
+		return &InvalidPaymentRequestErr{
+			Field:   "amount",
+			Message: "amount must be greater than zero",
+		}
+	}
+
+	if req.Amount > cur.MaxAmount() {
+		return &InvalidPaymentRequestErr{
+			Field:   "amount",
+			Message: fmt.Sprintf("amount must not exceed %d for %s", cur.MaxAmount(), cur.Code),
+		}
+	}
+
+	if len(req.CVV) < 3 ||
+		len(req.CVV) > 4 ||
+		!isDigitsOnly(req.CVV) {
+		return &InvalidPaymentRequestErr{
+			Field:   "cvv",
+			Message: "cvv must contain 3 or 4 numeric digits",
+		}
+	}
+
+	if err := validateMerchantDetails(req); err != nil {
+		return err
+	}
+
+	return validateBillingDetails(req)
+}
+
+func validateMerchantDetails(req PaymentRequest) error {
+	if utf8.RuneCountInString(req.Reference) > MaxReferenceLength {
+		return &InvalidPaymentRequestErr{
+			Field:   "reference",
+			Message: fmt.Sprintf("reference must not exceed %d characters", MaxReferenceLength),
+		}
+	}
+
+	if utf8.RuneCountInString(req.Description) > MaxDescriptionLength {
+		return &InvalidPaymentRequestErr{
+			Field:   "description",
+			Message: fmt.Sprintf("description must not exceed %d characters", MaxDescriptionLength),
+		}
+	}
+
+	if len(req.StatementDescriptor) > MaxStatementDescriptorLength ||
+		!isStatementDescriptor(req.StatementDescriptor) {
+		return &InvalidPaymentRequestErr{
+			Field: "statement_descriptor",
+			Message: fmt.Sprintf(
+				"statement descriptor must contain up to %d printable ASCII characters, excluding < > \\ ' \"",
+				MaxStatementDescriptorLength,
+			),
+		}
+	}
+
+	if len(req.Metadata) > MaxMetadataKeys {
+		return &InvalidPaymentRequestErr{
+			Field:   "metadata",
+			Message: fmt.Sprintf("metadata must not contain more than %d keys", MaxMetadataKeys),
+		}
+	}
+
+	for key, value := range req.Metadata {
+		if key == "" || utf8.RuneCountInString(key) > MaxMetadataKeyLength {
+			return &InvalidPaymentRequestErr{
+				Field:   "metadata",
+				Message: fmt.Sprintf("metadata keys must contain between 1 and %d characters", MaxMetadataKeyLength),
+			}
+		}
+
+		if utf8.RuneCountInString(value) > MaxMetadataValueLength {
+			return &InvalidPaymentRequestErr{
+				Field:   "metadata",
+				Message: fmt.Sprintf("metadata values must not exceed %d characters", MaxMetadataValueLength),
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateBillingDetails(req PaymentRequest) error {
+	if utf8.RuneCountInString(req.CardholderName) > MaxCardholderNameLength {
+		return &InvalidPaymentRequestErr{
+			Field:   "cardholder_name",
+			Message: fmt.Sprintf("cardholder name must not exceed %d characters", MaxCardholderNameLength),
+		}
+	}
+
+	addr := req.BillingAddress
+	if addr == nil {
+		return nil
+	}
+
+	for _, line := range []string{addr.Line1, addr.Line2, addr.City, addr.State} {
+		if utf8.RuneCountInString(line) > MaxAddressLineLength {
+			return &InvalidPaymentRequestErr{
+				Field:   "billing_address",
+				Message: fmt.Sprintf("billing address fields must not exceed %d characters", MaxAddressLineLength),
+			}
+		}
+	}
+
+	if utf8.RuneCountInString(addr.PostalCode) > MaxPostalCodeLength {
+		return &InvalidPaymentRequestErr{
+			Field:   "billing_address.postal_code",
+			Message: fmt.Sprintf("postal code must not exceed %d characters", MaxPostalCodeLength),
+		}
+	}
+
+	if len(addr.Country) != 2 ||
+		!strings.ContainsRune("ABCDEFGHIJKLMNOPQRSTUVWXYZ", rune(addr.Country[0])) ||
+		!strings.ContainsRune("ABCDEFGHIJKLMNOPQRSTUVWXYZ", rune(addr.Country[1])) {
+		return &InvalidPaymentRequestErr{
+			Field:   "billing_address.country",
+			Message: "country must be an ISO 3166-1 alpha-2 code",
+		}
+	}
+
+	return nil
+}
+
+// isStatementDescriptor reports whether s only contains the printable ASCII
+// characters accepted by card networks on statements.
+func isStatementDescriptor(s string) bool {
+	for _, r := range s {
+		if r < ' ' || r > '~' || strings.ContainsRune(`<>\'"`, r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,352 @@
+package payments_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedNow pins the clock used by the validation tests.
+var fixedNow = time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC)
+
+func validRequest() payments.PaymentRequest {
+	now := fixedNow
+
+	return payments.PaymentRequest{
+		CardNumber:  "4111111111111111",
+		ExpiryMonth: int(now.Month()) + 1,
+		ExpiryYear:  now.Year(),
+		Currency:    "USD",
+		Amount:      1000,
+		CVV:         "123",
+	}
+}
+
+func TestValidator_Validate(t *testing.T) {
+	validator := payments.NewValidator(clock.NewFake(fixedNow), payments.ExpiryPolicy{})
+
+	tests := []struct {
+		name          string
+		req           func() payments.PaymentRequest
+		expectErr     bool
+		expectedField string
+	}{
+		{
+			name:      "valid request",
+			req:       validRequest,
+			expectErr: false,
+		},
+		{
+			name: "invalid card number length",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.CardNumber = "123"
+				return r
+			},
+			expectErr:     true,
+			expectedField: "card_number",
+		},
+		{
+			name: "non-numeric card number",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.CardNumber = "4111abcd11111111"
+				return r
+			},
+			expectErr:     true,
+			expectedField: "card_number",
+		},
+		{
+			name: "invalid expiry month",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.ExpiryMonth = 13
+				return r
+			},
+			expectErr:     true,
+			expectedField: "expiry_month",
+		},
+		{
+			name: "invalid expiry month at the current month",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.ExpiryMonth = 1
+				return r
+			},
+			expectErr:     false,
+			expectedField: "expiry_month",
+		},
+		{
+			name: "expiry date in the past",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.ExpiryYear = fixedNow.Year() - 1
+				return r
+			},
+			expectErr:     true,
+			expectedField: "expiry_date",
+		},
+		{
+			name: "unknown currency",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.Currency = "ABC"
+				return r
+			},
+			expectErr:     true,
+			expectedField: "currency",
+		},
+		{
+			name: "zero-decimal currency",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.Currency = "JPY"
+				r.Amount = 999_999_999
+				return r
+			},
+			expectErr: false,
+		},
+		{
+			name: "amount above the currency maximum",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.Currency = "JPY"
+				r.Amount = 1_000_000_000
+				return r
+			},
+			expectErr:     true,
+			expectedField: "amount",
+		},
+		{
+			name: "amount is zero",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.Amount = 0
+				return r
+			},
+			expectErr:     true,
+			expectedField: "amount",
+		},
+		{
+			name: "merchant details within limits",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.Reference = strings.Repeat("r", payments.MaxReferenceLength)
+				r.Description = strings.Repeat("d", payments.MaxDescriptionLength)
+				r.StatementDescriptor = "ACME*MASKS"
+				r.Metadata = map[string]string{"order_channel": "web"}
+				return r
+			},
+			expectErr: false,
+		},
+		{
+			name: "reference too long",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.Reference = strings.Repeat("r", payments.MaxReferenceLength+1)
+				return r
+			},
+			expectErr:     true,
+			expectedField: "reference",
+		},
+		{
+			name: "description too long",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.Description = strings.Repeat("d", payments.MaxDescriptionLength+1)
+				return r
+			},
+			expectErr:     true,
+			expectedField: "description",
+		},
+		{
+			name: "statement descriptor with forbidden characters",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.StatementDescriptor = "ACME<MASKS>"
+				return r
+			},
+			expectErr:     true,
+			expectedField: "statement_descriptor",
+		},
+		{
+			name: "too many metadata keys",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.Metadata = map[string]string{}
+				for i := range payments.MaxMetadataKeys + 1 {
+					r.Metadata[fmt.Sprintf("key_%d", i)] = "value"
+				}
+				return r
+			},
+			expectErr:     true,
+			expectedField: "metadata",
+		},
+		{
+			name: "metadata value too long",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.Metadata = map[string]string{"key": strings.Repeat("v", payments.MaxMetadataValueLength+1)}
+				return r
+			},
+			expectErr:     true,
+			expectedField: "metadata",
+		},
+		{
+			name: "billing address with a valid country",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.CardholderName = "Jane Doe"
+				r.BillingAddress = &payments.BillingAddress{Line1: "1 Main Street", PostalCode: "W1 8QS", Country: "GB"}
+				return r
+			},
+			expectErr: false,
+		},
+		{
+			name: "billing address with an invalid country",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.BillingAddress = &payments.BillingAddress{Line1: "1 Main Street", Country: "gbr"}
+				return r
+			},
+			expectErr:     true,
+			expectedField: "billing_address.country",
+		},
+		{
+			name: "cardholder name too long",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.CardholderName = strings.Repeat("n", payments.MaxCardholderNameLength+1)
+				return r
+			},
+			expectErr:     true,
+			expectedField: "cardholder_name",
+		},
+		{
+			name: "invalid cvv",
+			req: func() payments.PaymentRequest {
+				r := validRequest()
+				r.CVV = "12a"
+				return r
+			},
+			expectErr:     true,
+			expectedField: "cvv",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validator.Validate(tt.req())
+
+			if !tt.expectErr {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+
+			var invalidErr *payments.InvalidPaymentRequestErr
+			require.ErrorAs(t, err, &invalidErr)
+			require.Equal(t, tt.expectedField, invalidErr.Field)
+			require.NotEmpty(t, invalidErr.Message)
+		})
+	}
+}
+
+func TestValidator_Validate_ExpiryBoundaries(t *testing.T) {
+	saoPaulo := time.FixedZone("America/Sao_Paulo", -3*60*60)
+	tokyo := time.FixedZone("Asia/Tokyo", 9*60*60)
+
+	tests := []struct {
+		name        string
+		now         time.Time
+		expiryMonth int
+		expiryYear  int
+		policy      payments.ExpiryPolicy
+		expectErr   bool
+	}{
+		{
+			name:        "last second of the expiry month",
+			now:         time.Date(2025, time.December, 31, 23, 59, 59, 0, time.UTC),
+			expiryMonth: 12,
+			expiryYear:  2025,
+			expectErr:   false,
+		},
+		{
+			name:        "first second after the expiry month across a year boundary",
+			now:         time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+			expiryMonth: 12,
+			expiryYear:  2025,
+			expectErr:   true,
+		},
+		{
+			name:        "first second after the expiry month across a month boundary",
+			now:         time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC),
+			expiryMonth: 2,
+			expiryYear:  2026,
+			expectErr:   true,
+		},
+		{
+			name:        "issuer behind UTC is still in the expiry month",
+			now:         time.Date(2026, time.January, 1, 2, 0, 0, 0, time.UTC),
+			expiryMonth: 12,
+			expiryYear:  2025,
+			policy:      payments.ExpiryPolicy{Location: saoPaulo},
+			expectErr:   false,
+		},
+		{
+			name:        "issuer ahead of UTC has already left the expiry month",
+			now:         time.Date(2025, time.December, 31, 16, 0, 0, 0, time.UTC),
+			expiryMonth: 12,
+			expiryYear:  2025,
+			policy:      payments.ExpiryPolicy{Location: tokyo},
+			expectErr:   true,
+		},
+		{
+			name:        "within the grace period",
+			now:         time.Date(2026, time.January, 1, 23, 0, 0, 0, time.UTC),
+			expiryMonth: 12,
+			expiryYear:  2025,
+			policy:      payments.ExpiryPolicy{Grace: 24 * time.Hour},
+			expectErr:   false,
+		},
+		{
+			name:        "after the grace period",
+			now:         time.Date(2026, time.January, 2, 0, 0, 0, 0, time.UTC),
+			expiryMonth: 12,
+			expiryYear:  2025,
+			policy:      payments.ExpiryPolicy{Grace: 24 * time.Hour},
+			expectErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			validator := payments.NewValidator(clock.NewFake(tt.now), tt.policy)
+
+			req := validRequest()
+			req.ExpiryMonth = tt.expiryMonth
+			req.ExpiryYear = tt.expiryYear
+
+			err := validator.Validate(req)
+
+			if !tt.expectErr {
+				require.NoError(t, err)
+				return
+			}
+
+			var invalidErr *payments.InvalidPaymentRequestErr
+			require.ErrorAs(t, err, &invalidErr)
+			require.Equal(t, "expiry_date", invalidErr.Field)
+		})
+	}
+}
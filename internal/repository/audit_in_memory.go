@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/audit"
+)
+
+type AuditRepositoryInMemory struct {
+	mu      sync.RWMutex
+	entries []audit.Entry // entries[i] has sequence i+1
+}
+
+func NewAuditRepositoryInMemory() *AuditRepositoryInMemory {
+	return &AuditRepositoryInMemory{}
+}
+
+func (as *AuditRepositoryInMemory) Append(_ context.Context, entry *audit.Entry) error {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	if want := int64(len(as.entries) + 1); entry.Sequence != want {
+		return fmt.Errorf("%w: got %d, want %d", audit.ErrOutOfSequence, entry.Sequence, want)
+	}
+
+	as.entries = append(as.entries, *entry)
+
+	return nil
+}
+
+func (as *AuditRepositoryInMemory) Last(_ context.Context) (*audit.Entry, error) {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
+	if len(as.entries) == 0 {
+		return nil, nil
+	}
+
+	last := as.entries[len(as.entries)-1]
+	return &last, nil
+}
+
+func (as *AuditRepositoryInMemory) List(_ context.Context, query audit.Query) ([]*audit.Entry, error) {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
+	var found []*audit.Entry
+	for _, entry := range as.entries {
+		if !query.Matches(&entry) {
+			continue
+		}
+
+		found = append(found, &entry)
+
+		if query.Limit > 0 && len(found) == query.Limit {
+			break
+		}
+	}
+
+	return found, nil
+}
@@ -0,0 +1,51 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/audit"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditRepositoryInMemory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo := repository.NewAuditRepositoryInMemory()
+
+	last, err := repo.Last(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, last)
+
+	require.NoError(t, repo.Append(ctx, &audit.Entry{Sequence: 1, ActorID: "ops", Hash: "a"}))
+	require.NoError(t, repo.Append(ctx, &audit.Entry{Sequence: 2, ActorID: "bot", Hash: "b"}))
+	require.NoError(t, repo.Append(ctx, &audit.Entry{Sequence: 3, ActorID: "ops", Hash: "c"}))
+
+	err = repo.Append(ctx, &audit.Entry{Sequence: 3, Hash: "d"})
+	assert.ErrorIs(t, err, audit.ErrOutOfSequence)
+	err = repo.Append(ctx, &audit.Entry{Sequence: 5, Hash: "d"})
+	assert.ErrorIs(t, err, audit.ErrOutOfSequence)
+
+	last, err = repo.Last(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), last.Sequence)
+
+	// Entries returned are copies, changing them leaves the trail intact.
+	last.Hash = "tampered"
+	last, err = repo.Last(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "c", last.Hash)
+
+	entries, err := repo.List(ctx, audit.Query{ActorID: "ops"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, int64(1), entries[0].Sequence)
+	assert.Equal(t, int64(3), entries[1].Sequence)
+
+	entries, err = repo.List(ctx, audit.Query{Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
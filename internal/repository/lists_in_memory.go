@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/lists"
+	"github.com/google/uuid"
+)
+
+type ListsRepositoryInMemory struct {
+	mu      sync.RWMutex
+	entries map[string]*lists.Entry
+	byKey   map[lists.Key]map[string]bool // IDs of the entries holding each key
+}
+
+func NewListsRepositoryInMemory() *ListsRepositoryInMemory {
+	return &ListsRepositoryInMemory{
+		entries: map[string]*lists.Entry{},
+		byKey:   map[lists.Key]map[string]bool{},
+	}
+}
+
+func (ls *ListsRepositoryInMemory) AddEntry(_ context.Context, entry *lists.Entry) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+
+	entry.ID = id.String()
+	ls.entries[entry.ID] = cloneEntry(entry)
+
+	key := lists.Key{Kind: entry.Kind, Value: entry.Value}
+	if ls.byKey[key] == nil {
+		ls.byKey[key] = map[string]bool{}
+	}
+	ls.byKey[key][entry.ID] = true
+
+	return nil
+}
+
+func (ls *ListsRepositoryInMemory) GetEntry(_ context.Context, id string) (*lists.Entry, error) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	entry, ok := ls.entries[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return cloneEntry(entry), nil
+}
+
+// UpdateEntry replaces the entry. Its kind and value must not change.
+func (ls *ListsRepositoryInMemory) UpdateEntry(_ context.Context, entry *lists.Entry) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if _, ok := ls.entries[entry.ID]; !ok {
+		return lists.ErrEntryNotFound
+	}
+
+	ls.entries[entry.ID] = cloneEntry(entry)
+
+	return nil
+}
+
+func (ls *ListsRepositoryInMemory) DeleteEntry(_ context.Context, id string) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	entry, ok := ls.entries[id]
+	if !ok {
+		return lists.ErrEntryNotFound
+	}
+
+	key := lists.Key{Kind: entry.Kind, Value: entry.Value}
+	delete(ls.byKey[key], id)
+	if len(ls.byKey[key]) == 0 {
+		delete(ls.byKey, key)
+	}
+	delete(ls.entries, id)
+
+	return nil
+}
+
+func (ls *ListsRepositoryInMemory) ListEntries(_ context.Context, query lists.EntriesQuery, at time.Time) ([]*lists.Entry, error) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	var found []*lists.Entry
+	for _, entry := range ls.entries {
+		if query.List != "" && entry.List != query.List ||
+			query.Kind != "" && entry.Kind != query.Kind ||
+			query.Value != "" && entry.Value != query.Value ||
+			query.MerchantID != "" && entry.MerchantID != query.MerchantID ||
+			query.Active && !entry.Active(at) {
+			continue
+		}
+		found = append(found, cloneEntry(entry))
+	}
+
+	sortEntries(found)
+
+	return found, nil
+}
+
+func (ls *ListsRepositoryInMemory) FindEntries(_ context.Context, keys []lists.Key, at time.Time) ([]*lists.Entry, error) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	var found []*lists.Entry
+	for _, key := range keys {
+		for id := range ls.byKey[key] {
+			if entry := ls.entries[id]; entry.Active(at) {
+				found = append(found, cloneEntry(entry))
+			}
+		}
+	}
+
+	sortEntries(found)
+
+	return found, nil
+}
+
+func sortEntries(entries []*lists.Entry) {
+	slices.SortFunc(entries, func(a, b *lists.Entry) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+}
+
+func cloneEntry(entry *lists.Entry) *lists.Entry {
+	e := *entry
+	if entry.ExpiresAt != nil {
+		expiresAt := *entry.ExpiresAt
+		e.ExpiresAt = &expiresAt
+	}
+	return &e
+}
@@ -2,8 +2,16 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/outbox"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
 	"github.com/google/uuid"
 )
@@ -13,33 +21,389 @@ type PaymentID string
 type PaymentsRepositoryInMemory struct {
 	mu       sync.RWMutex
 	payments map[PaymentID]*payments.Payment
+	clock    clock.Clock
+
+	// pendingAuthorizations holds the sealed authorization requests of
+	// payments accepted asynchronously, until they are authorized.
+	pendingAuthorizations map[PaymentID][]byte
+
+	// outbox holds the events of payment changes, in write order. Records
+	// are appended under the same lock as the change they describe.
+	outbox []*outboxEntry
+	// published maps publishers to the length of the outbox prefix they
+	// all published.
+	published map[string]int
+	sequence  int64
+}
+
+type outboxEntry struct {
+	record outbox.Record
+	// publishedAt records when each publisher published the record.
+	publishedAt map[string]time.Time
+}
+
+// Option configures optional dependencies of the in-memory repository.
+type Option func(*PaymentsRepositoryInMemory)
+
+// WithClock sets the clock used to stamp payment timestamps.
+func WithClock(clk clock.Clock) Option {
+	return func(ps *PaymentsRepositoryInMemory) {
+		ps.clock = clk
+	}
 }
 
-func NewPaymentsRepositoryInMemory() *PaymentsRepositoryInMemory {
-	return &PaymentsRepositoryInMemory{
-		payments: map[PaymentID]*payments.Payment{},
+func NewPaymentsRepositoryInMemory(opts ...Option) *PaymentsRepositoryInMemory {
+	ps := &PaymentsRepositoryInMemory{
+		payments:              map[PaymentID]*payments.Payment{},
+		clock:                 clock.New(),
+		pendingAuthorizations: map[PaymentID][]byte{},
+		published:             map[string]int{},
+	}
+
+	for _, opt := range opts {
+		opt(ps)
 	}
+
+	return ps
+}
+
+// Ping reports whether the store is usable, failing when the payments lock
+// cannot be acquired before the context is done.
+func (ps *PaymentsRepositoryInMemory) Ping(ctx context.Context) error {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ctx.Err()
 }
 
-func (ps *PaymentsRepositoryInMemory) GetPayment(_ context.Context, id string) (*payments.Payment, error) {
+func (ps *PaymentsRepositoryInMemory) GetPayment(ctx context.Context, id string) (*payments.Payment, error) {
+	defer startSpan(ctx, "GetPayment").End()
+
 	ps.mu.RLock()
 	defer ps.mu.RUnlock()
 
-	payment := ps.payments[PaymentID(id)]
-	return payment, nil
+	payment, ok := ps.payments[PaymentID(id)]
+	if !ok {
+		return nil, nil
+	}
+
+	return clonePayment(payment), nil
 }
 
-func (ps *PaymentsRepositoryInMemory) AddPayment(_ context.Context, payment *payments.Payment) error {
+func (ps *PaymentsRepositoryInMemory) AddPayment(ctx context.Context, payment *payments.Payment) error {
+	defer startSpan(ctx, "AddPayment").End()
+
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
 
+	return ps.addPayment(payment)
+}
+
+// addPayment stores a new payment. Callers must hold the write lock.
+func (ps *PaymentsRepositoryInMemory) addPayment(payment *payments.Payment) error {
+	now := ps.clock.Now().UTC()
+	if payment.CreatedAt.IsZero() {
+		payment.CreatedAt = now
+	}
+	if payment.UpdatedAt.IsZero() {
+		payment.UpdatedAt = payment.CreatedAt
+	}
+
+	// UUIDv7 embeds the creation time, so IDs sort in creation order.
 	id, err := uuid.NewV7()
 	if err != nil {
 		return err
 	}
 
 	payment.ID = id.String()
-	ps.payments[PaymentID(id.String())] = payment
+
+	if err := ps.recordEvent(payment); err != nil {
+		return err
+	}
+	ps.payments[PaymentID(id.String())] = clonePayment(payment)
+
+	return nil
+}
+
+func (ps *PaymentsRepositoryInMemory) UpdatePayment(ctx context.Context, payment *payments.Payment) error {
+	defer startSpan(ctx, "UpdatePayment").End()
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return ps.updatePayment(payment)
+}
+
+// updatePayment replaces a stored payment. Callers must hold the write lock.
+func (ps *PaymentsRepositoryInMemory) updatePayment(payment *payments.Payment) error {
+	stored, ok := ps.payments[PaymentID(payment.ID)]
+	if !ok {
+		return payments.NotFoundPaymentErr
+	}
+
+	payment.UpdatedAt = ps.clock.Now().UTC()
+
+	if payment.Status != stored.Status {
+		if err := ps.recordEvent(payment); err != nil {
+			return err
+		}
+	}
+	ps.payments[PaymentID(payment.ID)] = clonePayment(payment)
+
+	return nil
+}
+
+func (ps *PaymentsRepositoryInMemory) AddPendingPayment(ctx context.Context, payment *payments.Payment, sealedRequest []byte) error {
+	defer startSpan(ctx, "AddPendingPayment").End()
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if err := ps.addPayment(payment); err != nil {
+		return err
+	}
+	ps.pendingAuthorizations[PaymentID(payment.ID)] = slices.Clone(sealedRequest)
+
+	return nil
+}
+
+func (ps *PaymentsRepositoryInMemory) GetPendingAuthorization(ctx context.Context, paymentID string) ([]byte, error) {
+	defer startSpan(ctx, "GetPendingAuthorization").End()
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return slices.Clone(ps.pendingAuthorizations[PaymentID(paymentID)]), nil
+}
+
+func (ps *PaymentsRepositoryInMemory) ListPendingAuthorizations(ctx context.Context) ([]string, error) {
+	defer startSpan(ctx, "ListPendingAuthorizations").End()
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	ids := make([]string, 0, len(ps.pendingAuthorizations))
+	for id := range ps.pendingAuthorizations {
+		ids = append(ids, string(id))
+	}
+
+	// UUIDv7 IDs sort in creation order.
+	slices.Sort(ids)
+
+	return ids, nil
+}
+
+func (ps *PaymentsRepositoryInMemory) CompletePendingAuthorization(ctx context.Context, payment *payments.Payment) error {
+	defer startSpan(ctx, "CompletePendingAuthorization").End()
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if err := ps.updatePayment(payment); err != nil {
+		return err
+	}
+	delete(ps.pendingAuthorizations, PaymentID(payment.ID))
 
 	return nil
 }
+
+// recordEvent appends the event matching the payment status to the outbox.
+// Callers must hold the write lock.
+func (ps *PaymentsRepositoryInMemory) recordEvent(payment *payments.Payment) error {
+	eventType, ok := payments.EventTypeFor(payment.Status)
+	if !ok {
+		return nil
+	}
+
+	payload, err := json.Marshal(payment)
+	if err != nil {
+		return fmt.Errorf("encode outbox payload: %w", err)
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+
+	ps.sequence++
+	ps.outbox = append(ps.outbox, &outboxEntry{
+		publishedAt: map[string]time.Time{},
+		record: outbox.Record{
+			ID:         id.String(),
+			Sequence:   ps.sequence,
+			PaymentID:  payment.ID,
+			MerchantID: payment.MerchantID,
+			Type:       eventType,
+			Payload:    payload,
+			OccurredAt: payment.UpdatedAt,
+		},
+	})
+
+	return nil
+}
+
+func (ps *PaymentsRepositoryInMemory) ListUnpublished(_ context.Context, publisher string, limit int) ([]*outbox.Record, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	var unpublished []*outbox.Record
+	for _, entry := range ps.outbox[ps.published[publisher]:] {
+		if _, ok := entry.publishedAt[publisher]; ok {
+			continue
+		}
+
+		record := entry.record
+		unpublished = append(unpublished, &record)
+
+		if limit > 0 && len(unpublished) == limit {
+			break
+		}
+	}
+
+	return unpublished, nil
+}
+
+func (ps *PaymentsRepositoryInMemory) MarkPublished(_ context.Context, publisher, id string, at time.Time) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for _, entry := range ps.outbox[ps.published[publisher]:] {
+		if entry.record.ID == id {
+			entry.publishedAt[publisher] = at
+			break
+		}
+	}
+
+	published := ps.published[publisher]
+	for published < len(ps.outbox) && !ps.outbox[published].publishedAt[publisher].IsZero() {
+		published++
+	}
+	ps.published[publisher] = published
+
+	return nil
+}
+
+// PurgePublished deletes the oldest records, up to the first one not yet
+// published by every publisher before the given instant.
+func (ps *PaymentsRepositoryInMemory) PurgePublished(_ context.Context, publishers []string, before time.Time) (int, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	purged := 0
+	for _, entry := range ps.outbox {
+		if !publishedBefore(entry, publishers, before) {
+			break
+		}
+		purged++
+	}
+	if purged == 0 {
+		return 0, nil
+	}
+
+	ps.outbox = slices.Clone(ps.outbox[purged:])
+	for publisher, published := range ps.published {
+		ps.published[publisher] = max(published-purged, 0)
+	}
+
+	return purged, nil
+}
+
+func publishedBefore(entry *outboxEntry, publishers []string, before time.Time) bool {
+	for _, publisher := range publishers {
+		at, ok := entry.publishedAt[publisher]
+		if !ok || !at.Before(before) {
+			return false
+		}
+	}
+	return true
+}
+
+func (ps *PaymentsRepositoryInMemory) ListExpiredAuthorizations(ctx context.Context, at time.Time) ([]*payments.Payment, error) {
+	defer startSpan(ctx, "ListExpiredAuthorizations").End()
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	var expired []*payments.Payment
+	for _, payment := range ps.payments {
+		if payment.Status != payments.StatusAuthorized ||
+			payment.ExpiresAt == nil ||
+			payment.ExpiresAt.After(at) {
+			continue
+		}
+
+		expired = append(expired, clonePayment(payment))
+	}
+
+	return expired, nil
+}
+
+func (ps *PaymentsRepositoryInMemory) SearchPayments(ctx context.Context, query payments.PaymentsQuery) ([]*payments.Payment, error) {
+	defer startSpan(ctx, "SearchPayments").End()
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	var found []*payments.Payment
+	for _, payment := range ps.payments {
+		if matches(payment, query) {
+			found = append(found, clonePayment(payment))
+		}
+	}
+
+	// UUIDv7 IDs sort in creation order.
+	slices.SortFunc(found, func(a, b *payments.Payment) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	if query.Limit > 0 && len(found) > query.Limit {
+		found = found[:query.Limit]
+	}
+
+	return found, nil
+}
+
+func matches(payment *payments.Payment, query payments.PaymentsQuery) bool {
+	if !query.AllMerchants && payment.MerchantID != query.MerchantID {
+		return false
+	}
+
+	if query.Reference != "" && payment.Reference != query.Reference {
+		return false
+	}
+
+	if len(query.Statuses) > 0 && !slices.Contains(query.Statuses, payment.Status) {
+		return false
+	}
+
+	for key, value := range query.Metadata {
+		if v, ok := payment.Metadata[key]; !ok || v != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// clonePayment copies a payment so callers never share memory with the store.
+func clonePayment(payment *payments.Payment) *payments.Payment {
+	p := *payment
+	p.Metadata = maps.Clone(payment.Metadata)
+	if payment.BillingAddress != nil {
+		addr := *payment.BillingAddress
+		p.BillingAddress = &addr
+	}
+	if payment.NextAction != nil {
+		action := *payment.NextAction
+		p.NextAction = &action
+	}
+	if payment.ThreeDS != nil {
+		outcome := *payment.ThreeDS
+		p.ThreeDS = &outcome
+	}
+	if payment.Review != nil {
+		review := *payment.Review
+		p.Review = &review
+	}
+	return &p
+}
@@ -2,8 +2,11 @@ package repository_test
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
 	"github.com/stretchr/testify/assert"
@@ -61,3 +64,156 @@ func TestPaymentsRepositoryInMemory_ConcurrentAdd(t *testing.T) {
 		<-done
 	}
 }
+
+func TestPaymentsRepositoryInMemory_Timestamps(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(now)
+	repo := repository.NewPaymentsRepositoryInMemory(repository.WithClock(clk))
+
+	payment := &payments.Payment{Status: payments.StatusAuthorized}
+	require.NoError(t, repo.AddPayment(context.Background(), payment))
+
+	assert.Equal(t, now, payment.CreatedAt)
+	assert.Equal(t, now, payment.UpdatedAt)
+
+	clk.Advance(time.Hour)
+	payment.Status = payments.StatusExpired
+	require.NoError(t, repo.UpdatePayment(context.Background(), payment))
+
+	got, err := repo.GetPayment(context.Background(), payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, payments.StatusExpired, got.Status)
+	assert.Equal(t, now, got.CreatedAt)
+	assert.Equal(t, now.Add(time.Hour), got.UpdatedAt)
+}
+
+func TestPaymentsRepositoryInMemory_UpdateNotFound(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.NewPaymentsRepositoryInMemory()
+
+	err := repo.UpdatePayment(context.Background(), &payments.Payment{ID: "missing"})
+	require.ErrorIs(t, err, payments.NotFoundPaymentErr)
+}
+
+func TestPaymentsRepositoryInMemory_ListExpiredAuthorizations(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	repo := repository.NewPaymentsRepositoryInMemory()
+
+	expired := &payments.Payment{Status: payments.StatusAuthorized, ExpiresAt: &past}
+	active := &payments.Payment{Status: payments.StatusAuthorized, ExpiresAt: &future}
+	declined := &payments.Payment{Status: payments.StatusDeclined, ExpiresAt: &past}
+
+	for _, p := range []*payments.Payment{expired, active, declined} {
+		require.NoError(t, repo.AddPayment(context.Background(), p))
+	}
+
+	got, err := repo.ListExpiredAuthorizations(context.Background(), now)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, expired.ID, got[0].ID)
+}
+
+func TestPaymentsRepositoryInMemory_SearchPayments(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.NewPaymentsRepositoryInMemory()
+
+	first := &payments.Payment{MerchantID: "m1", Reference: "ORD-1", Metadata: map[string]string{"channel": "web"}}
+	second := &payments.Payment{MerchantID: "m1", Reference: "ORD-1", Metadata: map[string]string{"channel": "app"}}
+	other := &payments.Payment{MerchantID: "m2", Reference: "ORD-1", Metadata: map[string]string{"channel": "web"}}
+
+	for _, p := range []*payments.Payment{first, second, other} {
+		require.NoError(t, repo.AddPayment(context.Background(), p))
+	}
+
+	got, err := repo.SearchPayments(context.Background(), payments.PaymentsQuery{
+		MerchantID: "m1",
+		Reference:  "ORD-1",
+	})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, first.ID, got[0].ID)
+	assert.Equal(t, second.ID, got[1].ID)
+
+	got, err = repo.SearchPayments(context.Background(), payments.PaymentsQuery{
+		AllMerchants: true,
+		Metadata:     map[string]string{"channel": "web"},
+	})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+
+	// An empty merchant is not a wildcard.
+	got, err = repo.SearchPayments(context.Background(), payments.PaymentsQuery{Reference: "ORD-1"})
+	require.NoError(t, err)
+	require.Empty(t, got)
+
+	got, err = repo.SearchPayments(context.Background(), payments.PaymentsQuery{MerchantID: "m1", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, first.ID, got[0].ID)
+
+	// Results must not share memory with the store.
+	got[0].Metadata["channel"] = "changed"
+	stored, err := repo.GetPayment(context.Background(), first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "web", stored.Metadata["channel"])
+}
+
+func TestPaymentsRepositoryInMemory_Outbox(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo := repository.NewPaymentsRepositoryInMemory()
+
+	pending := &payments.Payment{MerchantID: "merchant_a", Status: payments.StatusPending}
+	require.NoError(t, repo.AddPayment(ctx, pending))
+
+	authorized := &payments.Payment{MerchantID: "merchant_a", Status: payments.StatusAuthorized}
+	require.NoError(t, repo.AddPayment(ctx, authorized))
+
+	// Updates that keep the status do not emit events.
+	authorized.Reference = "order-1"
+	require.NoError(t, repo.UpdatePayment(ctx, authorized))
+
+	authorized.Status = payments.StatusExpired
+	require.NoError(t, repo.UpdatePayment(ctx, authorized))
+
+	records, err := repo.ListUnpublished(ctx, "webhooks", 0)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, payments.EventPaymentAuthorized, records[0].Type)
+	assert.Equal(t, authorized.ID, records[0].PaymentID)
+	assert.Equal(t, "merchant_a", records[0].MerchantID)
+	assert.JSONEq(t, `"authorized"`, string(jsonField(t, records[0].Payload, "status")))
+	assert.Equal(t, payments.EventPaymentExpired, records[1].Type)
+	assert.Less(t, records[0].Sequence, records[1].Sequence)
+
+	require.NoError(t, repo.MarkPublished(ctx, "webhooks", records[0].ID, time.Now()))
+
+	records, err = repo.ListUnpublished(ctx, "webhooks", 0)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, payments.EventPaymentExpired, records[0].Type)
+
+	// Other publishers keep their own state.
+	records, err = repo.ListUnpublished(ctx, "file", 0)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+}
+
+func jsonField(t *testing.T, payload []byte, field string) json.RawMessage {
+	t.Helper()
+
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(payload, &fields))
+	return fields[field]
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository")
+
+// startSpan traces a repository call made on behalf of a traced operation.
+// Calls from background sweeps, which have no span, are not traced so they
+// do not start a new trace on every tick.
+func startSpan(ctx context.Context, operation string) trace.Span {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return trace.SpanFromContext(ctx)
+	}
+
+	_, span := tracer.Start(ctx, "repository."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameKey.String("memory"),
+			semconv.DBOperationName(operation),
+		),
+	)
+	return span
+}
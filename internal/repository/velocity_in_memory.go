@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// VelocityCountersInMemory implements fraud.VelocityCounters. Events older
+// than the retention are forgotten, so it must be at least the longest
+// velocity window of the fraud rules.
+type VelocityCountersInMemory struct {
+	mu        sync.Mutex
+	retention time.Duration
+	lastPrune time.Time
+
+	cardAttempts  map[string][]time.Time
+	cardDeclines  map[string][]time.Time
+	merchantCards map[string]map[string]time.Time // last use of each card per merchant
+}
+
+func NewVelocityCountersInMemory(retention time.Duration) *VelocityCountersInMemory {
+	return &VelocityCountersInMemory{
+		retention:     retention,
+		cardAttempts:  map[string][]time.Time{},
+		cardDeclines:  map[string][]time.Time{},
+		merchantCards: map[string]map[string]time.Time{},
+	}
+}
+
+func (vc *VelocityCountersInMemory) RecordAttempt(_ context.Context, merchantID, cardFingerprint string, at time.Time) error {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	vc.cardAttempts[cardFingerprint] = append(vc.cardAttempts[cardFingerprint], at)
+
+	cards, ok := vc.merchantCards[merchantID]
+	if !ok {
+		cards = map[string]time.Time{}
+		vc.merchantCards[merchantID] = cards
+	}
+	if last, ok := cards[cardFingerprint]; !ok || at.After(last) {
+		cards[cardFingerprint] = at
+	}
+
+	vc.prune(at)
+
+	return nil
+}
+
+func (vc *VelocityCountersInMemory) RecordDecline(_ context.Context, cardFingerprint string, at time.Time) error {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	vc.cardDeclines[cardFingerprint] = append(vc.cardDeclines[cardFingerprint], at)
+	vc.prune(at)
+
+	return nil
+}
+
+func (vc *VelocityCountersInMemory) CardAttempts(_ context.Context, cardFingerprint string, since time.Time) (int, error) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	return countAfter(vc.cardAttempts[cardFingerprint], since), nil
+}
+
+func (vc *VelocityCountersInMemory) CardDeclines(_ context.Context, cardFingerprint string, since time.Time) (int, error) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	return countAfter(vc.cardDeclines[cardFingerprint], since), nil
+}
+
+func (vc *VelocityCountersInMemory) MerchantCards(_ context.Context, merchantID string, since time.Time) (int, error) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	n := 0
+	for _, last := range vc.merchantCards[merchantID] {
+		if last.After(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// prune forgets the events past the retention. It goes through every
+// counter, so it runs at most once per retention period.
+func (vc *VelocityCountersInMemory) prune(now time.Time) {
+	if now.Sub(vc.lastPrune) < vc.retention {
+		return
+	}
+	vc.lastPrune = now
+
+	cutoff := now.Add(-vc.retention)
+	expired := func(t time.Time) bool { return !t.After(cutoff) }
+
+	for _, counters := range []map[string][]time.Time{vc.cardAttempts, vc.cardDeclines} {
+		for key, events := range counters {
+			events = slices.DeleteFunc(events, expired)
+			if len(events) == 0 {
+				delete(counters, key)
+			} else {
+				counters[key] = events
+			}
+		}
+	}
+
+	for merchantID, cards := range vc.merchantCards {
+		for card, last := range cards {
+			if expired(last) {
+				delete(cards, card)
+			}
+		}
+		if len(cards) == 0 {
+			delete(vc.merchantCards, merchantID)
+		}
+	}
+}
+
+func countAfter(events []time.Time, since time.Time) int {
+	n := 0
+	for _, t := range events {
+		if t.After(since) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,53 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVelocityCountersInMemory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC)
+	counters := repository.NewVelocityCountersInMemory(time.Hour)
+
+	require.NoError(t, counters.RecordAttempt(ctx, "merchant_a", "card_a", now.Add(-2*time.Minute)))
+	require.NoError(t, counters.RecordAttempt(ctx, "merchant_a", "card_a", now))
+	require.NoError(t, counters.RecordAttempt(ctx, "merchant_a", "card_b", now))
+	require.NoError(t, counters.RecordAttempt(ctx, "merchant_b", "card_a", now))
+	require.NoError(t, counters.RecordDecline(ctx, "card_a", now))
+
+	attempts, err := counters.CardAttempts(ctx, "card_a", now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	attempts, err = counters.CardAttempts(ctx, "card_a", now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	declines, err := counters.CardDeclines(ctx, "card_a", now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, declines)
+
+	cards, err := counters.MerchantCards(ctx, "merchant_a", now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2, cards, "cards are counted once")
+
+	// Past the retention, earlier events are forgotten.
+	later := now.Add(2 * time.Hour)
+	require.NoError(t, counters.RecordAttempt(ctx, "merchant_a", "card_c", later))
+
+	attempts, err = counters.CardAttempts(ctx, "card_a", time.Time{})
+	require.NoError(t, err)
+	assert.Zero(t, attempts)
+
+	cards, err = counters.MerchantCards(ctx, "merchant_a", time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, cards)
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+type WebhooksRepositoryInMemory struct {
+	mu         sync.RWMutex
+	endpoints  map[string]*webhooks.Endpoint
+	events     map[string]*webhooks.Event
+	deliveries map[string]*webhooks.Delivery
+}
+
+func NewWebhooksRepositoryInMemory() *WebhooksRepositoryInMemory {
+	return &WebhooksRepositoryInMemory{
+		endpoints:  map[string]*webhooks.Endpoint{},
+		events:     map[string]*webhooks.Event{},
+		deliveries: map[string]*webhooks.Delivery{},
+	}
+}
+
+func (ws *WebhooksRepositoryInMemory) AddEndpoint(_ context.Context, endpoint *webhooks.Endpoint) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+
+	endpoint.ID = id.String()
+	ws.endpoints[endpoint.ID] = cloneEndpoint(endpoint)
+
+	return nil
+}
+
+func (ws *WebhooksRepositoryInMemory) GetEndpoint(_ context.Context, id string) (*webhooks.Endpoint, error) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+
+	endpoint, ok := ws.endpoints[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return cloneEndpoint(endpoint), nil
+}
+
+func (ws *WebhooksRepositoryInMemory) ListEndpoints(_ context.Context, merchantID string) ([]*webhooks.Endpoint, error) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+
+	var found []*webhooks.Endpoint
+	for _, endpoint := range ws.endpoints {
+		if endpoint.MerchantID == merchantID {
+			found = append(found, cloneEndpoint(endpoint))
+		}
+	}
+
+	slices.SortFunc(found, func(a, b *webhooks.Endpoint) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return found, nil
+}
+
+func (ws *WebhooksRepositoryInMemory) DeleteEndpoint(_ context.Context, id string) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if _, ok := ws.endpoints[id]; !ok {
+		return webhooks.ErrEndpointNotFound
+	}
+
+	delete(ws.endpoints, id)
+
+	return nil
+}
+
+// AddEvent stores the event under its own ID, which is the ID of the outbox
+// record it comes from.
+func (ws *WebhooksRepositoryInMemory) AddEvent(_ context.Context, event *webhooks.Event) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	e := *event
+	ws.events[event.ID] = &e
+
+	return nil
+}
+
+func (ws *WebhooksRepositoryInMemory) GetEvent(_ context.Context, id string) (*webhooks.Event, error) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+
+	event, ok := ws.events[id]
+	if !ok {
+		return nil, nil
+	}
+
+	// Event data is never modified once stored, so it can be shared.
+	e := *event
+	return &e, nil
+}
+
+func (ws *WebhooksRepositoryInMemory) AddDelivery(_ context.Context, delivery *webhooks.Delivery) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+
+	delivery.ID = id.String()
+	ws.deliveries[delivery.ID] = cloneDelivery(delivery)
+
+	return nil
+}
+
+func (ws *WebhooksRepositoryInMemory) GetDelivery(_ context.Context, id string) (*webhooks.Delivery, error) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+
+	delivery, ok := ws.deliveries[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return cloneDelivery(delivery), nil
+}
+
+func (ws *WebhooksRepositoryInMemory) UpdateDelivery(_ context.Context, delivery *webhooks.Delivery) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if _, ok := ws.deliveries[delivery.ID]; !ok {
+		return webhooks.ErrDeliveryNotFound
+	}
+
+	ws.deliveries[delivery.ID] = cloneDelivery(delivery)
+
+	return nil
+}
+
+func (ws *WebhooksRepositoryInMemory) ListDeliveries(_ context.Context, query webhooks.DeliveriesQuery) ([]*webhooks.Delivery, error) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+
+	var found []*webhooks.Delivery
+	for _, delivery := range ws.deliveries {
+		if deliveryMatches(delivery, query) {
+			found = append(found, cloneDelivery(delivery))
+		}
+	}
+
+	sortDeliveries(found)
+
+	if query.Limit > 0 && len(found) > query.Limit {
+		found = found[:query.Limit]
+	}
+
+	return found, nil
+}
+
+func (ws *WebhooksRepositoryInMemory) ListDueDeliveries(_ context.Context, at time.Time, limit int) ([]*webhooks.Delivery, error) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+
+	var due []*webhooks.Delivery
+	for _, delivery := range ws.deliveries {
+		if delivery.Status != webhooks.DeliveryPending ||
+			delivery.NextAttemptAt == nil ||
+			delivery.NextAttemptAt.After(at) {
+			continue
+		}
+
+		due = append(due, cloneDelivery(delivery))
+	}
+
+	sortDeliveries(due)
+
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+
+	return due, nil
+}
+
+func deliveryMatches(delivery *webhooks.Delivery, query webhooks.DeliveriesQuery) bool {
+	return (query.AllMerchants || delivery.MerchantID == query.MerchantID) &&
+		(query.EndpointID == "" || delivery.EndpointID == query.EndpointID) &&
+		(query.EventID == "" || delivery.EventID == query.EventID) &&
+		(query.Status == "" || delivery.Status == query.Status)
+}
+
+// sortDeliveries orders deliveries by ID, UUIDv7 IDs sort in creation order.
+func sortDeliveries(deliveries []*webhooks.Delivery) {
+	slices.SortFunc(deliveries, func(a, b *webhooks.Delivery) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+}
+
+func cloneEndpoint(endpoint *webhooks.Endpoint) *webhooks.Endpoint {
+	e := *endpoint
+	e.Events = slices.Clone(endpoint.Events)
+	return &e
+}
+
+func cloneDelivery(delivery *webhooks.Delivery) *webhooks.Delivery {
+	d := *delivery
+	d.Attempts = slices.Clone(delivery.Attempts)
+	if delivery.NextAttemptAt != nil {
+		next := *delivery.NextAttemptAt
+		d.NextAttemptAt = &next
+	}
+	return &d
+}
@@ -0,0 +1,228 @@
+package threeds
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/google/uuid"
+)
+
+// DefaultSessionTTL is how long a simulated authentication session lasts
+// unless WithSessionTTL is given.
+const DefaultSessionTTL = 15 * time.Minute
+
+// Simulator is an in-process access control server used for local
+// development and tests. Challenges are completed either through its HTTP
+// handler or by calling Complete directly.
+type Simulator struct {
+	baseURL string
+	ttl     time.Duration
+	clock   clock.Clock
+
+	mu       sync.RWMutex
+	sessions map[string]*simulatedSession
+}
+
+type simulatedSession struct {
+	req       InitiateRequest // without the card number
+	result    *Result
+	expiresAt time.Time
+}
+
+// SimulatorOption configures optional settings of the Simulator.
+type SimulatorOption func(*Simulator)
+
+// WithSessionTTL sets how long sessions last. Expired sessions are dropped
+// and reported as not found.
+func WithSessionTTL(ttl time.Duration) SimulatorOption {
+	return func(s *Simulator) {
+		s.ttl = ttl
+	}
+}
+
+// WithSimulatorClock sets the clock sessions expire with.
+func WithSimulatorClock(clk clock.Clock) SimulatorOption {
+	return func(s *Simulator) {
+		s.clock = clk
+	}
+}
+
+// NewSimulator creates a simulated ACS whose challenge pages are served
+// under baseURL (e.g. http://localhost:8090/acs).
+func NewSimulator(baseURL string, opts ...SimulatorOption) *Simulator {
+	s := &Simulator{
+		baseURL:  baseURL,
+		ttl:      DefaultSessionTTL,
+		clock:    clock.New(),
+		sessions: map[string]*simulatedSession{},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *Simulator) Initiate(_ context.Context, req InitiateRequest) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+
+	// Abandoned sessions are dropped as new ones start.
+	for txID, session := range s.sessions {
+		if !now.Before(session.expiresAt) {
+			delete(s.sessions, txID)
+		}
+	}
+
+	// The challenge does not need the card number, so it is not kept.
+	req.CardNumber = ""
+
+	txID := uuid.NewString()
+	s.sessions[txID] = &simulatedSession{req: req, expiresAt: now.Add(s.ttl)}
+
+	return &Session{
+		TransactionID: txID,
+		ChallengeURL:  fmt.Sprintf("%s/challenges/%s", s.baseURL, txID),
+	}, nil
+}
+
+func (s *Simulator) Result(_ context.Context, transactionID string) (*Result, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.session(transactionID)
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	if session.result == nil {
+		return nil, ErrChallengeNotCompleted
+	}
+
+	res := *session.result
+	return &res, nil
+}
+
+// Complete records the cardholder answer to the challenge and returns the
+// callback URL the cardholder's browser posts to.
+func (s *Simulator) Complete(transactionID string, authenticated bool) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.session(transactionID)
+	if !ok {
+		return "", ErrSessionNotFound
+	}
+
+	result := &Result{
+		TransactionID: transactionID,
+		Status:        StatusFailed,
+		ECI:           "07",
+	}
+
+	if authenticated {
+		value := make([]byte, 20)
+		if _, err := rand.Read(value); err != nil {
+			return "", fmt.Errorf("generate authentication value: %w", err)
+		}
+
+		result.Status = StatusAuthenticated
+		result.ECI = "05"
+		result.AuthenticationValue = base64.StdEncoding.EncodeToString(value)
+	}
+
+	session.result = result
+
+	callback, err := url.Parse(session.req.CallbackURL)
+	if err != nil {
+		return "", fmt.Errorf("parse callback url: %w", err)
+	}
+
+	query := callback.Query()
+	query.Set("transaction_id", transactionID)
+	callback.RawQuery = query.Encode()
+
+	return callback.String(), nil
+}
+
+// session returns the session of the transaction unless it expired. The
+// caller must hold mu.
+func (s *Simulator) session(transactionID string) (*simulatedSession, bool) {
+	session, ok := s.sessions[transactionID]
+	if !ok || !s.clock.Now().Before(session.expiresAt) {
+		return nil, false
+	}
+	return session, true
+}
+
+var challengePage = template.Must(template.New("challenge").Parse(`<!DOCTYPE html>
+<html>
+<head><title>3-D Secure challenge</title></head>
+<body>
+  <h1>Simulated 3-D Secure challenge</h1>
+  <p>Merchant {{.MerchantID}} requests {{.Amount}} (minor units) in {{.Currency}}.</p>
+  <form method="post">
+    <button name="outcome" value="success">Authenticate</button>
+    <button name="outcome" value="failure">Fail authentication</button>
+  </form>
+</body>
+</html>
+`))
+
+// callbackPage posts to the callback as soon as it is loaded, the callback
+// authorizes the payment so it only accepts POST requests.
+var callbackPage = template.Must(template.New("callback").Parse(`<!DOCTYPE html>
+<html>
+<head><title>3-D Secure challenge</title></head>
+<body onload="document.forms[0].submit()">
+  <form method="post" action="{{.}}">
+    <p>Returning to the merchant.</p>
+    <noscript><button>Continue</button></noscript>
+  </form>
+</body>
+</html>
+`))
+
+// Handler serves the challenge pages. It expects to be mounted so that
+// request paths start with /challenges/{transaction_id}.
+func (s *Simulator) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /challenges/{id}", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		session, ok := s.session(r.PathValue("id"))
+		s.mu.RUnlock()
+
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = challengePage.Execute(w, session.req)
+	})
+
+	mux.HandleFunc("POST /challenges/{id}", func(w http.ResponseWriter, r *http.Request) {
+		callbackURL, err := s.Complete(r.PathValue("id"), r.FormValue("outcome") == "success")
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = callbackPage.Execute(w, callbackURL)
+	})
+
+	return mux
+}
@@ -0,0 +1,125 @@
+package threeds_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/threeds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulator_Challenge(t *testing.T) {
+	t.Parallel()
+
+	acs := threeds.NewSimulator("http://localhost:8090/acs")
+
+	session, err := acs.Initiate(context.Background(), threeds.InitiateRequest{
+		MerchantID:  "merchant_123",
+		Currency:    "EUR",
+		Amount:      1000,
+		CallbackURL: "http://localhost:8090/api/v1/payments/3ds/callback",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8090/acs/challenges/"+session.TransactionID, session.ChallengeURL)
+
+	_, err = acs.Result(context.Background(), session.TransactionID)
+	require.ErrorIs(t, err, threeds.ErrChallengeNotCompleted)
+
+	callbackURL, err := acs.Complete(session.TransactionID, true)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"http://localhost:8090/api/v1/payments/3ds/callback?transaction_id="+session.TransactionID,
+		callbackURL,
+	)
+
+	result, err := acs.Result(context.Background(), session.TransactionID)
+	require.NoError(t, err)
+	assert.Equal(t, threeds.StatusAuthenticated, result.Status)
+	assert.True(t, result.Status.Succeeded())
+	assert.Equal(t, "05", result.ECI)
+	assert.NotEmpty(t, result.AuthenticationValue)
+}
+
+func TestSimulator_Handler(t *testing.T) {
+	t.Parallel()
+
+	acs := threeds.NewSimulator("http://localhost:8090/acs")
+	handler := acs.Handler()
+
+	session, err := acs.Initiate(context.Background(), threeds.InitiateRequest{
+		CallbackURL: "http://localhost:8090/callback",
+	})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/challenges/"+session.TransactionID, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<form")
+
+	form := url.Values{"outcome": {"failure"}}
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/challenges/"+session.TransactionID,
+		strings.NewReader(form.Encode()),
+	)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(),
+		`<form method="post" action="http://localhost:8090/callback?transaction_id=`+session.TransactionID+`">`)
+
+	result, err := acs.Result(context.Background(), session.TransactionID)
+	require.NoError(t, err)
+	assert.Equal(t, threeds.StatusFailed, result.Status)
+	assert.False(t, result.Status.Succeeded())
+}
+
+func TestSimulator_UnknownSession(t *testing.T) {
+	t.Parallel()
+
+	acs := threeds.NewSimulator("http://localhost:8090/acs")
+
+	_, err := acs.Result(context.Background(), "unknown")
+	require.ErrorIs(t, err, threeds.ErrSessionNotFound)
+
+	_, err = acs.Complete("unknown", true)
+	require.ErrorIs(t, err, threeds.ErrSessionNotFound)
+}
+
+func TestSimulator_SessionExpiry(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	acs := threeds.NewSimulator("http://localhost:8090/acs",
+		threeds.WithSessionTTL(time.Minute),
+		threeds.WithSimulatorClock(clk),
+	)
+
+	session, err := acs.Initiate(context.Background(), threeds.InitiateRequest{
+		CardNumber:  "4111111111111111",
+		CallbackURL: "http://localhost:8090/callback",
+	})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	acs.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/challenges/"+session.TransactionID, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "4111111111111111")
+
+	clk.Advance(time.Minute)
+
+	_, err = acs.Complete(session.TransactionID, true)
+	require.ErrorIs(t, err, threeds.ErrSessionNotFound)
+	_, err = acs.Result(context.Background(), session.TransactionID)
+	require.ErrorIs(t, err, threeds.ErrSessionNotFound)
+}
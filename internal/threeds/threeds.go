@@ -0,0 +1,60 @@
+package threeds
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrSessionNotFound is returned when no authentication session matches
+	// the given transaction ID.
+	ErrSessionNotFound = errors.New("authentication session not found")
+	// ErrChallengeNotCompleted is returned when the cardholder has not
+	// completed the challenge yet.
+	ErrChallengeNotCompleted = errors.New("authentication challenge not completed")
+)
+
+// Authenticator performs 3-D Secure cardholder authentication against an
+// access control server (ACS).
+type Authenticator interface {
+	// Initiate starts an authentication session and returns where the
+	// cardholder must be redirected to complete the challenge.
+	Initiate(ctx context.Context, req InitiateRequest) (*Session, error)
+	// Result returns the outcome of a completed challenge.
+	Result(ctx context.Context, transactionID string) (*Result, error)
+}
+
+type InitiateRequest struct {
+	MerchantID  string
+	CardNumber  string
+	Currency    string
+	Amount      int64 // amount in minor units (e.g. cents)
+	CallbackURL string
+}
+
+type Session struct {
+	TransactionID string
+	ChallengeURL  string
+}
+
+// Status is the 3-D Secure transaction status.
+type Status string
+
+const (
+	StatusAuthenticated Status = "Y" // Cardholder successfully authenticated.
+	StatusAttempted     Status = "A" // Authentication attempted, liability shift still applies.
+	StatusFailed        Status = "N" // Cardholder failed authentication.
+	StatusRejected      Status = "R" // Issuer rejected the authentication.
+)
+
+// Succeeded reports whether the authorization can proceed with this outcome.
+func (s Status) Succeeded() bool {
+	return s == StatusAuthenticated || s == StatusAttempted
+}
+
+type Result struct {
+	TransactionID       string
+	Status              Status
+	ECI                 string // Electronic Commerce Indicator
+	AuthenticationValue string // Cryptogram (CAVV/AAV) proving the authentication
+}
@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LogAttrs returns the trace and span IDs of the span in the context, or
+// nothing when there is none.
+func LogAttrs(ctx context.Context) []any {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	return []any{
+		slog.String("trace_id", sc.TraceID().String()),
+		slog.String("span_id", sc.SpanID().String()),
+	}
+}
+
+// LogHandler adds the trace and span IDs to the records logged with a
+// context holding a span, e.g. through slog.InfoContext.
+type LogHandler struct {
+	slog.Handler
+}
+
+func NewLogHandler(next slog.Handler) *LogHandler {
+	return &LogHandler{Handler: next}
+}
+
+func (h *LogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs := LogAttrs(ctx); attrs != nil {
+		record = record.Clone()
+		record.Add(attrs...)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithGroup(name)}
+}
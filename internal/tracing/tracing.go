@@ -0,0 +1,119 @@
+// Package tracing sets up OpenTelemetry tracing: the exporters, the W3C trace
+// context propagation and the HTTP middleware starting the server spans.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+)
+
+const instrumentationName = "github.com/cko-recruitment/payment-gateway-challenge-go/internal/tracing"
+
+var ErrUnknownExporter = errors.New("unknown tracing exporter")
+
+// Exporter is where spans are sent.
+type Exporter string
+
+const (
+	// ExporterNone records no spans, incoming trace context is still
+	// propagated to the bank.
+	ExporterNone Exporter = "none"
+	// ExporterStdout prints spans, for local use.
+	ExporterStdout Exporter = "stdout"
+	// ExporterOTLP sends spans to an OpenTelemetry collector over HTTP.
+	ExporterOTLP Exporter = "otlp"
+)
+
+type settings struct {
+	serviceName    string
+	serviceVersion string
+	sampleRatio    float64
+	otlpEndpoint   string
+}
+
+// Option configures optional settings of the tracer provider.
+type Option func(*settings)
+
+// WithService sets the name and version spans are reported under.
+func WithService(name, version string) Option {
+	return func(s *settings) {
+		s.serviceName = name
+		s.serviceVersion = version
+	}
+}
+
+// WithSampleRatio sets the share of traces started here that are recorded.
+// Traces started by a caller follow its sampling decision.
+func WithSampleRatio(ratio float64) Option {
+	return func(s *settings) {
+		s.sampleRatio = ratio
+	}
+}
+
+// WithOTLPEndpoint sets the URL of the collector, e.g.
+// "http://localhost:4318". The standard OTEL_EXPORTER_OTLP_* environment
+// variables apply when it is not set.
+func WithOTLPEndpoint(url string) Option {
+	return func(s *settings) {
+		s.otlpEndpoint = url
+	}
+}
+
+// Setup installs the global tracer provider and the W3C trace context
+// propagator. The returned function flushes the pending spans and must be
+// called before exiting.
+func Setup(ctx context.Context, exporter Exporter, opts ...Option) (func(context.Context) error, error) {
+	s := settings{serviceName: "payment_gateway_api", sampleRatio: 1}
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		var otlpOpts []otlptracehttp.Option
+		if s.otlpEndpoint != "" {
+			otlpOpts = append(otlpOpts, otlptracehttp.WithEndpointURL(s.otlpEndpoint))
+		}
+		spanExporter, err = otlptracehttp.New(ctx, otlpOpts...)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownExporter, exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s exporter: %w", exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(s.serviceName),
+		semconv.ServiceVersion(s.serviceVersion),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("create resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(s.sampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
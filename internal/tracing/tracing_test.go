@@ -0,0 +1,136 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/tracing"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spans records the spans of every test. The middleware uses the global
+// tracer provider, so tests tell their spans apart by trace ID.
+var spans = tracetest.NewSpanRecorder()
+
+func TestMain(m *testing.M) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	os.Exit(m.Run())
+}
+
+func endedSpan(t *testing.T, traceID trace.TraceID) sdktrace.ReadOnlySpan {
+	t.Helper()
+
+	for _, span := range spans.Ended() {
+		if span.SpanContext().TraceID() == traceID {
+			return span
+		}
+	}
+
+	require.FailNow(t, "no span ended for trace", traceID.String())
+	return nil
+}
+
+func newRouter(handler http.HandlerFunc) http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(tracing.Middleware)
+	r.Get("/api/v1/payments/{id}", handler)
+	return r
+}
+
+func TestMiddleware_ContinuesCallerTrace(t *testing.T) {
+	t.Parallel()
+
+	var handlerTraceID trace.TraceID
+	router := newRouter(func(w http.ResponseWriter, r *http.Request) {
+		handlerTraceID = trace.SpanContextFromContext(r.Context()).TraceID()
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/payments/pay_123", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", handlerTraceID.String())
+
+	span := endedSpan(t, handlerTraceID)
+	assert.Equal(t, "GET /api/v1/payments/{id}", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.True(t, span.Parent().IsRemote())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Contains(t, span.Attributes(), attribute.String("http.route", "/api/v1/payments/{id}"))
+	assert.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", http.StatusInternalServerError))
+}
+
+func TestMiddleware_StartsTrace(t *testing.T) {
+	t.Parallel()
+
+	var handlerTraceID trace.TraceID
+	router := newRouter(func(w http.ResponseWriter, r *http.Request) {
+		handlerTraceID = trace.SpanContextFromContext(r.Context()).TraceID()
+		_, ok := w.(http.Flusher)
+		assert.True(t, ok, "the wrapped writer must keep http.Flusher")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/payments/pay_123", nil))
+
+	require.True(t, handlerTraceID.IsValid())
+
+	span := endedSpan(t, handlerTraceID)
+	assert.False(t, span.Parent().IsValid())
+	assert.Equal(t, codes.Unset, span.Status().Code)
+	assert.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", http.StatusOK))
+}
+
+func TestLogHandler(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := slog.New(tracing.NewLogHandler(slog.NewJSONHandler(&buf, nil))).With("component", "test")
+
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	}))
+
+	logger.InfoContext(ctx, "traced")
+	logger.Info("untraced")
+
+	dec := json.NewDecoder(&buf)
+
+	var traced map[string]any
+	require.NoError(t, dec.Decode(&traced))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traced["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", traced["span_id"])
+	assert.Equal(t, "test", traced["component"])
+
+	var untraced map[string]any
+	require.NoError(t, dec.Decode(&untraced))
+	assert.NotContains(t, untraced, "trace_id")
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	t.Parallel()
+
+	_, err := tracing.Setup(context.Background(), "jaeger")
+	assert.ErrorIs(t, err, tracing.ErrUnknownExporter)
+}
@@ -0,0 +1,255 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"golang.org/x/sync/errgroup"
+)
+
+// RetryPolicy controls how failed deliveries are retried. The wait before
+// attempt n+1 is InitialInterval * 2^(n-1), capped at MaxInterval.
+type RetryPolicy struct {
+	MaxAttempts     int
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+}
+
+// DefaultRetryPolicy retries for roughly a day before giving up.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:     10,
+	InitialInterval: 30 * time.Second,
+	MaxInterval:     6 * time.Hour,
+}
+
+func (p RetryPolicy) backoff(attempts int) time.Duration {
+	wait := p.InitialInterval
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= p.MaxInterval {
+			return p.MaxInterval
+		}
+	}
+	return wait
+}
+
+const (
+	defaultDispatcherWorkers = 4
+	defaultDispatcherBatch   = 100
+	defaultDeliveryTimeout   = 10 * time.Second
+)
+
+// Dispatcher sends due deliveries to the merchant endpoints and schedules
+// retries of the failed ones.
+type Dispatcher struct {
+	store   Store
+	client  *http.Client
+	clock   clock.Clock
+	retry   RetryPolicy
+	workers int
+}
+
+// DispatcherOption configures optional dependencies of the Dispatcher.
+type DispatcherOption func(*Dispatcher)
+
+// WithHTTPClient sets the client used to call merchant endpoints. Unless
+// endpoints on private networks are allowed, it should be built by
+// NewHTTPClient.
+func WithHTTPClient(client *http.Client) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+// WithDispatcherClock sets the clock used to schedule retries.
+func WithDispatcherClock(clk clock.Clock) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.clock = clk
+	}
+}
+
+// WithRetryPolicy sets how failed deliveries are retried.
+func WithRetryPolicy(policy RetryPolicy) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.retry = policy
+	}
+}
+
+// WithWorkers sets how many deliveries are sent concurrently.
+func WithWorkers(n int) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.workers = n
+	}
+}
+
+func NewDispatcher(store Store, opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{
+		store:   store,
+		client:  NewHTTPClient(defaultDeliveryTimeout),
+		clock:   clock.New(),
+		retry:   DefaultRetryPolicy,
+		workers: defaultDispatcherWorkers,
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// Run sends due deliveries every interval until the context is cancelled.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := d.DispatchDue(ctx); err != nil {
+				slog.Error("dispatching webhooks", "error", err)
+			}
+		}
+	}
+}
+
+// DispatchDue attempts every delivery that is due. It returns how many
+// deliveries were attempted.
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	due, err := d.store.ListDueDeliveries(ctx, d.clock.Now().UTC(), defaultDispatcherBatch)
+	if err != nil {
+		return 0, fmt.Errorf("list due deliveries: %w", err)
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(d.workers)
+
+	for _, delivery := range due {
+		g.Go(func() error {
+			return d.dispatch(ctx, delivery)
+		})
+	}
+
+	return len(due), g.Wait()
+}
+
+// dispatch makes one attempt and records its outcome on the delivery.
+func (d *Dispatcher) dispatch(ctx context.Context, delivery *Delivery) error {
+	endpoint, err := d.store.GetEndpoint(ctx, delivery.EndpointID)
+	if err != nil {
+		return fmt.Errorf("get endpoint: %w", err)
+	}
+
+	event, err := d.store.GetEvent(ctx, delivery.EventID)
+	if err != nil {
+		return fmt.Errorf("get event: %w", err)
+	}
+
+	var attempt Attempt
+	switch {
+	case endpoint == nil:
+		attempt = Attempt{AttemptedAt: d.clock.Now().UTC(), Error: ErrEndpointNotFound.Error()}
+		delivery.Attempts = append(delivery.Attempts, attempt)
+		d.finish(delivery, DeliveryFailed)
+
+	case event == nil:
+		attempt = Attempt{AttemptedAt: d.clock.Now().UTC(), Error: "event not found"}
+		delivery.Attempts = append(delivery.Attempts, attempt)
+		d.finish(delivery, DeliveryFailed)
+
+	default:
+		attempt = d.send(ctx, endpoint, event)
+		delivery.Attempts = append(delivery.Attempts, attempt)
+		d.schedule(delivery, attempt)
+	}
+
+	if err := d.store.UpdateDelivery(ctx, delivery); err != nil {
+		return fmt.Errorf("update delivery %s: %w", delivery.ID, err)
+	}
+
+	if attempt.Error != "" {
+		slog.WarnContext(ctx, "webhook delivery attempt failed",
+			"delivery_id", delivery.ID,
+			"endpoint_id", delivery.EndpointID,
+			"attempt", len(delivery.Attempts),
+			"error", attempt.Error,
+		)
+	}
+
+	return nil
+}
+
+// schedule moves the delivery to its next state after an attempt.
+func (d *Dispatcher) schedule(delivery *Delivery, attempt Attempt) {
+	if attempt.Error == "" {
+		d.finish(delivery, DeliverySucceeded)
+		return
+	}
+
+	if len(delivery.Attempts) >= d.retry.MaxAttempts {
+		d.finish(delivery, DeliveryFailed)
+		return
+	}
+
+	next := attempt.AttemptedAt.Add(d.retry.backoff(len(delivery.Attempts)))
+	delivery.NextAttemptAt = &next
+	delivery.UpdatedAt = attempt.AttemptedAt
+}
+
+func (d *Dispatcher) finish(delivery *Delivery, status DeliveryStatus) {
+	delivery.Status = status
+	delivery.NextAttemptAt = nil
+	delivery.UpdatedAt = d.clock.Now().UTC()
+}
+
+func (d *Dispatcher) send(ctx context.Context, endpoint *Endpoint, event *Event) Attempt {
+	start := d.clock.Now().UTC()
+	attempt := Attempt{AttemptedAt: start}
+
+	err := func() error {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("encode event: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("build request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(EventIDHeader, event.ID)
+		req.Header.Set(EventTypeHeader, string(event.Type))
+		req.Header.Set(SignatureHeader, Sign(endpoint.Secret, start, payload))
+
+		res, err := d.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+		attempt.StatusCode = res.StatusCode
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return fmt.Errorf("unexpected status code %d", res.StatusCode)
+		}
+
+		return nil
+	}()
+
+	attempt.DurationMS = d.clock.Now().Sub(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	return attempt
+}
@@ -0,0 +1,286 @@
+package webhooks_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/outbox"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/webhooks"
+	"github.com/stretchr/testify/require"
+)
+
+var webhooksNow = time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC)
+
+type webhooksFixture struct {
+	clock      *clock.Fake
+	store      *repository.WebhooksRepositoryInMemory
+	service    *webhooks.Service
+	dispatcher *webhooks.Dispatcher
+}
+
+func newWebhooksFixture() *webhooksFixture {
+	clk := clock.NewFake(webhooksNow)
+	store := repository.NewWebhooksRepositoryInMemory()
+
+	return &webhooksFixture{
+		clock: clk,
+		store: store,
+		// Test endpoints are served on the loopback.
+		service: webhooks.NewService(store, webhooks.WithServiceClock(clk), webhooks.WithPrivateNetworks()),
+		dispatcher: webhooks.NewDispatcher(
+			store,
+			webhooks.WithDispatcherClock(clk),
+			webhooks.WithHTTPClient(&http.Client{Timeout: 5 * time.Second}),
+			webhooks.WithRetryPolicy(webhooks.RetryPolicy{
+				MaxAttempts:     3,
+				InitialInterval: time.Minute,
+				MaxInterval:     time.Hour,
+			}),
+		),
+	}
+}
+
+func authorizedRecord(merchantID string) *outbox.Record {
+	return &outbox.Record{
+		ID:         "rec_" + merchantID,
+		Sequence:   1,
+		PaymentID:  "pay_1",
+		MerchantID: merchantID,
+		Type:       payments.EventPaymentAuthorized,
+		Payload:    []byte(`{"id":"pay_1","status":"authorized"}`),
+		OccurredAt: webhooksNow,
+	}
+}
+
+func TestDispatcher_DispatchDue_Succeeded(t *testing.T) {
+	t.Parallel()
+
+	f := newWebhooksFixture()
+
+	var received atomic.Int32
+	var endpoint *webhooks.Endpoint
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, string(payments.EventPaymentAuthorized), r.Header.Get(webhooks.EventTypeHeader))
+		require.NotEmpty(t, r.Header.Get(webhooks.EventIDHeader))
+		require.NoError(t, webhooks.Verify(endpoint.Secret, r.Header.Get(webhooks.SignatureHeader), body, 0, webhooksNow))
+		received.Add(1)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	endpoint, err := f.service.CreateEndpoint(ctx, "merchant_a", server.URL, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, endpoint.Secret)
+
+	require.NoError(t, f.service.Publish(ctx, authorizedRecord("merchant_a")))
+
+	n, err := f.dispatcher.DispatchDue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.EqualValues(t, 1, received.Load())
+
+	deliveries, err := f.service.ListDeliveries(ctx, webhooks.DeliveriesQuery{MerchantID: "merchant_a"})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, webhooks.DeliverySucceeded, deliveries[0].Status)
+	require.Len(t, deliveries[0].Attempts, 1)
+	require.Equal(t, http.StatusOK, deliveries[0].Attempts[0].StatusCode)
+	require.Nil(t, deliveries[0].NextAttemptAt)
+
+	n, err = f.dispatcher.DispatchDue(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestDispatcher_DispatchDue_RetriesWithBackoff(t *testing.T) {
+	t.Parallel()
+
+	f := newWebhooksFixture()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	_, err := f.service.CreateEndpoint(ctx, "merchant_a", server.URL, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, f.service.Publish(ctx, authorizedRecord("merchant_a")))
+
+	delivery := func() *webhooks.Delivery {
+		deliveries, err := f.service.ListDeliveries(ctx, webhooks.DeliveriesQuery{MerchantID: "merchant_a"})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		return deliveries[0]
+	}
+
+	_, err = f.dispatcher.DispatchDue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, webhooks.DeliveryPending, delivery().Status)
+	require.Equal(t, webhooksNow.Add(time.Minute), *delivery().NextAttemptAt)
+
+	// Not due yet.
+	n, err := f.dispatcher.DispatchDue(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	f.clock.Advance(time.Minute)
+	_, err = f.dispatcher.DispatchDue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, webhooksNow.Add(3*time.Minute), *delivery().NextAttemptAt)
+
+	f.clock.Advance(2 * time.Minute)
+	_, err = f.dispatcher.DispatchDue(ctx)
+	require.NoError(t, err)
+
+	d := delivery()
+	require.Equal(t, webhooks.DeliveryFailed, d.Status)
+	require.Len(t, d.Attempts, 3)
+	require.Equal(t, http.StatusServiceUnavailable, d.Attempts[2].StatusCode)
+	require.Nil(t, d.NextAttemptAt)
+}
+
+func TestService_Notify_OnlySubscribedEndpoints(t *testing.T) {
+	t.Parallel()
+
+	f := newWebhooksFixture()
+	ctx := context.Background()
+
+	subscribed, err := f.service.CreateEndpoint(ctx, "merchant_a", "https://a.example/hooks", []payments.EventType{payments.EventPaymentAuthorized})
+	require.NoError(t, err)
+	_, err = f.service.CreateEndpoint(ctx, "merchant_a", "https://a.example/declines", []payments.EventType{payments.EventPaymentDeclined})
+	require.NoError(t, err)
+	_, err = f.service.CreateEndpoint(ctx, "merchant_b", "https://b.example/hooks", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, f.service.Publish(ctx, authorizedRecord("merchant_a")))
+
+	deliveries, err := f.store.ListDeliveries(ctx, webhooks.DeliveriesQuery{AllMerchants: true})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, subscribed.ID, deliveries[0].EndpointID)
+	require.Equal(t, payments.EventPaymentAuthorized, deliveries[0].EventType)
+}
+
+func TestService_Publish_Idempotent(t *testing.T) {
+	t.Parallel()
+
+	f := newWebhooksFixture()
+	ctx := context.Background()
+
+	_, err := f.service.CreateEndpoint(ctx, "merchant_a", "https://a.example/hooks", nil)
+	require.NoError(t, err)
+
+	record := authorizedRecord("merchant_a")
+	require.NoError(t, f.service.Publish(ctx, record))
+	require.NoError(t, f.service.Publish(ctx, record))
+
+	deliveries, err := f.store.ListDeliveries(ctx, webhooks.DeliveriesQuery{MerchantID: "merchant_a"})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, record.ID, deliveries[0].EventID)
+}
+
+func TestService_CreateEndpoint_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		url    string
+		events []payments.EventType
+		field  string
+	}{
+		{name: "relative url", url: "/hooks", field: "url"},
+		{name: "unsupported scheme", url: "ftp://a.example/hooks", field: "url"},
+		{name: "unknown event", url: "https://a.example/hooks", events: []payments.EventType{"payment.unknown"}, field: "events"},
+		{name: "loopback address", url: "http://127.0.0.1:8090/hooks", field: "url"},
+		{name: "IPv4-mapped loopback address", url: "http://[::ffff:127.0.0.1]/hooks", field: "url"},
+		{name: "local host", url: "http://localhost/hooks", field: "url"},
+		{name: "private address", url: "https://10.0.0.1/hooks", field: "url"},
+		{name: "link-local address", url: "http://169.254.169.254/latest/meta-data", field: "url"},
+		{name: "unspecified address", url: "http://[::]/hooks", field: "url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			service := webhooks.NewService(repository.NewWebhooksRepositoryInMemory())
+			_, err := service.CreateEndpoint(context.Background(), "merchant_a", tt.url, tt.events)
+
+			var invalidEndpointErr *webhooks.InvalidEndpointErr
+			require.ErrorAs(t, err, &invalidEndpointErr)
+			require.Equal(t, tt.field, invalidEndpointErr.Field)
+		})
+	}
+}
+
+func TestService_ListEndpoints_HidesSecrets(t *testing.T) {
+	t.Parallel()
+
+	f := newWebhooksFixture()
+	ctx := context.Background()
+
+	_, err := f.service.CreateEndpoint(ctx, "merchant_a", "https://a.example/hooks", nil)
+	require.NoError(t, err)
+
+	endpoints, err := f.service.ListEndpoints(ctx, "merchant_a")
+	require.NoError(t, err)
+	require.Len(t, endpoints, 1)
+	require.Empty(t, endpoints[0].Secret)
+}
+
+func TestService_Redeliver(t *testing.T) {
+	t.Parallel()
+
+	f := newWebhooksFixture()
+	ctx := context.Background()
+
+	_, err := f.service.CreateEndpoint(ctx, "merchant_a", "https://a.example/hooks", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, f.service.Publish(ctx, authorizedRecord("merchant_a")))
+
+	deliveries, err := f.service.ListDeliveries(ctx, webhooks.DeliveriesQuery{MerchantID: "merchant_a"})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+
+	_, err = f.service.Redeliver(ctx, "merchant_b", deliveries[0].ID)
+	require.ErrorIs(t, err, webhooks.ErrDeliveryNotFound)
+
+	redelivery, err := f.service.Redeliver(ctx, "merchant_a", deliveries[0].ID)
+	require.NoError(t, err)
+	require.NotEqual(t, deliveries[0].ID, redelivery.ID)
+	require.Equal(t, deliveries[0].EventID, redelivery.EventID)
+	require.Equal(t, webhooks.DeliveryPending, redelivery.Status)
+	require.Equal(t, webhooksNow, *redelivery.NextAttemptAt)
+}
+
+func TestNewHTTPClient_RefusesForbiddenAddresses(t *testing.T) {
+	t.Parallel()
+
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+	}))
+	defer server.Close()
+
+	// The name is only resolved when dialing, as a rebinding name would be.
+	url := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	_, err := webhooks.NewHTTPClient(time.Second).Get(url)
+	require.ErrorIs(t, err, webhooks.ErrForbiddenAddress)
+	require.Zero(t, received.Load())
+}
@@ -0,0 +1,73 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when calling an endpoint that resolves to
+// an address merchants must not reach, such as the loopback or a private
+// network of the gateway.
+var ErrForbiddenAddress = errors.New("endpoint address is not allowed")
+
+// forbiddenAddress reports whether the address belongs to the host or the
+// networks of the gateway rather than to the internet.
+func forbiddenAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsUnspecified()
+}
+
+// forbiddenHost reports whether the host of an endpoint URL is a forbidden
+// address or names the local host. Other names are checked once resolved,
+// when dialing.
+func forbiddenHost(host string) bool {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return forbiddenAddress(addr)
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	return host == "localhost" || strings.HasSuffix(host, ".localhost")
+}
+
+// checkDialAddress is a net.Dialer control function refusing forbidden
+// addresses. It runs on every address dialed, after name resolution, so a
+// name resolving to a private address once the endpoint is registered is
+// refused too.
+func checkDialAddress(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if forbiddenAddress(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// NewHTTPClient returns a client for merchant endpoints, giving up after
+// timeout and refusing to connect to forbidden addresses, redirects
+// included. It never uses a proxy, which would connect on its behalf.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control:   checkDialAddress,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
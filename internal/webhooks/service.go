@@ -0,0 +1,241 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/clock"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/outbox"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+)
+
+// secretPrefix makes signing secrets easy to recognise, e.g. in leaked logs.
+const secretPrefix = "whsec_"
+
+// Service manages the endpoints of merchants and turns payment events into
+// deliveries, which the Dispatcher sends in the background.
+type Service struct {
+	store Store
+	clock clock.Clock
+	// privateNetworks allows endpoints on the loopback and private
+	// networks, for local development.
+	privateNetworks bool
+}
+
+// ServiceOption configures optional dependencies of the Service.
+type ServiceOption func(*Service)
+
+// WithServiceClock sets the clock used to schedule deliveries.
+func WithServiceClock(clk clock.Clock) ServiceOption {
+	return func(s *Service) {
+		s.clock = clk
+	}
+}
+
+// WithPrivateNetworks allows registering endpoints on the loopback and
+// private networks, which merchants must not reach in production. The
+// Dispatcher must then be given a client allowing them too.
+func WithPrivateNetworks() ServiceOption {
+	return func(s *Service) {
+		s.privateNetworks = true
+	}
+}
+
+func NewService(store Store, opts ...ServiceOption) *Service {
+	s := &Service{
+		store: store,
+		clock: clock.New(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// CreateEndpoint registers a new endpoint for the merchant. The returned
+// endpoint is the only one carrying the signing secret.
+func (s *Service) CreateEndpoint(ctx context.Context, merchantID, rawURL string, events []payments.EventType) (*Endpoint, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, &InvalidEndpointErr{Field: "url", Message: "url must be an absolute http or https URL"}
+	}
+	if !s.privateNetworks && forbiddenHost(u.Hostname()) {
+		return nil, &InvalidEndpointErr{Field: "url", Message: "url must not point to a loopback, private, link-local or unspecified address"}
+	}
+
+	for _, t := range events {
+		if !slices.Contains(payments.EventTypes, t) {
+			return nil, &InvalidEndpointErr{Field: "events", Message: fmt.Sprintf("unknown event type %q", t)}
+		}
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, fmt.Errorf("generate secret: %w", err)
+	}
+
+	endpoint := &Endpoint{
+		MerchantID: merchantID,
+		URL:        u.String(),
+		Events:     slices.Clone(events),
+		Secret:     secret,
+		CreatedAt:  s.clock.Now().UTC(),
+	}
+
+	if err := s.store.AddEndpoint(ctx, endpoint); err != nil {
+		return nil, fmt.Errorf("persist endpoint: %w", err)
+	}
+
+	return endpoint, nil
+}
+
+// ListEndpoints returns the endpoints of the merchant, without their secrets.
+func (s *Service) ListEndpoints(ctx context.Context, merchantID string) ([]*Endpoint, error) {
+	endpoints, err := s.store.ListEndpoints(ctx, merchantID)
+	if err != nil {
+		return nil, fmt.Errorf("list endpoints: %w", err)
+	}
+
+	for _, e := range endpoints {
+		e.Secret = ""
+	}
+
+	return endpoints, nil
+}
+
+// DeleteEndpoint removes an endpoint of the merchant. Pending deliveries to
+// it fail on their next attempt.
+func (s *Service) DeleteEndpoint(ctx context.Context, merchantID, id string) error {
+	endpoint, err := s.store.GetEndpoint(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get endpoint: %w", err)
+	}
+
+	if endpoint == nil || endpoint.MerchantID != merchantID {
+		return ErrEndpointNotFound
+	}
+
+	return s.store.DeleteEndpoint(ctx, id)
+}
+
+// ListDeliveries returns the delivery logs matching the query.
+func (s *Service) ListDeliveries(ctx context.Context, query DeliveriesQuery) ([]*Delivery, error) {
+	deliveries, err := s.store.ListDeliveries(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver schedules a new delivery of the same event to the same endpoint,
+// to be attempted right away. The original delivery and its log are kept.
+func (s *Service) Redeliver(ctx context.Context, merchantID, deliveryID string) (*Delivery, error) {
+	original, err := s.store.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("get delivery: %w", err)
+	}
+
+	if original == nil || original.MerchantID != merchantID {
+		return nil, ErrDeliveryNotFound
+	}
+
+	endpoint, err := s.store.GetEndpoint(ctx, original.EndpointID)
+	if err != nil {
+		return nil, fmt.Errorf("get endpoint: %w", err)
+	}
+
+	if endpoint == nil {
+		return nil, ErrEndpointNotFound
+	}
+
+	delivery := s.newDelivery(original.EventID, original.EventType, endpoint)
+	if err := s.store.AddDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("persist delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// Publish records the event and schedules its delivery to every subscribed
+// endpoint of the merchant. It implements outbox.EventPublisher; the event
+// takes the ID of the outbox record and publishing a record again only adds
+// the deliveries that are missing, so each endpoint receives it once.
+func (s *Service) Publish(ctx context.Context, record *outbox.Record) error {
+	endpoints, err := s.store.ListEndpoints(ctx, record.MerchantID)
+	if err != nil {
+		return fmt.Errorf("list endpoints: %w", err)
+	}
+
+	endpoints = slices.DeleteFunc(endpoints, func(endpoint *Endpoint) bool {
+		return !endpoint.Subscribed(record.Type)
+	})
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	event, err := s.store.GetEvent(ctx, record.ID)
+	if err != nil {
+		return fmt.Errorf("get event: %w", err)
+	}
+
+	if event == nil {
+		event = &Event{
+			ID:         record.ID,
+			Type:       record.Type,
+			MerchantID: record.MerchantID,
+			CreatedAt:  record.OccurredAt,
+			Data:       record.Payload,
+		}
+		if err := s.store.AddEvent(ctx, event); err != nil {
+			return fmt.Errorf("persist event: %w", err)
+		}
+	}
+
+	existing, err := s.store.ListDeliveries(ctx, DeliveriesQuery{MerchantID: event.MerchantID, EventID: event.ID})
+	if err != nil {
+		return fmt.Errorf("list deliveries: %w", err)
+	}
+
+	for _, endpoint := range endpoints {
+		if slices.ContainsFunc(existing, func(d *Delivery) bool { return d.EndpointID == endpoint.ID }) {
+			continue
+		}
+
+		if err := s.store.AddDelivery(ctx, s.newDelivery(event.ID, event.Type, endpoint)); err != nil {
+			return fmt.Errorf("persist delivery: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) newDelivery(eventID string, eventType payments.EventType, endpoint *Endpoint) *Delivery {
+	now := s.clock.Now().UTC()
+
+	return &Delivery{
+		EventID:       eventID,
+		EventType:     eventType,
+		EndpointID:    endpoint.ID,
+		MerchantID:    endpoint.MerchantID,
+		Status:        DeliveryPending,
+		Attempts:      []Attempt{},
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretPrefix + hex.EncodeToString(b), nil
+}
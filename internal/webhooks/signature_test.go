@@ -0,0 +1,79 @@
+package webhooks_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/webhooks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignature(t *testing.T) {
+	t.Parallel()
+
+	signedAt := time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC)
+	payload := []byte(`{"id":"evt_1"}`)
+	header := webhooks.Sign("whsec_test", signedAt, payload)
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		payload []byte
+		now     time.Time
+		wantErr bool
+	}{
+		{
+			name:    "valid signature",
+			secret:  "whsec_test",
+			header:  header,
+			payload: payload,
+			now:     signedAt.Add(time.Minute),
+		},
+		{
+			name:    "wrong secret",
+			secret:  "whsec_other",
+			header:  header,
+			payload: payload,
+			now:     signedAt,
+			wantErr: true,
+		},
+		{
+			name:    "tampered payload",
+			secret:  "whsec_test",
+			header:  header,
+			payload: []byte(`{"id":"evt_2"}`),
+			now:     signedAt,
+			wantErr: true,
+		},
+		{
+			name:    "outside of the tolerance",
+			secret:  "whsec_test",
+			header:  header,
+			payload: payload,
+			now:     signedAt.Add(10 * time.Minute),
+			wantErr: true,
+		},
+		{
+			name:    "malformed header",
+			secret:  "whsec_test",
+			header:  "v1=abc",
+			payload: payload,
+			now:     signedAt,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := webhooks.Verify(tt.secret, tt.header, tt.payload, 5*time.Minute, tt.now)
+			if tt.wantErr {
+				require.ErrorIs(t, err, webhooks.ErrInvalidSignature)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// Package webhooks notifies merchants about payment status changes by
+// POSTing signed events to the endpoints they registered.
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/payments"
+)
+
+var (
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// InvalidEndpointErr is returned when an endpoint registration is invalid.
+type InvalidEndpointErr struct {
+	Field   string
+	Message string
+}
+
+func (e *InvalidEndpointErr) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Endpoint is a merchant URL receiving webhook events.
+type Endpoint struct {
+	ID         string               `json:"id"`
+	MerchantID string               `json:"merchant_id"`
+	URL        string               `json:"url"`
+	Events     []payments.EventType `json:"events"`           // Subscribed events, every event when empty.
+	Secret     string               `json:"secret,omitempty"` // Signing secret, only returned when the endpoint is created.
+	CreatedAt  time.Time            `json:"created_at"`
+}
+
+// Subscribed reports whether the endpoint wants to receive the event type.
+func (e *Endpoint) Subscribed(eventType payments.EventType) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, t := range e.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is the payload POSTed to endpoints.
+type Event struct {
+	ID         string             `json:"id"`
+	Type       payments.EventType `json:"type"`
+	MerchantID string             `json:"merchant_id"`
+	CreatedAt  time.Time          `json:"created_at"`
+	Data       json.RawMessage    `json:"data" swaggertype:"object"`
+}
+
+// DeliveryStatus is the state of the delivery of an event to an endpoint.
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery tracks the delivery of one event to one endpoint, along with the
+// log of every attempt made.
+type Delivery struct {
+	ID            string             `json:"id"`
+	EventID       string             `json:"event_id"`
+	EventType     payments.EventType `json:"event_type"`
+	EndpointID    string             `json:"endpoint_id"`
+	MerchantID    string             `json:"merchant_id"`
+	Status        DeliveryStatus     `json:"status" enums:"pending,succeeded,failed"`
+	Attempts      []Attempt          `json:"attempts"`
+	NextAttemptAt *time.Time         `json:"next_attempt_at,omitempty"` // Set while the delivery is pending.
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+// Attempt records one HTTP call made to deliver an event.
+type Attempt struct {
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode  int       `json:"status_code,omitempty"` // HTTP status returned by the endpoint, if any.
+	Error       string    `json:"error,omitempty"`
+	DurationMS  int64     `json:"duration_ms"`
+}
+
+// DeliveriesQuery filters the deliveries of a merchant. Other empty fields
+// match every delivery.
+type DeliveriesQuery struct {
+	MerchantID string
+	// AllMerchants matches the deliveries of every merchant instead of
+	// those of MerchantID, for the gateway's own use only.
+	AllMerchants bool
+	EndpointID   string
+	EventID      string
+	Status       DeliveryStatus
+	Limit        int
+}
+
+// Store persists endpoints, events and deliveries. Getters return nil and no
+// error when nothing is found.
+type Store interface {
+	AddEndpoint(ctx context.Context, endpoint *Endpoint) error
+	GetEndpoint(ctx context.Context, id string) (*Endpoint, error)
+	ListEndpoints(ctx context.Context, merchantID string) ([]*Endpoint, error)
+	DeleteEndpoint(ctx context.Context, id string) error
+
+	AddEvent(ctx context.Context, event *Event) error
+	GetEvent(ctx context.Context, id string) (*Event, error)
+
+	AddDelivery(ctx context.Context, delivery *Delivery) error
+	GetDelivery(ctx context.Context, id string) (*Delivery, error)
+	UpdateDelivery(ctx context.Context, delivery *Delivery) error
+	ListDeliveries(ctx context.Context, query DeliveriesQuery) ([]*Delivery, error)
+	// ListDueDeliveries returns pending deliveries whose next attempt is at
+	// or before the given time, oldest first.
+	ListDueDeliveries(ctx context.Context, at time.Time, limit int) ([]*Delivery, error)
+}
@@ -0,0 +1,46 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth_ReadyWithDependenciesUp(t *testing.T) {
+	t.Parallel()
+
+	apiURL := os.Getenv("TEST_API_BASE_URL")
+	if apiURL == "" {
+		apiURL = "http://localhost:8090"
+	}
+
+	client := NewTestClient(apiURL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var liveness struct {
+		Status string `json:"status"`
+	}
+	resp, err := client.Get(ctx, "/healthz", &liveness)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "up", liveness.Status)
+
+	var report struct {
+		Status string `json:"status"`
+		Checks map[string]struct {
+			Status string `json:"status"`
+		} `json:"checks"`
+	}
+	resp, err = client.Get(ctx, "/readyz", &report)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "up", report.Status)
+	require.Equal(t, "up", report.Checks["repository"].Status)
+	require.Equal(t, "up", report.Checks["bank"].Status)
+}
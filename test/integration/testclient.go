@@ -9,14 +9,19 @@ import (
 	"time"
 )
 
+// TestMerchantID is the merchant the test client sends requests for.
+const TestMerchantID = "merchant_e2e"
+
 type TestClient struct {
-	BaseURL string
-	Client  *http.Client
+	BaseURL    string
+	MerchantID string
+	Client     *http.Client
 }
 
 func NewTestClient(baseURL string) *TestClient {
 	return &TestClient{
-		BaseURL: baseURL,
+		BaseURL:    baseURL,
+		MerchantID: TestMerchantID,
 		Client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -45,6 +50,7 @@ func (c *TestClient) Post(
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Merchant-ID", c.MerchantID)
 
 	resp, err := c.Client.Do(req)
 	if err != nil {
@@ -65,6 +71,7 @@ func (c *TestClient) Get(
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("X-Merchant-ID", c.MerchantID)
 
 	resp, err := c.Client.Do(req)
 	if err != nil {